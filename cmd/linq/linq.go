@@ -1,21 +1,29 @@
 package linq
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/urfave/cli"
 
 	"land-bridge/conf"
+	"land-bridge/handle/dao"
 	"land-bridge/handle/listener"
 	"land-bridge/network"
+	"land-bridge/network/node"
+	"land-bridge/utils"
 )
 
 var (
-	configFile string
+	configFile      string
+	shutdownTimeout time.Duration
 )
 
 var LinQFlags = []cli.Flag{
@@ -25,54 +33,137 @@ var LinQFlags = []cli.Flag{
 		Value:       "./conf/config_devnet.json",
 		Destination: &configFile,
 	},
+	cli.DurationFlag{
+		Name:        "shutdown-timeout",
+		Usage:       "how long in-flight relays are drained on shutdown before they are cancelled",
+		Value:       30 * time.Second,
+		Destination: &shutdownTimeout,
+	},
 	network.NodeKeyFileFlag,
 }
 
 func StartServer(ctx *cli.Context) {
-	for {
-		startServer(ctx)
-		sig := waitSignal()
-		stopServer()
-		if sig != syscall.SIGHUP {
-			break
-		} else {
-			continue
-		}
+	if args := ctx.Args(); len(args) > 0 {
+		logs.Error(fmt.Errorf("invalid command: %q", args[0]))
 	}
+
+	config, err := conf.NewConfig(configFile)
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	if err := checkSchema(config.DBConfig); err != nil {
+		utils.Fatalf("Database schema check failed: %v", err)
+	}
+
+	listener.StartCrossChainListen(config.Chains, config.Relay, config.DBConfig)
+	srv := &server{config: config, stack: network.StartNetWork(ctx, config)}
+	srv.stack.SetReloader(srv.reload)
+
+	srv.waitSignal()
+	srv.stop()
 }
 
-func startServer(ctx *cli.Context) {
-	config := conf.NewConfig(configFile)
-	if config == nil {
-		logs.Error("startServer - read config failed!")
-		return
+// server keeps the running configuration so a reload can tell which
+// sections changed.
+type server struct {
+	mu     sync.Mutex
+	config *conf.Config
+	stack  *node.Node
+}
+
+// reload re-reads the config file and applies the chain listener, relay and
+// retry sections in place. Database and p2p settings only take effect after a
+// restart.
+func (s *server) reload() error {
+	config, err := conf.NewConfig(configFile)
+	if err != nil {
+		return err
 	}
 
-	if args := ctx.Args(); len(args) > 0 {
-		logs.Error(fmt.Errorf("invalid command: %q", args[0]))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if config.RunMode != s.config.RunMode {
+		return fmt.Errorf("RunMode changed from %s to %s, restart the node to apply it", s.config.RunMode, config.RunMode)
+	}
+	if !reflect.DeepEqual(config.DBConfig, s.config.DBConfig) {
+		logs.Warn("DBConfig changed, it is ignored until the node restarts")
+	}
+	if !reflect.DeepEqual(config.LinQConfig, s.config.LinQConfig) {
+		logs.Warn("LinQConfig changed, it is ignored until the node restarts")
+	}
+	if !reflect.DeepEqual(config.Consensus, s.config.Consensus) {
+		logs.Warn("Consensus changed, it is ignored until the node restarts")
+	}
+	if !reflect.DeepEqual(config.RPC, s.config.RPC) {
+		logs.Warn("RPC changed, it is ignored until the node restarts")
 	}
 
-	listener.StartCrossChainListen(config.Chains, config.DBConfig)
-	network.StartNetWork(ctx, config)
+	if err := listener.ReloadCrossChainListen(config.Chains, config.Relay); err != nil {
+		return err
+	}
+	s.stack.Bridge.Reload(config)
+
+	config.DBConfig = s.config.DBConfig
+	config.LinQConfig = s.config.LinQConfig
+	config.Consensus = s.config.Consensus
+	config.RPC = s.config.RPC
+	s.config = config
+	logs.Info("config %s reloaded", configFile)
+	return nil
 }
 
-func waitSignal() os.Signal {
-	exit := make(chan os.Signal, 0)
+// waitSignal blocks until the process is asked to exit, reloading the config
+// on every SIGHUP.
+func (s *server) waitSignal() {
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	defer signal.Stop(sc)
-	go func() {
-		for sig := range sc {
-			logs.Info("cross chain listen received signal:(%s).", sig.String())
-			exit <- sig
-			close(exit)
-			break
+	for sig := range sc {
+		logs.Info("linq server received signal:(%s).", sig.String())
+		if sig != syscall.SIGHUP {
+			return
 		}
-	}()
-	sig := <-exit
-	return sig
+		if err := s.stack.Reload(); err != nil {
+			logs.Error("reload config %s failed, keeping the running config: %v", configFile, err)
+		}
+	}
 }
 
-func stopServer() {
+// stop shuts the server down in order: the chain listeners stop feeding new
+// transfers, then the node drains its in-flight relays for at most
+// shutdownTimeout before closing p2p and the databases. A second interrupt
+// exits right away.
+func (s *server) stop() {
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sc)
+	go func() {
+		<-sc
+		logs.Warn("interrupted again, exiting without a clean shutdown")
+		os.Exit(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	logs.Info("shutting down, draining for at most %v", shutdownTimeout)
 	listener.StopCrossChainListen()
+	if err := s.stack.Shutdown(ctx); err != nil {
+		logs.Error("shutdown failed: %v", err)
+		return
+	}
+	logs.Info("shutdown complete")
+}
+
+// checkSchema refuses to run against a database migrated by another release.
+func checkSchema(dbCfg *conf.DBConfig) error {
+	db, err := dao.OpenDB(dbCfg)
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	return dao.CheckSchema(db)
 }
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"land-bridge/handle/dao"
+	"land-bridge/network/storage"
+)
+
+var (
+	chainStoreFrom, chainStoreTo, chainDataDir string
+)
+
+var ChainStoreCMD = cli.Command{
+	Name:  "chainstore",
+	Usage: "Copy LinQ blocks, LBFT snapshots and evidence from one chain store backend to another",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:        "config",
+			Usage:       "Server config file `<path>`",
+			Value:       "./conf/config_devnet.json",
+			Destination: &configPath,
+		},
+		cli.StringFlag{
+			Name:        "from",
+			Usage:       "Source backend `<mysql|leveldb>`",
+			Value:       storage.MySQL,
+			Destination: &chainStoreFrom,
+		},
+		cli.StringFlag{
+			Name:        "to",
+			Usage:       "Target backend `<mysql|leveldb>`",
+			Value:       storage.LevelDB,
+			Destination: &chainStoreTo,
+		},
+		cli.StringFlag{
+			Name:        "datadir",
+			Usage:       "LevelDB chain data `<path>`, defaults to LinQConfig.ChainData",
+			Destination: &chainDataDir,
+		},
+	},
+	Action: chainStore,
+}
+
+func chainStore(ctx *cli.Context) {
+	if chainStoreFrom == chainStoreTo {
+		fmt.Println("Source and target chain stores are the same.")
+		return
+	}
+	cfg := loadConfig()
+	db, err := dao.OpenDB(cfg.DBConfig)
+	if err != nil {
+		panic(err)
+	}
+
+	path := chainDataDir
+	if path == "" && cfg.LinQConfig != nil {
+		path = cfg.LinQConfig.ChainData
+	}
+	if path == "" {
+		path = storage.DefaultChainData
+	}
+	from, err := storage.OpenBackend(chainStoreFrom, path, db)
+	if err != nil {
+		panic(err)
+	}
+	defer from.Close()
+	to, err := storage.OpenBackend(chainStoreTo, path, db)
+	if err != nil {
+		panic(err)
+	}
+	defer to.Close()
+
+	blocks, snapshots, evidence, err := storage.Migrate(from, to)
+	if err != nil {
+		fmt.Println("Chain store migration failed:", err)
+		return
+	}
+	fmt.Printf("Copied %d blocks, %d snapshots and %d equivocation evidence from %s to %s.\n", blocks, snapshots, evidence, chainStoreFrom, chainStoreTo)
+	fmt.Printf("Set LinQConfig.ChainStore to %q to use the new backend.\n", chainStoreTo)
+}
@@ -47,7 +47,9 @@ const (
   "LinQConfig": {
     "DefaultBootNodes": [],
     "Addr": "0.0.0.0",
-    "Port": 30303
+    "Port": 30303,
+    "ChainStore": "mysql",
+    "ChainData": "./chaindata"
   },
   "Chains": [
     {
@@ -64,7 +66,13 @@ const (
       "CCMContract": "",
       "NFTProxyContract": "",
       "NFTWrapperContract": "",
-      "NFTQueryContract": ""
+      "NFTQueryContract": "",
+      "Gas": {
+        "Strategy": "legacy",
+        "GasLimit": 400000,
+        "MaxGasPrice": 0,
+        "SpendingCap": 0
+      }
     }
   ]
 }`
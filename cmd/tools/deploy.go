@@ -4,13 +4,10 @@ import (
 	"fmt"
 
 	"github.com/urfave/cli"
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
 
-	"land-bridge/conf"
 	"land-bridge/handle/dao"
 	"land-bridge/models"
-	"land-bridge/network"
+	"land-bridge/network/storage"
 )
 
 var (
@@ -39,38 +36,19 @@ var DeployCMD = cli.Command{
 
 func deploy(ctx *cli.Context) {
 	fmt.Println("LinQ Tool Start Initialization Procedure.")
-	cfg := conf.NewConfig(configPath)
-	dbCfg := cfg.DBConfig
-	db, err := gorm.Open(mysql.Open(dbCfg.User+":"+dbCfg.Password+"@tcp("+dbCfg.URL+")/"+
-		dbCfg.Scheme+"?charset=utf8"), &gorm.Config{Logger: network.Nologger{}})
+	cfg := loadConfig()
+	db, err := dao.OpenDB(cfg.DBConfig)
 	if err != nil {
 		panic(err)
 	}
 	fmt.Println("Linked Mysql Database Successfully.")
-	err = db.AutoMigrate(
-		&models.ChainFee{},
-		&models.Chain{},
-		&models.DstTransaction{},
-		&models.DstSwap{},
-		&models.DstTransfer{},
-		&models.NFTProfile{},
-		&models.PriceMarket{},
-		&models.SrcTransaction{},
-		&models.SrcSwap{},
-		&models.SrcTransfer{},
-		&models.TimeStatistic{},
-		&models.TokenBasic{},
-		&models.TokenMap{},
-		&models.Token{},
-		&models.WrapperTransaction{},
-		&models.ErrorTransaction{},
-		&models.Block{},
-		&models.Snapshot{},
-		&models.TxHashHistory{},
-	)
+	applied, err := dao.Migrate(db, 0)
 	if err != nil {
 		panic(err)
 	}
+	for _, m := range applied {
+		fmt.Printf("Applied migration %d: %s\n", m.Version, m.Name)
+	}
 	fmt.Println("Table Information Generated Successfully.")
 
 	dao := dao.NewBridgeDao(cfg.DBConfig)
@@ -86,7 +64,14 @@ func deploy(ctx *cli.Context) {
 
 	fmt.Println("Chain Information Insert Successfully.")
 
-	initGenesis(genesisPath, db)
+	chainStore, err := storage.Open(cfg.LinQConfig, db)
+	if err != nil {
+		panic(err)
+	}
+	defer chainStore.Close()
+	if err := initGenesis(genesisPath, chainStore); err != nil {
+		panic(err)
+	}
 
 	fmt.Println("Genesis Block Information Insert Successfully.")
 
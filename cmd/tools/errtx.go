@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"land-bridge/constant"
+	"land-bridge/handle/dao"
+)
+
+var (
+	errtxState int
+	errtxID    uint
+)
+
+var ErrTxCMD = cli.Command{
+	Name:  "errtx",
+	Usage: "List, retry or abandon failed relay submissions",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:        "config",
+			Usage:       "Server config file `<path>`",
+			Value:       "./conf/config_devnet.json",
+			Destination: &configPath,
+		},
+	},
+	Subcommands: []cli.Command{
+		{
+			Name:  "list",
+			Usage: "List error transactions",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:        "state",
+					Usage:       "Only list entries in `<state>` (0 resolved, 1 pending, 2 abandoned, -1 all)",
+					Value:       constant.ERROR_STATE_PENDING,
+					Destination: &errtxState,
+				},
+			},
+			Action: errtxList,
+		},
+		{
+			Name:   "retry",
+			Usage:  "Schedule an error transaction for immediate retry",
+			Flags:  []cli.Flag{errtxIDFlag},
+			Action: errtxRetry,
+		},
+		{
+			Name:   "abandon",
+			Usage:  "Abandon an error transaction and fail its wrapper",
+			Flags:  []cli.Flag{errtxIDFlag},
+			Action: errtxAbandon,
+		},
+	},
+}
+
+var errtxIDFlag = cli.UintFlag{
+	Name:        "id",
+	Usage:       "Error transaction `<id>`",
+	Destination: &errtxID,
+}
+
+var errorTypeNames = map[uint8]string{
+	constant.ERROR_UNKNOWN:     "unknown",
+	constant.ERROR_NONCE:       "nonce",
+	constant.ERROR_UNDERPRICED: "underpriced",
+	constant.ERROR_REVERT:      "revert",
+	constant.ERROR_RPC_DOWN:    "rpc-down",
+	constant.ERROR_NO_QUORUM:   "no-quorum",
+}
+
+func errtxDao() *dao.BridgeDao {
+	cfg := loadConfig()
+	return dao.NewBridgeDao(cfg.DBConfig)
+}
+
+func errtxList(ctx *cli.Context) {
+	errorTransactions, err := errtxDao().GetErrorTransactions(errtxState)
+	if err != nil {
+		fmt.Println("List error transactions failed:", err)
+		return
+	}
+	fmt.Printf("%-8s %-66s %-10s %-10s %-6s %-12s %-9s %-11s %s\n", "ID", "TxHash", "FromChain", "ToChain", "State", "Type", "Attempts", "NextRetry", "Error")
+	for _, et := range errorTransactions {
+		fmt.Printf("%-8d %-66s %-10d %-10d %-6d %-12s %-9d %-11d %s\n", et.ID, et.TxHash, et.FromChainID, et.ToChainID,
+			et.State, errorTypeNames[et.ErrorType], et.Attempts, et.NextRetry, et.ErrorMsg)
+	}
+}
+
+func errtxRetry(ctx *cli.Context) {
+	if err := errtxDao().RetryErrorTransaction(errtxID); err != nil {
+		fmt.Println("Retry error transaction failed:", err)
+		return
+	}
+	fmt.Printf("Error transaction %d scheduled for retry.\n", errtxID)
+}
+
+func errtxAbandon(ctx *cli.Context) {
+	if err := errtxDao().AbandonErrorTransaction(errtxID, ""); err != nil {
+		fmt.Println("Abandon error transaction failed:", err)
+		return
+	}
+	fmt.Printf("Error transaction %d abandoned.\n", errtxID)
+}
@@ -14,9 +14,10 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/urfave/cli"
-	"gorm.io/gorm"
 
-	"land-bridge/models"
+	"land-bridge/conf"
+	"land-bridge/network/consensus/lbft"
+	"land-bridge/network/storage"
 	networkUtils "land-bridge/network/utils"
 	"land-bridge/utils"
 )
@@ -33,6 +34,11 @@ var GenesisCMD = cli.Command{
 			Required:    true,
 			Destination: &pubkeyList,
 		},
+		cli.StringFlag{
+			Name:        "config",
+			Usage:       "Server config file `<path>` whose Consensus section is committed in the genesis block, defaults are used without it",
+			Destination: &configPath,
+		},
 	},
 	Action: genesis,
 }
@@ -46,7 +52,16 @@ func genesis(ctx *cli.Context) {
 		addrs = append(addrs, pub)
 	}
 
-	extraData, err := Encode("0x00", addrs)
+	var cc *conf.ConsensusConfig
+	if configPath != "" {
+		cc = loadConfig().Consensus
+	}
+	consensusConfig, err := lbft.NewConfig(cc)
+	if err != nil {
+		utils.Fatalf("Invalid consensus config: %v", err)
+	}
+
+	extraData, err := Encode("0x00", addrs, consensusConfig.Params())
 	if err != nil {
 		logs.Error("Failed to encode extra data", "err", err)
 		return
@@ -85,7 +100,7 @@ func genesis(ctx *cli.Context) {
 	}
 }
 
-func Encode(vanity string, validators []common.Address) (string, error) {
+func Encode(vanity string, validators []common.Address, params *networkUtils.ConsensusParams) (string, error) {
 	newVanity, err := hexutil.Decode(vanity)
 	if err != nil {
 		return "", err
@@ -100,6 +115,7 @@ func Encode(vanity string, validators []common.Address) (string, error) {
 		Validators:    validators,
 		Seal:          make([]byte, 0),
 		CommittedSeal: [][]byte{},
+		Consensus:     params,
 	}
 
 	payload, err := rlp.EncodeToBytes(&ist)
@@ -132,33 +148,19 @@ const (
 }`
 )
 
-func initGenesis(genesisPath string, db *gorm.DB) error {
+func initGenesis(genesisPath string, chainStore storage.ChainStore) error {
 	file, err := os.Open(genesisPath)
 	if err != nil {
 		utils.Fatalf("Failed to read genesis file: %v", err)
 	}
 	defer file.Close()
 
-	var (
-		genesis = networkUtils.Block{}
-		model   = &models.Block{}
-		buf     = new(bytes.Buffer)
-	)
-
-	if err := json.NewDecoder(file).Decode(&genesis); err != nil {
+	genesis := new(networkUtils.Block)
+	if err := json.NewDecoder(file).Decode(genesis); err != nil {
 		utils.Fatalf("invalid genesis file: %v", err)
 	}
-
-	err = rlp.Encode(buf, &genesis)
-	if err != nil {
-		return err
+	if chainStore.HasBlock(genesis.BlockHash, genesis.Height) {
+		return nil
 	}
-	model.Bytes = common.Bytes2Hex(buf.Bytes())
-	model.Height = genesis.Height
-	model.BlockHash = genesis.BlockHash.Hex()
-	if err := db.Create(model).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return chainStore.WriteBlock(genesis)
 }
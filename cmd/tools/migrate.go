@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli"
+	"gorm.io/gorm"
+
+	"land-bridge/handle/dao"
+)
+
+var (
+	migrateTarget uint
+	migrateSteps  int
+)
+
+var MigrateCMD = cli.Command{
+	Name:  "migrate",
+	Usage: "Apply, roll back or show the database schema migrations",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:        "config",
+			Usage:       "Server config file `<path>`",
+			Value:       "./conf/config_devnet.json",
+			Destination: &configPath,
+		},
+	},
+	Subcommands: []cli.Command{
+		{
+			Name:  "up",
+			Usage: "Apply pending migrations",
+			Flags: []cli.Flag{
+				cli.UintFlag{
+					Name:        "to",
+					Usage:       "Stop at schema `<version>`, the latest when 0",
+					Destination: &migrateTarget,
+				},
+			},
+			Action: migrateUp,
+		},
+		{
+			Name:  "down",
+			Usage: "Roll back applied migrations",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:        "steps",
+					Usage:       "Number of `<migrations>` to roll back",
+					Value:       1,
+					Destination: &migrateSteps,
+				},
+			},
+			Action: migrateDown,
+		},
+		{
+			Name:   "status",
+			Usage:  "Show the schema version and the state of every migration",
+			Action: migrateStatus,
+		},
+	},
+}
+
+func migrateDB() *gorm.DB {
+	cfg := loadConfig()
+	db, err := dao.OpenDB(cfg.DBConfig)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+func migrateUp(ctx *cli.Context) {
+	applied, err := dao.Migrate(migrateDB(), migrateTarget)
+	for _, m := range applied {
+		fmt.Printf("Applied migration %d: %s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		fmt.Println("Migrate failed:", err)
+		return
+	}
+	if len(applied) == 0 {
+		fmt.Println("Schema is up to date.")
+	}
+}
+
+func migrateDown(ctx *cli.Context) {
+	reverted, err := dao.Rollback(migrateDB(), migrateSteps)
+	for _, m := range reverted {
+		fmt.Printf("Rolled back migration %d: %s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		fmt.Println("Rollback failed:", err)
+		return
+	}
+	if len(reverted) == 0 {
+		fmt.Println("No migration to roll back.")
+	}
+}
+
+func migrateStatus(ctx *cli.Context) {
+	db := migrateDB()
+	version, err := dao.SchemaVersion(db)
+	if err != nil {
+		fmt.Println("Read schema version failed:", err)
+		return
+	}
+	states, err := dao.MigrationStatus(db)
+	if err != nil {
+		fmt.Println("Read migrations failed:", err)
+		return
+	}
+	fmt.Printf("schema version %d, latest %d\n", version, dao.LatestSchemaVersion())
+	for _, s := range states {
+		applied := "pending"
+		if s.Applied {
+			applied = "applied " + time.Unix(int64(s.AppliedAt), 0).Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("  %4d  %-40s %s\n", s.Version, s.Name, applied)
+	}
+}
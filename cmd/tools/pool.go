@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"land-bridge/handle/dao"
+	"land-bridge/network/linq/txblock"
+)
+
+var PoolCMD = cli.Command{
+	Name:  "pool",
+	Usage: "Show the transfers waiting for a LinQ block, in the order they are served",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:        "config",
+			Usage:       "Server config file `<path>`",
+			Value:       "./conf/config_devnet.json",
+			Destination: &configPath,
+		},
+	},
+	Action: pool,
+}
+
+func pool(ctx *cli.Context) {
+	cfg := loadConfig()
+	wrappers, err := dao.NewBridgeDao(cfg.DBConfig).GetPooledWrappers()
+	if err != nil {
+		fmt.Println("Load pooled transfers failed:", err)
+		return
+	}
+	p := txblock.NewBlockPool()
+	for _, w := range wrappers {
+		p.Push(w.Hash, &txblock.TxInfo{W: w})
+	}
+
+	content := p.Content()
+	chains := make([]uint64, 0, len(content))
+	for id := range content {
+		chains = append(chains, id)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i] < chains[j] })
+
+	fmt.Printf("%d pending transfers\n", p.Len())
+	for _, id := range chains {
+		fmt.Printf("destination chain %d:\n", id)
+		for _, tx := range content[id] {
+			fee := "0"
+			if tx.W.FeeAmount != nil {
+				fee = tx.W.FeeAmount.String()
+			}
+			fmt.Printf("  %s src %-8d fee %-24s age %s\n", tx.W.Hash, tx.W.SrcChainID, fee,
+				time.Since(time.Unix(int64(tx.W.Time), 0)).Truncate(time.Second))
+		}
+	}
+}
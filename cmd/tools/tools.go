@@ -1,15 +1,34 @@
 package tools
 
-import "github.com/urfave/cli"
+import (
+	"github.com/urfave/cli"
+
+	"land-bridge/conf"
+	"land-bridge/utils"
+)
 
 var CMD = cli.Command{
 	Name:    "tool",
 	Aliases: []string{"t"},
 	Usage:   "linq Tool",
 	Subcommands: []cli.Command{
+		ChainStoreCMD,
 		ConfigCMD,
 		DeployCMD,
+		ErrTxCMD,
 		GenesisCMD,
+		MigrateCMD,
 		NodekeyCMD,
+		PoolCMD,
 	},
 }
+
+// loadConfig loads and validates the config file of the tool commands,
+// exiting with the invalid fields.
+func loadConfig() *conf.Config {
+	cfg, err := conf.NewConfig(configPath)
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	return cfg
+}
@@ -1,12 +1,6 @@
 package conf
 
-import (
-	"encoding/json"
-
-	"github.com/beego/beego/v2/core/logs"
-
-	"land-bridge/utils"
-)
+import "fmt"
 
 var (
 	BSC      uint64
@@ -31,20 +25,17 @@ func initChainID(env string) {
 	}
 }
 
-func NewConfig(filePath string) *Config {
-	buf, err := utils.ReadFile(filePath)
+// NewConfig loads and validates the config file. The error lists every
+// invalid field, one per line.
+func NewConfig(filePath string) (*Config, error) {
+	config, err := LoadConfig(filePath)
 	if err != nil {
-		logs.Error("NewServiceConfig: failed, err: %s", err)
-		return nil
+		return nil, fmt.Errorf("Failed to load config %s:\n%v", filePath, err)
 	}
-	config := &Config{}
-	err = json.Unmarshal(buf, config)
-	if err != nil {
-		logs.Error("NewServiceConfig: failed, err: %s", err)
-		return nil
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid config %s:\n%v", filePath, err)
 	}
-	initChainID(config.RunMode)
-	return config
+	return config, nil
 }
 
 func (cc *ChainListenConfig) GetNodesURL() []string {
@@ -0,0 +1,276 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/naoina/toml"
+	"gopkg.in/yaml.v3"
+
+	"land-bridge/utils"
+)
+
+const (
+	// EnvPrefix prefixes the environment variables overriding config fields,
+	// e.g. LINQ_DBCONFIG_PASSWORD or LINQ_CHAINS_0_NODES_0_URL.
+	EnvPrefix = "LINQ"
+	// envFileSuffix names the variable holding a file to read a field from,
+	// e.g. LINQ_DBCONFIG_PASSWORD_FILE.
+	envFileSuffix = "_FILE"
+	// secretPrefix marks a string value as a reference to a secret file.
+	secretPrefix = "file:"
+)
+
+// FieldError is a problem with one config field.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+// FieldErrors collects the problems found in a config.
+type FieldErrors []*FieldError
+
+func (errs FieldErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (errs *FieldErrors) add(field string, format string, args ...interface{}) {
+	*errs = append(*errs, &FieldError{Field: field, Err: fmt.Errorf(format, args...)})
+}
+
+func (errs FieldErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// LoadConfig reads a JSON, YAML or TOML config file, chosen by extension,
+// rejecting unknown fields. LINQ_* environment variables and secret file
+// references are then applied, but the result is not validated.
+func LoadConfig(filePath string) (*Config, error) {
+	buf, err := utils.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".json", "":
+		dec := json.NewDecoder(bytes.NewReader(buf))
+		dec.UseNumber()
+		err = dec.Decode(&raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(buf, &raw)
+	case ".toml":
+		var m map[string]interface{}
+		err = toml.Unmarshal(buf, &m)
+		raw = m
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format %q", filePath, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	var errs FieldErrors
+	checkFields(raw, reflect.TypeOf(Config{}), "", &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	// YAML and TOML documents go through JSON, so that field names match
+	// the same way in every format.
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+	config := &Config{}
+	if err := json.Unmarshal(normalized, config); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return nil, FieldErrors{{Field: typeErr.Field, Err: fmt.Errorf("cannot use %s as %s", typeErr.Value, typeErr.Type)}}
+		}
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix, "", &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	initChainID(config.RunMode)
+	return config, nil
+}
+
+func fieldName(f reflect.StructField) string {
+	if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+		return tag
+	}
+	return f.Name
+}
+
+func joinField(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// checkFields reports the keys of raw which do not match a field of typ.
+func checkFields(raw interface{}, typ reflect.Type, path string, errs *FieldErrors) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := make(map[string]reflect.StructField, typ.NumField())
+		for i := 0; i < typ.NumField(); i++ {
+			if f := typ.Field(i); f.PkgPath == "" {
+				fields[strings.ToLower(fieldName(f))] = f
+			}
+		}
+		for key, value := range m {
+			f, ok := fields[strings.ToLower(key)]
+			if !ok {
+				errs.add(joinField(path, key), "unknown field")
+				continue
+			}
+			checkFields(value, f.Type, joinField(path, fieldName(f)), errs)
+		}
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			checkFields(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// applyEnv overrides the fields of v from the environment and resolves secret
+// file references, reporting whether anything was set.
+func applyEnv(v reflect.Value, env string, path string, errs *FieldErrors) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return false
+		}
+		if !v.IsNil() {
+			return applyEnv(v.Elem(), env, path, errs)
+		}
+		// allocate missing sections, keeping them only if a variable sets them
+		section := reflect.New(v.Type().Elem())
+		if applyEnv(section.Elem(), env, path, errs) {
+			v.Set(section)
+			return true
+		}
+		return false
+	case reflect.Struct:
+		set := false
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := fieldName(f)
+			if applyEnv(v.Field(i), env+"_"+strings.ToUpper(name), joinField(path, name), errs) {
+				set = true
+			}
+		}
+		return set
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			set := false
+			for i := 0; i < v.Len(); i++ {
+				if applyEnv(v.Index(i), fmt.Sprintf("%s_%d", env, i), fmt.Sprintf("%s[%d]", path, i), errs) {
+					set = true
+				}
+			}
+			return set
+		}
+	}
+
+	value, ok := os.LookupEnv(env)
+	if file, fileOk := os.LookupEnv(env + envFileSuffix); fileOk {
+		value, ok = secretPrefix+file, true
+	}
+	if !ok {
+		if v.Kind() == reflect.String && strings.HasPrefix(v.String(), secretPrefix) {
+			value, ok = v.String(), true
+		} else {
+			return false
+		}
+	}
+	if strings.HasPrefix(value, secretPrefix) {
+		secret, err := ioutil.ReadFile(strings.TrimPrefix(value, secretPrefix))
+		if err != nil {
+			errs.add(path, "read secret: %v", err)
+			return false
+		}
+		value = strings.TrimSpace(string(secret))
+	}
+	if err := setValue(v, value); err != nil {
+		errs.add(path, "%s: %v", env, err)
+		return false
+	}
+	return true
+}
+
+func setValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("cannot set %s from the environment", v.Type())
+	}
+	return nil
+}
@@ -5,6 +5,10 @@ type Config struct {
 	DBConfig   *DBConfig
 	Chains     []*ChainListenConfig
 	LinQConfig *LinQConfig
+	Retry      *RetryConfig
+	Relay      *RelayConfig
+	Consensus  *ConsensusConfig
+	RPC        *RPCConfig
 }
 
 type DBConfig struct {
@@ -32,12 +36,32 @@ type ChainListenConfig struct {
 	ChainID            uint64
 	ListenSlot         uint64
 	BatchSize          uint64
-	Defer              uint64
+	Defer              uint64 // confirmations awaited by the listener and by validators verifying a transfer
 	Nodes              []*Restful
 	NFTWrapperContract string
 	NFTProxyContract   string
 	NFTQueryContract   string
 	CCMContract        string
+	Gas                *GasConfig
+}
+
+const (
+	GasLegacy     = "legacy"
+	GasEIP1559    = "eip1559"
+	GasFixed      = "fixed"
+	GasMultiplier = "multiplier"
+)
+
+// GasConfig describes how relay transactions are priced on a chain.
+// All prices and caps are in gwei.
+type GasConfig struct {
+	Strategy       string
+	GasLimit       uint64
+	GasPrice       uint64  // fixed gas price
+	Multiplier     float64 // applied to the suggested gas price
+	MaxGasPrice    uint64  // ceiling of the gas price, or of the fee cap for eip1559
+	MaxPriorityFee uint64  // ceiling of the eip1559 priority fee
+	SpendingCap    uint64  // refuse to submit when gas limit times price exceeds it
 }
 
 type ContractAddrs struct {
@@ -61,4 +85,65 @@ type LinQConfig struct {
 	DefaultBootNodes []string
 	Addr             string
 	Port             uint
+	ChainStore       string   // "mysql" (default), "leveldb" or "memory"
+	ChainData        string   // directory of the leveldb chain store
+	NetworkID        uint64   // identifier of the network, peers announcing another one are rejected
+	NoDiscovery      bool     // disable the UDP node discovery
+	ExtIP            string   // IP advertised to the other nodes, detected when empty
+	Permissioned     bool     // only peer with the current validators and the observers
+	Observers        []string // node key addresses of the non-validator nodes allowed to peer
+	BanTime          uint64   // seconds a misbehaving peer stays banned, 3600 when zero
+
+	Checkpoint *CheckpointConfig // block an empty chain is synced from, the genesis block if nil
+}
+
+// CheckpointConfig starts the sync of a new node from a trusted block instead
+// of the genesis block. With Hash set, the node fetches that block, which must
+// be an epoch block; otherwise it takes the last epoch block of a peer once
+// committed by the Signers.
+type CheckpointConfig struct {
+	Number  uint64   // height of the trusted block
+	Hash    string   // hash of the trusted block
+	Signers []string // addresses of the trusted validators, more than two thirds of which must commit the block
+}
+
+type RelayConfig struct {
+	BackupTimeout    uint64 // seconds each backup relayer waits after the one before it
+	ExecutionTimeout uint64 // seconds a relayed wrapper may wait for its destination execution before it is failed, 3600 when zero
+}
+
+type RetryConfig struct {
+	MaxAttempts uint64
+	BaseDelay   uint64 // seconds
+	MaxDelay    uint64 // seconds
+}
+
+const (
+	ProposerRoundRobin = "roundrobin"
+	ProposerSticky     = "sticky"
+	ProposerWeighted   = "weighted"
+	ProposerRandom     = "random"
+)
+
+// ConsensusConfig tunes LBFT. RequestTimeout and AllowedFutureBlockTime are
+// local to the node. The other parameters must be identical network-wide:
+// the genesis block commits to them, and a zero value takes the genesis one.
+type ConsensusConfig struct {
+	RequestTimeout         uint64 // milliseconds a round may last before a round change
+	AllowedFutureBlockTime uint64 // seconds a block timestamp may be ahead of the local clock
+	BlockPeriod            uint64 // minimum seconds between two blocks
+	ProposerPolicy         string // "roundrobin" (default), "sticky", "weighted" or "random"
+	Epoch                  uint64 // blocks after which pending votes are reset
+	Ceil2Nby3Block         uint64 // height from which a quorum is ceil(2N/3) instead of 2F+1
+}
+
+// RPCConfig sets the JSON-RPC endpoints of the node. The IPC socket is opened
+// unless disabled, HTTP and WebSocket only when HTTPPort is set.
+type RPCConfig struct {
+	NoIPC    bool   // disable the IPC socket
+	IPCPath  string // socket path, a plain file name is placed in the data directory
+	HTTPAddr string // HTTP listening IP, default 127.0.0.1
+	HTTPPort uint   // HTTP listening port, HTTP is disabled when 0
+	WS       bool   // also serve WebSocket on the HTTP port
+	Token    string // bearer token required by every HTTP and WebSocket request
 }
@@ -0,0 +1,220 @@
+package conf
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+var (
+	runModes    = map[string]bool{"testnet": true, "mainnet": true}
+	chainStores = map[string]bool{"": true, "mysql": true, "leveldb": true, "memory": true}
+	nodeSchemes = map[string]bool{"http": true, "https": true, "ws": true, "wss": true}
+
+	proposerPolicies = map[string]bool{"": true, ProposerRoundRobin: true, ProposerSticky: true, ProposerWeighted: true, ProposerRandom: true}
+)
+
+// minRequestTimeout is the shortest LBFT round timeout accepted, in milliseconds.
+const minRequestTimeout = 100
+
+// Validate checks the whole config, reporting every invalid field.
+func (c *Config) Validate() error {
+	var errs FieldErrors
+	if !runModes[c.RunMode] {
+		errs.add("RunMode", "must be testnet or mainnet, got %q", c.RunMode)
+	}
+	c.DBConfig.validate("DBConfig", &errs)
+	c.LinQConfig.validate("LinQConfig", &errs)
+	if len(c.Chains) == 0 {
+		errs.add("Chains", "no chain configured")
+	}
+	seen := make(map[uint64]bool)
+	for i, chain := range c.Chains {
+		path := fmt.Sprintf("Chains[%d]", i)
+		if chain == nil {
+			errs.add(path, "empty chain")
+			continue
+		}
+		if seen[chain.ChainID] {
+			errs.add(path+".ChainID", "chain %d configured twice", chain.ChainID)
+		}
+		seen[chain.ChainID] = true
+		chain.validate(path, &errs)
+	}
+	if c.Retry != nil && c.Retry.MaxDelay > 0 && c.Retry.MaxDelay < c.Retry.BaseDelay {
+		errs.add("Retry.MaxDelay", "is below BaseDelay")
+	}
+	c.Consensus.validate("Consensus", &errs)
+	c.RPC.validate("RPC", &errs)
+	return errs.err()
+}
+
+func (rc *RPCConfig) validate(path string, errs *FieldErrors) {
+	if rc == nil {
+		return
+	}
+	if rc.HTTPAddr != "" && net.ParseIP(rc.HTTPAddr) == nil {
+		errs.add(path+".HTTPAddr", "invalid IP address %q", rc.HTTPAddr)
+	}
+	if rc.HTTPPort > 65535 {
+		errs.add(path+".HTTPPort", "%d is out of range", rc.HTTPPort)
+	}
+	if rc.HTTPPort > 0 && rc.Token == "" {
+		errs.add(path+".Token", "required when HTTP is enabled")
+	}
+}
+
+func (cc *ConsensusConfig) validate(path string, errs *FieldErrors) {
+	if cc == nil {
+		return
+	}
+	if cc.RequestTimeout > 0 && cc.RequestTimeout < minRequestTimeout {
+		errs.add(path+".RequestTimeout", "must be at least %d milliseconds", minRequestTimeout)
+	}
+	if !proposerPolicies[cc.ProposerPolicy] {
+		errs.add(path+".ProposerPolicy", "must be %s, %s, %s or %s, got %q", ProposerRoundRobin, ProposerSticky, ProposerWeighted, ProposerRandom, cc.ProposerPolicy)
+	}
+}
+
+func (db *DBConfig) validate(path string, errs *FieldErrors) {
+	if db == nil {
+		errs.add(path, "missing")
+		return
+	}
+	if _, _, err := net.SplitHostPort(db.URL); err != nil {
+		errs.add(path+".URL", "must be host:port: %v", err)
+	}
+	if db.Scheme == "" {
+		errs.add(path+".Scheme", "missing")
+	}
+	if db.User == "" {
+		errs.add(path+".User", "missing")
+	}
+}
+
+func (lc *LinQConfig) validate(path string, errs *FieldErrors) {
+	if lc == nil {
+		errs.add(path, "missing")
+		return
+	}
+	if lc.Addr != "" && net.ParseIP(lc.Addr) == nil {
+		errs.add(path+".Addr", "invalid IP address %q", lc.Addr)
+	}
+	if lc.ExtIP != "" && net.ParseIP(lc.ExtIP) == nil {
+		errs.add(path+".ExtIP", "invalid IP address %q", lc.ExtIP)
+	}
+	if lc.Port > 65535 {
+		errs.add(path+".Port", "%d is out of range", lc.Port)
+	}
+	for i, rawurl := range lc.DefaultBootNodes {
+		if _, err := enode.Parse(enode.ValidSchemes, rawurl); err != nil {
+			errs.add(fmt.Sprintf("%s.DefaultBootNodes[%d]", path, i), "%v", err)
+		}
+	}
+	for i, observer := range lc.Observers {
+		if !common.IsHexAddress(observer) {
+			errs.add(fmt.Sprintf("%s.Observers[%d]", path, i), "invalid address %q", observer)
+		}
+	}
+	if !chainStores[lc.ChainStore] {
+		errs.add(path+".ChainStore", "unknown chain store %q", lc.ChainStore)
+	}
+	if lc.Checkpoint != nil {
+		lc.Checkpoint.validate(path+".Checkpoint", errs)
+	}
+}
+
+func (cc *CheckpointConfig) validate(path string, errs *FieldErrors) {
+	if cc.Hash == "" && len(cc.Signers) == 0 {
+		errs.add(path, "needs a Hash or Signers")
+	}
+	if cc.Hash != "" {
+		if b, err := hexutil.Decode(cc.Hash); err != nil || len(b) != common.HashLength {
+			errs.add(path+".Hash", "invalid block hash %q", cc.Hash)
+		}
+		if cc.Number == 0 {
+			errs.add(path+".Number", "missing")
+		}
+	}
+	for i, signer := range cc.Signers {
+		if !common.IsHexAddress(signer) {
+			errs.add(fmt.Sprintf("%s.Signers[%d]", path, i), "invalid address %q", signer)
+		}
+	}
+}
+
+// supportedChain reports whether a listener exists for chainID in the run mode.
+func supportedChain(chainID uint64) bool {
+	switch chainID {
+	case ETHEREUM, BSC, KLAYTN, PLATON:
+		return true
+	}
+	return false
+}
+
+func (cc *ChainListenConfig) validate(path string, errs *FieldErrors) {
+	if cc.ChainName == "" {
+		errs.add(path+".ChainName", "missing")
+	}
+	if !supportedChain(cc.ChainID) {
+		errs.add(path+".ChainID", "chain %d is not supported", cc.ChainID)
+	}
+	if cc.ListenSlot == 0 {
+		errs.add(path+".ListenSlot", "must be positive")
+	}
+	if cc.BatchSize == 0 {
+		errs.add(path+".BatchSize", "must be positive")
+	}
+	if len(cc.Nodes) == 0 {
+		errs.add(path+".Nodes", "no node configured")
+	}
+	for i, node := range cc.Nodes {
+		field := fmt.Sprintf("%s.Nodes[%d].URL", path, i)
+		if node == nil {
+			errs.add(field, "missing")
+			continue
+		}
+		u, err := url.Parse(node.URL)
+		switch {
+		case err != nil:
+			errs.add(field, "%v", err)
+		case !nodeSchemes[u.Scheme] || u.Host == "":
+			errs.add(field, "must be an http(s) or ws(s) URL, got %q", node.URL)
+		}
+	}
+	contracts := []struct{ name, addr string }{
+		{"CCMContract", cc.CCMContract},
+		{"NFTProxyContract", cc.NFTProxyContract},
+		{"NFTWrapperContract", cc.NFTWrapperContract},
+		{"NFTQueryContract", cc.NFTQueryContract},
+	}
+	for _, c := range contracts {
+		if !common.IsHexAddress(c.addr) || common.HexToAddress(c.addr) == (common.Address{}) {
+			errs.add(path+"."+c.name, "invalid contract address %q", c.addr)
+		}
+	}
+	cc.Gas.validate(path+".Gas", errs)
+}
+
+func (gc *GasConfig) validate(path string, errs *FieldErrors) {
+	if gc == nil {
+		return
+	}
+	switch gc.Strategy {
+	case GasLegacy, GasEIP1559, "":
+	case GasFixed:
+		if gc.GasPrice == 0 {
+			errs.add(path+".GasPrice", "must be positive for the fixed strategy")
+		}
+	case GasMultiplier:
+		if gc.Multiplier <= 0 {
+			errs.add(path+".Multiplier", "must be positive for the multiplier strategy")
+		}
+	default:
+		errs.add(path+".Strategy", "unknown gas strategy %q", gc.Strategy)
+	}
+}
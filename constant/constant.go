@@ -5,4 +5,25 @@ const (
 	STATE_PENDDING
 	STATE_SOURCE_DONE
 	STATE_SOURCE_CONFIRMED
+	STATE_FAILED
+)
+
+const (
+	ERROR_STATE_RESOLVED = iota
+	ERROR_STATE_PENDING
+	ERROR_STATE_ABANDONED
+)
+
+const (
+	ERROR_UNKNOWN = iota
+	ERROR_NONCE
+	ERROR_UNDERPRICED
+	ERROR_REVERT
+	ERROR_RPC_DOWN
+	ERROR_NO_QUORUM
+)
+
+const (
+	RELAY_STATE_DONE = iota
+	RELAY_STATE_WAITING
 )
@@ -16,6 +16,7 @@ require (
 	github.com/mattn/go-colorable v0.1.11 // indirect
 	github.com/mattn/go-runewidth v0.0.13 // indirect
 	github.com/mitchellh/mapstructure v1.4.2 // indirect
+	github.com/naoina/toml v0.1.2-0.20170918210437-9fafd6967416
 	github.com/polynetwork/poly v1.3.1
 	github.com/urfave/cli v1.22.4
 	golang.org/x/crypto v0.0.0-20211215153901-e495a2d5b3d3
@@ -23,7 +24,7 @@ require (
 	golang.org/x/text v0.3.7 // indirect
 	google.golang.org/protobuf v1.27.1 // indirect
 	gopkg.in/karalabe/cookiejar.v2 v2.0.0-20150724131613-8dcd6a7f4951
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 	gorm.io/driver/mysql v1.0.3
 	gorm.io/gorm v1.20.8
 )
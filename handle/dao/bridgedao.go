@@ -2,36 +2,40 @@ package dao
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 
 	"land-bridge/conf"
+	"land-bridge/constant"
 	"land-bridge/models"
-	"land-bridge/network"
 )
 
+// BridgeDao is the MySQL backed Repository.
 type BridgeDao struct {
-	dbCfg *conf.DBConfig
-	db    *gorm.DB
+	db *gorm.DB
 }
 
 func NewBridgeDao(dbCfg *conf.DBConfig) *BridgeDao {
-	dao := &BridgeDao{
-		dbCfg: dbCfg,
-	}
-	Logger := logger.Default
-	if dbCfg.Debug == true {
-		Logger = Logger.LogMode(logger.Info)
-	}
-	db, err := gorm.Open(mysql.Open(dbCfg.User+":"+dbCfg.Password+"@tcp("+dbCfg.URL+")/"+
-		dbCfg.Scheme+"?charset=utf8"), &gorm.Config{Logger: network.Nologger{}})
+	db, err := OpenDB(dbCfg)
 	if err != nil {
 		panic(err)
 	}
-	dao.db = db
-	return dao
+	return NewBridgeDaoWithDB(db)
+}
+
+// NewBridgeDaoWithDB wraps an open database connection.
+func NewBridgeDaoWithDB(db *gorm.DB) *BridgeDao {
+	return &BridgeDao{db: db}
+}
+
+func (dao *BridgeDao) Close() error {
+	sqlDB, err := dao.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
 
 func (dao *BridgeDao) UpdateEvents(wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, dstTransactions []*models.DstTransaction) error {
@@ -57,11 +61,39 @@ func (dao *BridgeDao) UpdateEvents(wrapperTransactions []*models.WrapperTransact
 			tx.Rollback()
 			return res.Error
 		}
+		for _, dstTransaction := range dstTransactions {
+			if dstTransaction.PolyHash == "" {
+				continue
+			}
+			res := tx.Model(&models.WrapperTransaction{}).
+				Where("hash = ? and dst_chain_id = ?", dstTransaction.PolyHash, dstTransaction.ChainID).
+				Updates(map[string]interface{}{"status": constant.STATE_FINISHED, "dst_hash": dstTransaction.Hash})
+			if res.Error != nil {
+				tx.Rollback()
+				return res.Error
+			}
+		}
 	}
 	tx.Commit()
 	return nil
 }
 
+func (dao *BridgeDao) GetRelayingWrappers(chainID uint64) ([]*models.WrapperTransaction, error) {
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	res := dao.db.Where("dst_chain_id = ? and status = ?", chainID, constant.STATE_SOURCE_CONFIRMED).Find(&wrapperTransactions)
+	if res.Error != nil && res.Error != gorm.ErrRecordNotFound {
+		return nil, res.Error
+	}
+	return wrapperTransactions, nil
+}
+
+func (dao *BridgeDao) FailWrapper(wrapperTransaction *models.WrapperTransaction) error {
+	res := dao.db.Model(&models.WrapperTransaction{}).
+		Where("hash = ? and status = ?", wrapperTransaction.Hash, constant.STATE_SOURCE_CONFIRMED).
+		Update("status", constant.STATE_FAILED)
+	return res.Error
+}
+
 func (dao *BridgeDao) GetChain(chainID uint64) (*models.Chain, error) {
 	chain := new(models.Chain)
 	res := dao.db.Where("chain_id = ?", chainID).First(chain)
@@ -113,3 +145,215 @@ func (dao *BridgeDao) AddChains(chain []*models.Chain, chainFees []*models.Chain
 	}
 	return nil
 }
+
+func (dao *BridgeDao) GetErrorTransactions(state int) ([]*models.ErrorTransaction, error) {
+	errorTransactions := make([]*models.ErrorTransaction, 0)
+	db := dao.db
+	if state >= 0 {
+		db = db.Where("state = ?", state)
+	}
+	res := db.Order("id").Find(&errorTransactions)
+	if res.Error != nil && res.Error != gorm.ErrRecordNotFound {
+		return nil, res.Error
+	}
+	return errorTransactions, nil
+}
+
+func (dao *BridgeDao) RetryErrorTransaction(id uint) error {
+	errorTransaction := new(models.ErrorTransaction)
+	if err := dao.db.Where("id = ?", id).First(errorTransaction).Error; err != nil {
+		return err
+	}
+	if errorTransaction.State == constant.ERROR_STATE_RESOLVED {
+		return fmt.Errorf("error transaction %d is no longer retryable", id)
+	}
+	tx := dao.db.Begin()
+	if err := tx.Model(errorTransaction).
+		Updates(map[string]interface{}{"state": constant.ERROR_STATE_PENDING, "attempts": 0, "next_retry": 0}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&models.WrapperTransaction{}).
+		Where("hash = ? and status = ?", errorTransaction.TxHash, constant.STATE_FAILED).
+		Update("status", constant.STATE_SOURCE_CONFIRMED).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (dao *BridgeDao) AbandonErrorTransaction(id uint, reason string) error {
+	errorTransaction := new(models.ErrorTransaction)
+	if err := dao.db.Where("id = ?", id).First(errorTransaction).Error; err != nil {
+		return err
+	}
+	if errorTransaction.State != constant.ERROR_STATE_PENDING {
+		return fmt.Errorf("error transaction %d is not pending", id)
+	}
+	updates := map[string]interface{}{"state": constant.ERROR_STATE_ABANDONED}
+	if reason != "" {
+		updates["error_msg"] = reason
+	}
+	tx := dao.db.Begin()
+	if err := tx.Model(errorTransaction).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&models.WrapperTransaction{}).
+		Where("hash = ? and status = ?", errorTransaction.TxHash, constant.STATE_SOURCE_CONFIRMED).
+		Update("status", constant.STATE_FAILED).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (dao *BridgeDao) GetPooledWrappers() ([]*models.WrapperTransaction, error) {
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	res := dao.db.Where("status = ?", constant.STATE_PENDDING).Find(&wrapperTransactions)
+	if res.Error != nil && res.Error != gorm.ErrRecordNotFound {
+		return nil, res.Error
+	}
+	return wrapperTransactions, nil
+}
+
+func (dao *BridgeDao) GetSrcTransfer(txHash string) (*models.SrcTransfer, error) {
+	srcTransfer := new(models.SrcTransfer)
+	if err := dao.db.Where("tx_hash = ?", txHash).First(srcTransfer).Error; err != nil {
+		return nil, err
+	}
+	return srcTransfer, nil
+}
+
+func (dao *BridgeDao) GetWrapper(hash string) (*models.WrapperTransaction, error) {
+	wrapperTransaction := new(models.WrapperTransaction)
+	if err := dao.db.Where("hash = ?", hash).First(wrapperTransaction).Error; err != nil {
+		return nil, err
+	}
+	return wrapperTransaction, nil
+}
+
+func (dao *BridgeDao) GetWrappersByStatus(status uint64) ([]*models.WrapperTransaction, error) {
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	res := dao.db.Where("status = ?", status).Find(&wrapperTransactions)
+	if res.Error != nil && res.Error != gorm.ErrRecordNotFound {
+		return nil, res.Error
+	}
+	return wrapperTransactions, nil
+}
+
+func (dao *BridgeDao) UpdateWrapperStatus(hash string, status uint64) error {
+	return dao.db.Model(&models.WrapperTransaction{}).
+		Where("hash = ? and status <> ?", hash, constant.STATE_FINISHED).
+		Update("status", status).Error
+}
+
+func (dao *BridgeDao) SetWrapperDstHash(hash string, dstHash string) error {
+	return dao.db.Model(&models.WrapperTransaction{}).
+		Where("hash = ? and status <> ?", hash, constant.STATE_FINISHED).
+		Updates(map[string]interface{}{
+			"dst_hash":   dstHash,
+			"relay_time": gorm.Expr("CASE WHEN relay_time = 0 THEN ? ELSE relay_time END", time.Now().Unix()),
+		}).Error
+}
+
+func (dao *BridgeDao) RecordBlockTransfers(height uint64, blockHash string, transfers []*models.TxHashHistory) error {
+	blockHash = normalizeHash(blockHash)
+	tx := dao.db.Begin()
+	for _, txHash := range transfers {
+		recorded, ok, err := recordedBlock(tx, txHash)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if ok && recorded != "" && recorded != blockHash {
+			tx.Rollback()
+			return fmt.Errorf("transfer %s already recorded", txHash.TxHash)
+		}
+		if ok {
+			continue
+		}
+		if err := tx.Create(txHash).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Model(&models.WrapperTransaction{}).Where("hash = ?", txHash.TxHash).
+			Updates(map[string]interface{}{"linq_height": height, "linq_hash": blockHash}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// recordedBlock reports whether a transfer is recorded, and the hash of the
+// block recording it, empty if its wrapper does not tell.
+func recordedBlock(tx *gorm.DB, txHash *models.TxHashHistory) (string, bool, error) {
+	err := tx.Where("tx_hash = ? and chain_id = ?", txHash.TxHash, txHash.ChainID).First(&models.TxHashHistory{}).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	wrapper := new(models.WrapperTransaction)
+	err = tx.Select("linq_hash").Where("hash = ?", txHash.TxHash).First(wrapper).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", false, err
+	}
+	return normalizeHash(wrapper.LinQHash), true, nil
+}
+
+// normalizeHash returns a hash in the encoding of the wrapper columns,
+// lowercase hex without 0x prefix.
+func normalizeHash(hash string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(hash, "0x"), "0X"))
+}
+
+func (dao *BridgeDao) HasTxHash(txHash string, chainID uint64) bool {
+	txHashHistory := new(models.TxHashHistory)
+	return dao.db.Where("tx_hash = ? and chain_id = ?", txHash, chainID).First(txHashHistory).Error == nil
+}
+
+func (dao *BridgeDao) CreateErrorTransaction(errorTransaction *models.ErrorTransaction) error {
+	return dao.db.Create(errorTransaction).Error
+}
+
+func (dao *BridgeDao) GetDueErrorTransactions(now uint64) ([]*models.ErrorTransaction, error) {
+	errorTransactions := make([]*models.ErrorTransaction, 0)
+	res := dao.db.Where("state = ? and next_retry <= ?", constant.ERROR_STATE_PENDING, now).Find(&errorTransactions)
+	if res.Error != nil && res.Error != gorm.ErrRecordNotFound {
+		return nil, res.Error
+	}
+	return errorTransactions, nil
+}
+
+func (dao *BridgeDao) CountPendingErrorTransactions(txHash string) (int64, error) {
+	var count int64
+	err := dao.db.Model(&models.ErrorTransaction{}).
+		Where("tx_hash = ? and state = ?", txHash, constant.ERROR_STATE_PENDING).Count(&count).Error
+	return count, err
+}
+
+func (dao *BridgeDao) UpdateErrorTransaction(errorTransaction *models.ErrorTransaction) error {
+	return dao.db.Model(errorTransaction).
+		Select("state", "error_type", "attempts", "next_retry", "error_msg").
+		Updates(errorTransaction).Error
+}
+
+func (dao *BridgeDao) CreateRelayTask(task *models.RelayTask) error {
+	return dao.db.Create(task).Error
+}
+
+func (dao *BridgeDao) GetDueRelayTasks(now uint64) ([]*models.RelayTask, error) {
+	tasks := make([]*models.RelayTask, 0)
+	res := dao.db.Where("state = ? and deadline <= ?", constant.RELAY_STATE_WAITING, now).Find(&tasks)
+	if res.Error != nil && res.Error != gorm.ErrRecordNotFound {
+		return nil, res.Error
+	}
+	return tasks, nil
+}
+
+func (dao *BridgeDao) FinishRelayTask(task *models.RelayTask) error {
+	return dao.db.Model(task).Update("state", constant.RELAY_STATE_DONE).Error
+}
@@ -0,0 +1,20 @@
+package dao
+
+import (
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"land-bridge/conf"
+)
+
+// OpenDB connects to the MySQL database of dbCfg. SQL statements are only
+// logged when dbCfg.Debug is set.
+func OpenDB(dbCfg *conf.DBConfig) (*gorm.DB, error) {
+	Logger := logger.Default.LogMode(logger.Silent)
+	if dbCfg.Debug {
+		Logger = logger.Default.LogMode(logger.Info)
+	}
+	return gorm.Open(mysql.Open(dbCfg.User+":"+dbCfg.Password+"@tcp("+dbCfg.URL+")/"+
+		dbCfg.Scheme+"?charset=utf8"), &gorm.Config{Logger: Logger})
+}
@@ -0,0 +1,407 @@
+package dao
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"land-bridge/constant"
+	"land-bridge/models"
+)
+
+// MemoryDao is a Repository kept in memory, so that the listeners, the worker
+// and the bridge can run without a database. Records are copied in and out
+// the way they would be by a real database.
+type MemoryDao struct {
+	mu sync.RWMutex
+
+	chains            map[uint64]*models.Chain
+	chainFees         []*models.ChainFee
+	wrappers          map[string]*models.WrapperTransaction
+	srcTransactions   map[string]*models.SrcTransaction
+	srcTransfers      map[string]*models.SrcTransfer
+	dstTransactions   map[string]*models.DstTransaction
+	txHashes          map[string]string // block hash recording each transfer
+	errorTransactions map[uint]*models.ErrorTransaction
+	relayTasks        map[uint]*models.RelayTask
+
+	lastID uint
+}
+
+func NewMemoryDao() *MemoryDao {
+	return &MemoryDao{
+		chains:            make(map[uint64]*models.Chain),
+		wrappers:          make(map[string]*models.WrapperTransaction),
+		srcTransactions:   make(map[string]*models.SrcTransaction),
+		srcTransfers:      make(map[string]*models.SrcTransfer),
+		dstTransactions:   make(map[string]*models.DstTransaction),
+		txHashes:          make(map[string]string),
+		errorTransactions: make(map[uint]*models.ErrorTransaction),
+		relayTasks:        make(map[uint]*models.RelayTask),
+	}
+}
+
+// Close is a no-op, the records live as long as the MemoryDao.
+func (dao *MemoryDao) Close() error {
+	return nil
+}
+
+func (dao *MemoryDao) nextID() uint {
+	dao.lastID++
+	return dao.lastID
+}
+
+func txHashKey(txHash string, chainID uint64) string {
+	return fmt.Sprintf("%d:%s", chainID, txHash)
+}
+
+func (dao *MemoryDao) GetChain(chainID uint64) (*models.Chain, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	chain, ok := dao.chains[chainID]
+	if !ok {
+		return nil, fmt.Errorf("no record")
+	}
+	cpy := *chain
+	cpy.HeightSwap = 0
+	return &cpy, nil
+}
+
+func (dao *MemoryDao) UpdateChain(chain *models.Chain) error {
+	if chain == nil {
+		return fmt.Errorf("no value!\n")
+	}
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if _, ok := dao.chains[chain.ChainID]; !ok {
+		return fmt.Errorf("no update!\n")
+	}
+	cpy := *chain
+	dao.chains[chain.ChainID] = &cpy
+	return nil
+}
+
+func (dao *MemoryDao) AddChains(chains []*models.Chain, chainFees []*models.ChainFee) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	for _, chain := range chains {
+		if _, ok := dao.chains[chain.ChainID]; ok {
+			return fmt.Errorf("chain %d already exists", chain.ChainID)
+		}
+	}
+	for _, chain := range chains {
+		chain.ID = int64(dao.nextID())
+		cpy := *chain
+		dao.chains[chain.ChainID] = &cpy
+	}
+	for _, chainFee := range chainFees {
+		cpy := *chainFee
+		dao.chainFees = append(dao.chainFees, &cpy)
+	}
+	return nil
+}
+
+func (dao *MemoryDao) UpdateEvents(wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, dstTransactions []*models.DstTransaction) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	for _, wrapperTransaction := range wrapperTransactions {
+		if wrapperTransaction.ID == 0 {
+			wrapperTransaction.ID = int64(dao.nextID())
+		}
+		cpy := *wrapperTransaction
+		dao.wrappers[wrapperTransaction.Hash] = &cpy
+	}
+	for _, srcTransaction := range srcTransactions {
+		if srcTransaction.ID == 0 {
+			srcTransaction.ID = int64(dao.nextID())
+		}
+		cpy := *srcTransaction
+		dao.srcTransactions[srcTransaction.Hash] = &cpy
+		if srcTransaction.SrcTransfer != nil {
+			transfer := *srcTransaction.SrcTransfer
+			dao.srcTransfers[transfer.TxHash] = &transfer
+		}
+	}
+	for _, dstTransaction := range dstTransactions {
+		if dstTransaction.ID == 0 {
+			dstTransaction.ID = int64(dao.nextID())
+		}
+		cpy := *dstTransaction
+		dao.dstTransactions[dstTransaction.Hash] = &cpy
+		if dstTransaction.PolyHash == "" {
+			continue
+		}
+		if w, ok := dao.wrappers[dstTransaction.PolyHash]; ok && w.DstChainID == dstTransaction.ChainID {
+			w.Status = constant.STATE_FINISHED
+			w.DstHash = dstTransaction.Hash
+		}
+	}
+	return nil
+}
+
+func (dao *MemoryDao) GetSrcTransfer(txHash string) (*models.SrcTransfer, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	srcTransfer, ok := dao.srcTransfers[txHash]
+	if !ok {
+		return nil, fmt.Errorf("src transfer %s not found", txHash)
+	}
+	cpy := *srcTransfer
+	return &cpy, nil
+}
+
+func (dao *MemoryDao) GetWrapper(hash string) (*models.WrapperTransaction, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	wrapperTransaction, ok := dao.wrappers[hash]
+	if !ok {
+		return nil, fmt.Errorf("wrapper %s not found", hash)
+	}
+	cpy := *wrapperTransaction
+	return &cpy, nil
+}
+
+func (dao *MemoryDao) findWrappers(match func(w *models.WrapperTransaction) bool) []*models.WrapperTransaction {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	for _, w := range dao.wrappers {
+		if match(w) {
+			cpy := *w
+			wrapperTransactions = append(wrapperTransactions, &cpy)
+		}
+	}
+	sort.Slice(wrapperTransactions, func(i, j int) bool {
+		return wrapperTransactions[i].ID < wrapperTransactions[j].ID
+	})
+	return wrapperTransactions
+}
+
+func (dao *MemoryDao) GetWrappersByStatus(status uint64) ([]*models.WrapperTransaction, error) {
+	return dao.findWrappers(func(w *models.WrapperTransaction) bool {
+		return w.Status == status
+	}), nil
+}
+
+func (dao *MemoryDao) GetPooledWrappers() ([]*models.WrapperTransaction, error) {
+	return dao.GetWrappersByStatus(constant.STATE_PENDDING)
+}
+
+func (dao *MemoryDao) GetRelayingWrappers(chainID uint64) ([]*models.WrapperTransaction, error) {
+	return dao.findWrappers(func(w *models.WrapperTransaction) bool {
+		return w.DstChainID == chainID && w.Status == constant.STATE_SOURCE_CONFIRMED
+	}), nil
+}
+
+func (dao *MemoryDao) UpdateWrapperStatus(hash string, status uint64) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if w, ok := dao.wrappers[hash]; ok && w.Status != constant.STATE_FINISHED {
+		w.Status = status
+	}
+	return nil
+}
+
+func (dao *MemoryDao) SetWrapperDstHash(hash string, dstHash string) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if w, ok := dao.wrappers[hash]; ok && w.Status != constant.STATE_FINISHED {
+		w.DstHash = dstHash
+		if w.RelayTime == 0 {
+			w.RelayTime = uint64(time.Now().Unix())
+		}
+	}
+	return nil
+}
+
+func (dao *MemoryDao) FailWrapper(wrapperTransaction *models.WrapperTransaction) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if w, ok := dao.wrappers[wrapperTransaction.Hash]; ok && w.Status == constant.STATE_SOURCE_CONFIRMED {
+		w.Status = constant.STATE_FAILED
+	}
+	return nil
+}
+
+func (dao *MemoryDao) RecordBlockTransfers(height uint64, blockHash string, transfers []*models.TxHashHistory) error {
+	blockHash = normalizeHash(blockHash)
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	for _, txHash := range transfers {
+		if recorded, ok := dao.txHashes[txHashKey(txHash.TxHash, txHash.ChainID)]; ok && recorded != blockHash {
+			return fmt.Errorf("transfer %s already recorded", txHash.TxHash)
+		}
+	}
+	for _, txHash := range transfers {
+		txHash.ID = dao.nextID()
+		dao.txHashes[txHashKey(txHash.TxHash, txHash.ChainID)] = blockHash
+		if w, ok := dao.wrappers[txHash.TxHash]; ok {
+			w.LinQHeight = height
+			w.LinQHash = blockHash
+		}
+	}
+	return nil
+}
+
+func (dao *MemoryDao) HasTxHash(txHash string, chainID uint64) bool {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	_, ok := dao.txHashes[txHashKey(txHash, chainID)]
+	return ok
+}
+
+func (dao *MemoryDao) CreateErrorTransaction(errorTransaction *models.ErrorTransaction) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	errorTransaction.ID = dao.nextID()
+	cpy := *errorTransaction
+	dao.errorTransactions[cpy.ID] = &cpy
+	return nil
+}
+
+func (dao *MemoryDao) findErrorTransactions(match func(et *models.ErrorTransaction) bool) []*models.ErrorTransaction {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	errorTransactions := make([]*models.ErrorTransaction, 0)
+	for _, et := range dao.errorTransactions {
+		if match(et) {
+			cpy := *et
+			errorTransactions = append(errorTransactions, &cpy)
+		}
+	}
+	sort.Slice(errorTransactions, func(i, j int) bool {
+		return errorTransactions[i].ID < errorTransactions[j].ID
+	})
+	return errorTransactions
+}
+
+func (dao *MemoryDao) GetErrorTransactions(state int) ([]*models.ErrorTransaction, error) {
+	return dao.findErrorTransactions(func(et *models.ErrorTransaction) bool {
+		return state < 0 || et.State == uint(state)
+	}), nil
+}
+
+func (dao *MemoryDao) GetDueErrorTransactions(now uint64) ([]*models.ErrorTransaction, error) {
+	return dao.findErrorTransactions(func(et *models.ErrorTransaction) bool {
+		return et.State == constant.ERROR_STATE_PENDING && et.NextRetry <= now
+	}), nil
+}
+
+func (dao *MemoryDao) CountPendingErrorTransactions(txHash string) (int64, error) {
+	return int64(len(dao.findErrorTransactions(func(et *models.ErrorTransaction) bool {
+		return et.TxHash == txHash && et.State == constant.ERROR_STATE_PENDING
+	}))), nil
+}
+
+func (dao *MemoryDao) UpdateErrorTransaction(errorTransaction *models.ErrorTransaction) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	et, ok := dao.errorTransactions[errorTransaction.ID]
+	if !ok {
+		return fmt.Errorf("error transaction %d not found", errorTransaction.ID)
+	}
+	et.State = errorTransaction.State
+	et.ErrorType = errorTransaction.ErrorType
+	et.Attempts = errorTransaction.Attempts
+	et.NextRetry = errorTransaction.NextRetry
+	et.ErrorMsg = errorTransaction.ErrorMsg
+	return nil
+}
+
+func (dao *MemoryDao) RetryErrorTransaction(id uint) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	et, ok := dao.errorTransactions[id]
+	if !ok {
+		return fmt.Errorf("error transaction %d not found", id)
+	}
+	if et.State == constant.ERROR_STATE_RESOLVED {
+		return fmt.Errorf("error transaction %d is no longer retryable", id)
+	}
+	et.State = constant.ERROR_STATE_PENDING
+	et.Attempts = 0
+	et.NextRetry = 0
+	if w, ok := dao.wrappers[et.TxHash]; ok && w.Status == constant.STATE_FAILED {
+		w.Status = constant.STATE_SOURCE_CONFIRMED
+	}
+	return nil
+}
+
+func (dao *MemoryDao) AbandonErrorTransaction(id uint, reason string) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	et, ok := dao.errorTransactions[id]
+	if !ok {
+		return fmt.Errorf("error transaction %d not found", id)
+	}
+	if et.State != constant.ERROR_STATE_PENDING {
+		return fmt.Errorf("error transaction %d is not pending", id)
+	}
+	et.State = constant.ERROR_STATE_ABANDONED
+	if reason != "" {
+		et.ErrorMsg = reason
+	}
+	if w, ok := dao.wrappers[et.TxHash]; ok && w.Status == constant.STATE_SOURCE_CONFIRMED {
+		w.Status = constant.STATE_FAILED
+	}
+	return nil
+}
+
+func (dao *MemoryDao) CreateRelayTask(task *models.RelayTask) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	for _, t := range dao.relayTasks {
+		if t.TxHash == task.TxHash {
+			return fmt.Errorf("relay task %s already exists", task.TxHash)
+		}
+	}
+	task.ID = dao.nextID()
+	cpy := *task
+	dao.relayTasks[cpy.ID] = &cpy
+	return nil
+}
+
+func (dao *MemoryDao) GetDueRelayTasks(now uint64) ([]*models.RelayTask, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	tasks := make([]*models.RelayTask, 0)
+	for _, t := range dao.relayTasks {
+		if t.State == constant.RELAY_STATE_WAITING && t.Deadline <= now {
+			cpy := *t
+			tasks = append(tasks, &cpy)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+func (dao *MemoryDao) FinishRelayTask(task *models.RelayTask) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if t, ok := dao.relayTasks[task.ID]; ok {
+		t.State = constant.RELAY_STATE_DONE
+	}
+	task.State = constant.RELAY_STATE_DONE
+	return nil
+}
@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"testing"
+
+	"land-bridge/models"
+)
+
+func TestRecordBlockTransfers(t *testing.T) {
+	db := NewMemoryDao()
+	transfers := func() []*models.TxHashHistory {
+		return []*models.TxHashHistory{{ChainID: 2, TxHash: "aa"}, {ChainID: 2, TxHash: "bb"}}
+	}
+	if err := db.UpdateEvents([]*models.WrapperTransaction{{Hash: "aa"}, {Hash: "bb"}}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.RecordBlockTransfers(5, "0x05", transfers()); err != nil {
+		t.Fatalf("first record: %v", err)
+	}
+	if !db.HasTxHash("aa", 2) || !db.HasTxHash("bb", 2) || db.HasTxHash("aa", 6) {
+		t.Fatal("transfers not recorded per chain")
+	}
+	// the block is inserted again after its write failed
+	if err := db.RecordBlockTransfers(5, "05", transfers()); err != nil {
+		t.Fatalf("record of the same block: %v", err)
+	}
+	if err := db.RecordBlockTransfers(6, "0x06", transfers()[1:]); err == nil {
+		t.Fatal("transfer recorded by a second block")
+	}
+	w, err := db.GetWrapper("bb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.LinQHeight != 5 || w.LinQHash != "05" {
+		t.Errorf("wrapper in block %d %s, want 5 05", w.LinQHeight, w.LinQHash)
+	}
+}
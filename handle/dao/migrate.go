@@ -0,0 +1,168 @@
+package dao
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"land-bridge/models"
+)
+
+// Migration is a versioned schema change. Up and Down run in a transaction,
+// but MySQL commits DDL statements implicitly, so a migration should check
+// the schema before altering it to stay re-runnable after a partial failure.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// MigrationState reports whether a migration has been applied.
+type MigrationState struct {
+	*Migration
+	Applied   bool
+	AppliedAt uint64
+}
+
+// LatestSchemaVersion is the schema version this build runs against.
+func LatestSchemaVersion() uint {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+func appliedVersions(db *gorm.DB) (map[uint]*models.SchemaVersion, error) {
+	applied := make(map[uint]*models.SchemaVersion)
+	if !db.Migrator().HasTable(&models.SchemaVersion{}) {
+		return applied, nil
+	}
+	var versions []*models.SchemaVersion
+	if err := db.Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		applied[v.Version] = v
+	}
+	return applied, nil
+}
+
+// SchemaVersion returns the highest applied migration version of db.
+func SchemaVersion(db *gorm.DB) (uint, error) {
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return 0, err
+	}
+	version := uint(0)
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// CheckSchema fails unless db has exactly the schema version of this build.
+func CheckSchema(db *gorm.DB) error {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	latest := LatestSchemaVersion()
+	switch {
+	case version < latest:
+		return fmt.Errorf("database schema version %d is outdated, run `linq tool migrate up` to upgrade it to %d", version, latest)
+	case version > latest:
+		return fmt.Errorf("database schema version %d is newer than %d supported by this build", version, latest)
+	}
+	return nil
+}
+
+// MigrationStatus lists every known migration with its state in db.
+func MigrationStatus(db *gorm.DB) ([]*MigrationState, error) {
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]*MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		state := &MigrationState{Migration: m}
+		if v, ok := applied[m.Version]; ok {
+			state.Applied = true
+			state.AppliedAt = v.AppliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Migrate applies the pending migrations up to version target, all of them
+// if target is 0. It returns the migrations applied.
+func Migrate(db *gorm.DB, target uint) ([]*Migration, error) {
+	if err := db.AutoMigrate(&models.SchemaVersion{}); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	if target == 0 {
+		target = LatestSchemaVersion()
+	}
+	var done []*Migration
+	for _, m := range migrations {
+		if m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&models.SchemaVersion{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: uint64(time.Now().Unix()),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s): %v", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Rollback reverts the last steps applied migrations, newest first. It
+// returns the migrations reverted.
+func Rollback(db *gorm.DB, steps int) ([]*Migration, error) {
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	var done []*Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return done, fmt.Errorf("migration %d (%s) cannot be rolled back", m.Version, m.Name)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&models.SchemaVersion{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback %d (%s): %v", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
@@ -0,0 +1,145 @@
+package dao
+
+import "gorm.io/gorm"
+
+// migrations is the schema history, in ascending version order. Released
+// migrations must never be edited, schema changes go into a new one. They work
+// on the frozen tables of schema.go rather than on the models, which follow
+// the latest schema.
+var migrations = []*Migration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(v1Tables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(v1Tables()...)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "relay tracking and retry bookkeeping",
+		Up: func(tx *gorm.DB) error {
+			for _, c := range v2Columns {
+				if !tx.Migrator().HasColumn(c.model, c.field) {
+					if err := tx.Migrator().AddColumn(c.model, c.field); err != nil {
+						return err
+					}
+				}
+			}
+			if !tx.Migrator().HasIndex(&v2WrapperTransaction{}, "DstHash") {
+				if err := tx.Migrator().CreateIndex(&v2WrapperTransaction{}, "DstHash"); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&v2RelayTask{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&v2RelayTask{}); err != nil {
+				return err
+			}
+			for _, c := range v2Columns {
+				if tx.Migrator().HasColumn(c.model, c.field) {
+					if err := tx.Migrator().DropColumn(c.model, c.field); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 3,
+		Name:    "equivocation evidence",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&v3Evidence{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v3Evidence{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "relay start time",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&v4WrapperTransaction{}, "RelayTime") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&v4WrapperTransaction{}, "RelayTime")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&v4WrapperTransaction{}, "RelayTime") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&v4WrapperTransaction{}, "RelayTime")
+		},
+	},
+	{
+		Version: 5,
+		Name:    "unique transfer history",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex(&v5TxHashHistory{}, "idx_tx_hash_chain") {
+				return nil
+			}
+			// keep the first record of the transfers committed twice; the
+			// same source hash on two chains are two transfers
+			if err := tx.Exec("DELETE t1 FROM tx_hash_histories t1 JOIN tx_hash_histories t2 " +
+				"ON t1.tx_hash = t2.tx_hash AND t1.chain_id = t2.chain_id AND t1.id > t2.id").Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().AlterColumn(&v5TxHashHistory{}, "TxHash"); err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&v5TxHashHistory{}, "idx_tx_hash_chain")
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex(&v5TxHashHistory{}, "idx_tx_hash_chain") {
+				if err := tx.Migrator().DropIndex(&v5TxHashHistory{}, "idx_tx_hash_chain"); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().AlterColumn(&v1TxHashHistory{}, "TxHash")
+		},
+	},
+}
+
+// v1Tables are the tables created by the first release.
+func v1Tables() []interface{} {
+	return []interface{}{
+		&v1ChainFee{},
+		&v1Chain{},
+		&v1DstTransaction{},
+		&v1DstSwap{},
+		&v1DstTransfer{},
+		&v1NFTProfile{},
+		&v1PriceMarket{},
+		&v1SrcTransaction{},
+		&v1SrcSwap{},
+		&v1SrcTransfer{},
+		&v1TimeStatistic{},
+		&v1TokenBasic{},
+		&v1TokenMap{},
+		&v1Token{},
+		&v1WrapperTransaction{},
+		&v1ErrorTransaction{},
+		&v1Block{},
+		&v1Snapshot{},
+		&v1TxHashHistory{},
+	}
+}
+
+// v2Columns were added to existing tables by migration 2.
+var v2Columns = []struct {
+	model interface{}
+	field string
+}{
+	{&v2WrapperTransaction{}, "LinQHeight"},
+	{&v2WrapperTransaction{}, "LinQHash"},
+	{&v2WrapperTransaction{}, "DstHash"},
+	{&v2ErrorTransaction{}, "Signers"},
+	{&v2ErrorTransaction{}, "ValidatorSet"},
+	{&v2ErrorTransaction{}, "ErrorType"},
+	{&v2ErrorTransaction{}, "Attempts"},
+	{&v2ErrorTransaction{}, "NextRetry"},
+}
@@ -0,0 +1,77 @@
+package dao
+
+import "land-bridge/models"
+
+// ChainRepository keeps the listening progress of every chain.
+type ChainRepository interface {
+	GetChain(chainID uint64) (*models.Chain, error)
+	UpdateChain(chain *models.Chain) error
+	AddChains(chains []*models.Chain, chainFees []*models.ChainFee) error
+}
+
+// EventRepository keeps the cross chain events found by the listeners.
+type EventRepository interface {
+	UpdateEvents(wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, dstTransactions []*models.DstTransaction) error
+	GetSrcTransfer(txHash string) (*models.SrcTransfer, error)
+}
+
+// WrapperRepository tracks wrapper transactions from the source chain through
+// LinQ consensus to their execution on the destination chain.
+type WrapperRepository interface {
+	GetWrapper(hash string) (*models.WrapperTransaction, error)
+	GetWrappersByStatus(status uint64) ([]*models.WrapperTransaction, error)
+	GetPooledWrappers() ([]*models.WrapperTransaction, error)
+	GetRelayingWrappers(chainID uint64) ([]*models.WrapperTransaction, error)
+	// UpdateWrapperStatus sets the status of a wrapper unless it is already finished.
+	UpdateWrapperStatus(hash string, status uint64) error
+	// SetWrapperDstHash records the destination transaction relaying a wrapper,
+	// and the time of the first one.
+	SetWrapperDstHash(hash string, dstHash string) error
+	FailWrapper(wrapperTransaction *models.WrapperTransaction) error
+
+	// RecordBlockTransfers marks the transfers of a LinQ block as handled, and
+	// fails if one of them was by another block. Recording the same block
+	// again is a no-op, so that a block whose write failed after its
+	// transfers were recorded can be inserted again. The block hash is kept
+	// like the other wrapper hashes, in lowercase hex without 0x prefix.
+	RecordBlockTransfers(height uint64, blockHash string, transfers []*models.TxHashHistory) error
+	HasTxHash(txHash string, chainID uint64) bool
+}
+
+// ErrorTransactionRepository keeps the failed relay submissions.
+type ErrorTransactionRepository interface {
+	CreateErrorTransaction(errorTransaction *models.ErrorTransaction) error
+	// GetErrorTransactions lists the entries in state, all of them if state is negative.
+	GetErrorTransactions(state int) ([]*models.ErrorTransaction, error)
+	GetDueErrorTransactions(now uint64) ([]*models.ErrorTransaction, error)
+	CountPendingErrorTransactions(txHash string) (int64, error)
+	// UpdateErrorTransaction saves the state and retry bookkeeping of an entry.
+	UpdateErrorTransaction(errorTransaction *models.ErrorTransaction) error
+	RetryErrorTransaction(id uint) error
+	AbandonErrorTransaction(id uint, reason string) error
+}
+
+// RelayTaskRepository keeps the backup relays of this validator.
+type RelayTaskRepository interface {
+	CreateRelayTask(task *models.RelayTask) error
+	GetDueRelayTasks(now uint64) ([]*models.RelayTask, error)
+	FinishRelayTask(task *models.RelayTask) error
+}
+
+// Repository is the relational storage of the bridge. Blocks and snapshots
+// are kept apart in a storage.ChainStore.
+type Repository interface {
+	ChainRepository
+	EventRepository
+	WrapperRepository
+	ErrorTransactionRepository
+	RelayTaskRepository
+
+	// Close releases the underlying connection.
+	Close() error
+}
+
+var (
+	_ Repository = (*BridgeDao)(nil)
+	_ Repository = (*MemoryDao)(nil)
+)
@@ -0,0 +1,353 @@
+package dao
+
+import "land-bridge/models"
+
+// The tables below are frozen copies of the models as each migration created
+// or altered them, so that a migration keeps producing the same schema when
+// the models change. They must never be edited, a later migration alters the
+// schema instead.
+
+// Tables created by migration 1.
+
+type v1Chain struct {
+	ID                  int64  `gorm:"primaryKey;autoIncrement"`
+	ChainID             uint64 `gorm:"uniqueIndex;type:bigint(20);not null"`
+	Name                string `gorm:"type:varchar(32)"`
+	Height              uint64 `gorm:"type:bigint(20);not null"`
+	HeightSwap          uint64 `gorm:"type:bigint(20);not null"`
+	BackwardBlockNumber uint64 `gorm:"type:bigint(20);not null"`
+}
+
+func (v1Chain) TableName() string { return "chains" }
+
+type v1SrcTransaction struct {
+	ID          int64          `gorm:"primaryKey;autoIncrement"`
+	Hash        string         `gorm:"uniqueIndex;size:66;not null"`
+	ChainID     uint64         `gorm:"type:bigint(20);not null"`
+	Standard    uint8          `gorm:"type:int(8);not null"`
+	State       uint64         `gorm:"type:bigint(20);not null"`
+	Time        uint64         `gorm:"type:bigint(20);not null"`
+	Fee         *models.BigInt `gorm:"type:varchar(64);not null"`
+	Height      uint64         `gorm:"type:bigint(20);not null"`
+	User        string         `gorm:"type:varchar(66);not null"`
+	DstChainID  uint64         `gorm:"type:bigint(20);not null"`
+	Contract    string         `gorm:"type:varchar(66);not null"`
+	Key         string         `gorm:"type:text;not null"`
+	Param       string         `gorm:"type:text;not null"`
+	SrcTransfer *v1SrcTransfer `gorm:"foreignKey:TxHash;references:Hash"`
+	SrcSwap     *v1SrcSwap     `gorm:"foreignKey:TxHash;references:Hash"`
+}
+
+func (v1SrcTransaction) TableName() string { return "src_transactions" }
+
+type v1SrcTransfer struct {
+	ID         int64          `gorm:"primaryKey;autoIncrement"`
+	TxHash     string         `gorm:"uniqueIndex;size:66;not null"`
+	ChainID    uint64         `gorm:"type:bigint(20);not null"`
+	Standard   uint8          `gorm:"type:int(8);not null"`
+	Time       uint64         `gorm:"type:bigint(20);not null"`
+	Asset      string         `gorm:"type:varchar(120);not null"`
+	From       string         `gorm:"type:varchar(66);not null"`
+	To         string         `gorm:"type:varchar(66);not null"`
+	TokenID    *models.BigInt `gorm:"type:varchar(86);not null"`
+	DstChainID uint64         `gorm:"type:bigint(20);not null"`
+	DstAsset   string         `gorm:"type:varchar(120);not null"`
+	DstUser    string         `gorm:"type:varchar(66);not null"`
+}
+
+func (v1SrcTransfer) TableName() string { return "src_transfers" }
+
+type v1SrcSwap struct {
+	ID         int64          `gorm:"primaryKey;autoIncrement"`
+	TxHash     string         `gorm:"uniqueIndex;size:66;not null"`
+	ChainID    uint64         `gorm:"type:bigint(20);not null"`
+	Time       uint64         `gorm:"type:bigint(20);not null"`
+	Asset      string         `gorm:"type:varchar(120);not null"`
+	From       string         `gorm:"type:varchar(66);not null"`
+	To         string         `gorm:"type:varchar(66);not null"`
+	TokenID    *models.BigInt `gorm:"type:varchar(86);not null"`
+	PoolID     uint64         `gorm:"type:bigint(20);not null"`
+	DstChainID uint64         `gorm:"type:bigint(20);not null"`
+	DstAsset   string         `gorm:"type:varchar(120);not null"`
+	DstUser    string         `gorm:"type:varchar(66);not null"`
+	Type       uint64         `gorm:"type:bigint(20);not null"`
+}
+
+func (v1SrcSwap) TableName() string { return "src_swaps" }
+
+type v1DstTransaction struct {
+	ID          int64          `gorm:"primaryKey;autoIncrement"`
+	Hash        string         `gorm:"uniqueIndex;size:66;not null"`
+	ChainID     uint64         `gorm:"type:bigint(20);not null"`
+	Standard    uint8          `gorm:"type:int(8);not null"`
+	State       uint64         `gorm:"type:bigint(20);not null"`
+	Time        uint64         `gorm:"type:bigint(20);not null"`
+	Fee         *models.BigInt `gorm:"type:varchar(64);not null"`
+	Height      uint64         `gorm:"type:bigint(20);not null"`
+	SrcChainID  uint64         `gorm:"type:bigint(20);not null"`
+	Contract    string         `gorm:"type:varchar(66);not null"`
+	PolyHash    string         `gorm:"index;size:66;not null"`
+	DstTransfer *v1DstTransfer `gorm:"foreignKey:TxHash;references:Hash"`
+	DstSwap     *v1DstSwap     `gorm:"foreignKey:TxHash;references:Hash"`
+}
+
+func (v1DstTransaction) TableName() string { return "dst_transactions" }
+
+type v1DstTransfer struct {
+	ID       int64          `gorm:"primaryKey;autoIncrement"`
+	TxHash   string         `gorm:"uniqueIndex;size:66;not null"`
+	ChainID  uint64         `gorm:"type:bigint(20);not null"`
+	Standard uint8          `gorm:"type:int(8);not null"`
+	Time     uint64         `gorm:"type:bigint(20);not null"`
+	Asset    string         `gorm:"type:varchar(120);not null"`
+	From     string         `gorm:"type:varchar(66);not null"`
+	To       string         `gorm:"type:varchar(66);not null"`
+	TokenID  *models.BigInt `gorm:"type:varchar(86);not null"`
+}
+
+func (v1DstTransfer) TableName() string { return "dst_transfers" }
+
+type v1DstSwap struct {
+	ID         int64          `gorm:"primaryKey;autoIncrement"`
+	TxHash     string         `gorm:"uniqueIndex;size:66;not null"`
+	ChainID    uint64         `gorm:"type:bigint(20);not null"`
+	Time       uint64         `gorm:"type:bigint(20);not null"`
+	PoolID     uint64         `gorm:"type:bigint(20);not null"`
+	InAsset    string         `gorm:"type:varchar(66);not null"`
+	InTokenID  *models.BigInt `gorm:"type:varchar(86);not null"`
+	OutAsset   string         `gorm:"type:varchar(120);not null"`
+	OutTokenID *models.BigInt `gorm:"type:varchar(86);not null"`
+	DstChainID uint64         `gorm:"type:bigint(20);not null"`
+	DstAsset   string         `gorm:"type:varchar(120);not null"`
+	DstUser    string         `gorm:"type:varchar(66);not null"`
+	Type       uint64         `gorm:"type:bigint(20);not null"`
+}
+
+func (v1DstSwap) TableName() string { return "dst_swaps" }
+
+type v1WrapperTransaction struct {
+	ID           int64          `gorm:"primaryKey;autoIncrement"`
+	Hash         string         `gorm:"uniqueIndex;size:66;not null"`
+	User         string         `gorm:"type:varchar(66);not null"`
+	SrcChainID   uint64         `gorm:"type:bigint(20);not null"`
+	Standard     uint8          `gorm:"type:int(8);not null"`
+	BlockHeight  uint64         `gorm:"type:bigint(20);not null"`
+	Time         uint64         `gorm:"type:bigint(20);not null"`
+	DstChainID   uint64         `gorm:"type:bigint(20);not null"`
+	DstUser      string         `gorm:"type:varchar(66);not null"`
+	ServerID     uint64         `gorm:"type:bigint(20);not null"`
+	FeeTokenHash string         `gorm:"size:66;not null"`
+	FeeAmount    *models.BigInt `gorm:"type:varchar(64);not null"`
+	Status       uint64         `gorm:"type:bigint(20);not null"`
+}
+
+func (v1WrapperTransaction) TableName() string { return "wrapper_transactions" }
+
+type v1ErrorTransaction struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement"`
+	TxHash       string         `gorm:"type:varchar(150);not null"`
+	FromChainID  uint64         `gorm:"type:bigint(20);not null"`
+	FromContract string         `gorm:"type:varchar(150);not null"`
+	ToChainID    uint64         `gorm:"type:bigint(20);not null"`
+	ToContract   string         `gorm:"type:varchar(66);not null"`
+	ToAssetHash  string         `gorm:"type:varchar(66);not null"`
+	ToAddress    string         `gorm:"type:varchar(66);not null"`
+	TokenID      *models.BigInt `gorm:"type:varchar(86);not null"`
+	TokenURI     string         `gorm:"type:varchar(255);not null"`
+	Signature    string         `gorm:"not null"`
+	State        uint           `gorm:"default:1"`
+	ErrorMsg     string
+}
+
+func (v1ErrorTransaction) TableName() string { return "error_transactions" }
+
+type v1TxHashHistory struct {
+	ID      uint `gorm:"primaryKey;autoIncrement"`
+	ChainID uint64
+	TxHash  string
+}
+
+func (v1TxHashHistory) TableName() string { return "tx_hash_histories" }
+
+type v1TokenBasic struct {
+	ID              int64            `gorm:"primaryKey;autoIncrement"`
+	Name            string           `gorm:"uniqueIndex;size:64;not null"`
+	Precision       uint64           `gorm:"type:bigint(20);not null"`
+	Price           int64            `gorm:"size:64;not null"`
+	ChainID         uint64           `gorm:"type:bigint(20);not null"`
+	Ind             uint64           `gorm:"type:bigint(20);not null"`
+	Time            int64            `gorm:"type:bigint(20);not null"`
+	Property        int64            `gorm:"type:bigint(20);not null"`
+	Standard        uint8            `gorm:"type:int(8);not null"`
+	Meta            string           `gorm:"type:varchar(128)"`
+	TotalAmount     *models.BigInt   `gorm:"type:varchar(64)"`
+	TotalCount      uint64           `gorm:"type:bigint(20)"`
+	StatsUpdateTime int64            `gorm:"type:bigint(20)"`
+	SocialTwitter   string           `gorm:"type:varchar(256)"`
+	SocialTelegram  string           `gorm:"type:varchar(256)"`
+	SocialWebsite   string           `gorm:"type:varchar(256)"`
+	SocialOther     string           `gorm:"type:varchar(256)"`
+	MetaFetcherType int              `gorm:"type:int(8);not null"`
+	PriceMarkets    []*v1PriceMarket `gorm:"foreignKey:TokenBasicName;references:Name"`
+	Tokens          []*v1Token       `gorm:"foreignKey:TokenBasicName;references:Name"`
+}
+
+func (v1TokenBasic) TableName() string { return "token_basics" }
+
+type v1PriceMarket struct {
+	ID             int64         `gorm:"primaryKey;autoIncrement"`
+	TokenBasicName string        `gorm:"uniqueIndex:idx_tokenmarket;size:64;not null"`
+	MarketName     string        `gorm:"uniqueIndex:idx_tokenmarket;size:64;not null"`
+	Name           string        `gorm:"size:64;not null"`
+	Price          int64         `gorm:"type:bigint(20);not null"`
+	Ind            uint64        `gorm:"type:bigint(20);not null"`
+	Time           int64         `gorm:"type:bigint(20);not null"`
+	TokenBasic     *v1TokenBasic `gorm:"foreignKey:TokenBasicName;references:Name"`
+}
+
+func (v1PriceMarket) TableName() string { return "price_markets" }
+
+type v1ChainFee struct {
+	ID             int64          `gorm:"primaryKey;autoIncrement"`
+	ChainID        uint64         `gorm:"uniqueIndex;type:bigint(20);not null"`
+	TokenBasicName string         `gorm:"size:64;not null"`
+	TokenBasic     *v1TokenBasic  `gorm:"foreignKey:TokenBasicName;references:Name"`
+	MaxFee         *models.BigInt `gorm:"type:varchar(64);not null"`
+	MinFee         *models.BigInt `gorm:"type:varchar(64);not null"`
+	ProxyFee       *models.BigInt `gorm:"type:varchar(64);not null"`
+	Ind            uint64         `gorm:"type:bigint(20);not null"`
+	Time           int64          `gorm:"type:bigint(20);not null"`
+}
+
+func (v1ChainFee) TableName() string { return "chain_fees" }
+
+type v1Token struct {
+	ID              int64          `gorm:"primaryKey;autoIncrement"`
+	Hash            string         `gorm:"uniqueIndex:idx_token;size:66;not null"`
+	ChainID         uint64         `gorm:"uniqueIndex:idx_token;type:bigint(20);not null"`
+	Name            string         `gorm:"size:64;not null"`
+	Precision       uint64         `gorm:"type:bigint(20);not null"`
+	TokenBasicName  string         `gorm:"size:64;not null"`
+	Property        int64          `gorm:"type:bigint(20);not null"`
+	Standard        uint8          `gorm:"type:int(8);not null"`
+	TokenType       string         `gorm:"type:varchar(32)"`
+	AvailableAmount *models.BigInt `gorm:"type:varchar(64)"`
+	TokenBasic      *v1TokenBasic  `gorm:"foreignKey:TokenBasicName;references:Name"`
+	TokenMaps       []*v1TokenMap  `gorm:"foreignKey:SrcTokenHash,SrcChainID;references:Hash,ChainID"`
+}
+
+func (v1Token) TableName() string { return "tokens" }
+
+type v1TokenMap struct {
+	ID           int64    `gorm:"primaryKey;autoIncrement"`
+	SrcChainID   uint64   `gorm:"uniqueIndex:idx_token_map;type:bigint(20);not null"`
+	SrcTokenHash string   `gorm:"uniqueIndex:idx_token_map;size:66;not null"`
+	DstChainID   uint64   `gorm:"uniqueIndex:idx_token_map;type:bigint(20);not null"`
+	DstTokenHash string   `gorm:"uniqueIndex:idx_token_map;size:66;not null"`
+	SrcToken     *v1Token `gorm:"foreignKey:SrcTokenHash,SrcChainID;references:Hash,ChainID"`
+	DstToken     *v1Token `gorm:"foreignKey:DstTokenHash,DstChainID;references:Hash,ChainID"`
+	Standard     uint8    `gorm:"type:int(8);not null"`
+	Property     int64    `gorm:"type:bigint(20);not null"`
+}
+
+func (v1TokenMap) TableName() string { return "token_maps" }
+
+type v1TimeStatistic struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement"`
+	SrcChainID uint64 `gorm:"uniqueIndex:idx_chains;type:bigint(20);not null"`
+	DstChainID uint64 `gorm:"uniqueIndex:idx_chains;type:bigint(20);not null"`
+	Time       uint64 `gorm:"type:bigint(20);not null"`
+}
+
+func (v1TimeStatistic) TableName() string { return "time_statistics" }
+
+type v1NFTProfile struct {
+	ID             int64  `gorm:"primaryKey;autoIncrement"`
+	TokenBasicName string `gorm:"uniqueIndex:idx_name_token;size:64;not null"`
+	NftTokenID     string `gorm:"uniqueIndex:idx_name_token;type:varchar(64);not null"`
+	Name           string `gorm:"size:64;not null"`
+	URL            string `gorm:"size:64;not null"`
+	Image          string `gorm:"size:64;not null"`
+	Description    string `gorm:"type:varchar(256)"`
+	Text           string `gorm:"type:text"`
+}
+
+func (v1NFTProfile) TableName() string { return "nft_profiles" }
+
+type v1Snapshot struct {
+	ID    int64  `gorm:"primaryKey;autoIncrement"`
+	Hash  string `gorm:"not null;index:snapshot_hash;"`
+	Bytes string
+}
+
+func (v1Snapshot) TableName() string { return "snapshots" }
+
+type v1Block struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement"`
+	BlockHash string `gorm:"not null;uniqueIndex:block_hashcode;index:block_check,priority:1"`
+	Height    uint64 `gorm:"not null;uniqueIndex:block_height,sort:desc;index:block_check,priority:2,sort:desc"`
+	Bytes     string
+}
+
+func (v1Block) TableName() string { return "blocks" }
+
+// Columns and tables added by migration 2.
+
+type v2WrapperTransaction struct {
+	LinQHeight uint64 `gorm:"column:linq_height;type:bigint(20)"`
+	LinQHash   string `gorm:"column:linq_hash;size:66"`
+	DstHash    string `gorm:"index;size:66"`
+}
+
+func (v2WrapperTransaction) TableName() string { return "wrapper_transactions" }
+
+type v2ErrorTransaction struct {
+	Signers      string `gorm:"type:text"`
+	ValidatorSet string `gorm:"size:66"`
+	ErrorType    uint8  `gorm:"type:int(8);not null;default:0"`
+	Attempts     uint64 `gorm:"type:bigint(20);not null;default:0"`
+	NextRetry    uint64 `gorm:"type:bigint(20);not null;default:0"`
+}
+
+func (v2ErrorTransaction) TableName() string { return "error_transactions" }
+
+type v2RelayTask struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	TxHash    string `gorm:"uniqueIndex;size:66;not null"`
+	Height    uint64 `gorm:"type:bigint(20);not null"`
+	Rank      uint64 `gorm:"type:bigint(20);not null"`
+	Signers   string `gorm:"type:text;not null"`
+	Signature string `gorm:"type:text;not null"`
+	Deadline  uint64 `gorm:"type:bigint(20);not null"`
+	State     uint   `gorm:"default:1"`
+}
+
+func (v2RelayTask) TableName() string { return "relay_tasks" }
+
+// Tables created by migration 3.
+
+type v3Evidence struct {
+	ID    int64  `gorm:"primaryKey;autoIncrement"`
+	Hash  string `gorm:"not null;uniqueIndex:evidence_hash;size:66"`
+	Bytes string `gorm:"type:mediumtext"`
+}
+
+func (v3Evidence) TableName() string { return "evidences" }
+
+// Columns added by migration 4.
+
+type v4WrapperTransaction struct {
+	RelayTime uint64 `gorm:"type:bigint(20);not null;default:0"`
+}
+
+func (v4WrapperTransaction) TableName() string { return "wrapper_transactions" }
+
+// Column altered and index added by migration 5.
+
+type v5TxHashHistory struct {
+	ID      uint   `gorm:"primaryKey;autoIncrement"`
+	ChainID uint64 `gorm:"uniqueIndex:idx_tx_hash_chain"`
+	TxHash  string `gorm:"uniqueIndex:idx_tx_hash_chain;size:66"`
+}
+
+func (v5TxHashHistory) TableName() string { return "tx_hash_histories" }
@@ -3,9 +3,14 @@ package listener
 import (
 	"fmt"
 	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 
 	"land-bridge/conf"
 	"land-bridge/handle/dao"
@@ -15,29 +20,99 @@ import (
 	"land-bridge/models"
 )
 
-var chainListens [12]*ChainListen
+var (
+	chainListensMu sync.Mutex
+	chainListens   = make(map[uint64]*ChainListen)
+	listenConfigs  = make(map[uint64]*conf.ChainListenConfig)
+	listenDB       dao.Repository
 
-func StartCrossChainListen(cfg []*conf.ChainListenConfig, dbCfg *conf.DBConfig) {
-	dao := dao.NewBridgeDao(dbCfg)
-	if dao == nil {
+	// relayTimeout is how many seconds a relayed wrapper may wait for its
+	// destination execution before it is failed, accessed atomically
+	relayTimeout int64 = defaultRelayTimeout
+)
+
+const (
+	// relayCheckInterval is how often relaying wrappers are checked against the destination chain
+	relayCheckInterval = time.Second * 30
+	// defaultRelayTimeout is the relay timeout in seconds when the config does not set it
+	defaultRelayTimeout = 3600
+)
+
+func StartCrossChainListen(cfg []*conf.ChainListenConfig, relay *conf.RelayConfig, dbCfg *conf.DBConfig) {
+	db := dao.NewBridgeDao(dbCfg)
+	if db == nil {
 		panic("sql server is invalid")
 	}
-	for i, clc := range cfg {
+	chainListensMu.Lock()
+	listenDB = db
+	chainListensMu.Unlock()
+	if err := ReloadCrossChainListen(cfg, relay); err != nil {
+		panic(err)
+	}
+}
+
+// ReloadCrossChainListen brings the running listeners in line with cfg: the
+// listeners of removed chains are stopped, those of new chains started, and
+// those whose configuration changed are restarted from their stored height.
+// The relay timeout of relay applies to all of them.
+func ReloadCrossChainListen(cfg []*conf.ChainListenConfig, relay *conf.RelayConfig) error {
+	timeout := int64(defaultRelayTimeout)
+	if relay != nil && relay.ExecutionTimeout > 0 {
+		timeout = int64(relay.ExecutionTimeout)
+	}
+	atomic.StoreInt64(&relayTimeout, timeout)
+
+	chainListensMu.Lock()
+	defer chainListensMu.Unlock()
+
+	wanted := make(map[uint64]*conf.ChainListenConfig, len(cfg))
+	for _, clc := range cfg {
+		wanted[clc.ChainID] = clc
+	}
+	for chainID, chainListen := range chainListens {
+		clc, ok := wanted[chainID]
+		if ok && reflect.DeepEqual(clc, listenConfigs[chainID]) {
+			continue
+		}
+		if ok {
+			logs.Info("chain %s(%d) configuration changed, restarting its listener", clc.ChainName, chainID)
+		} else {
+			logs.Info("chain %d removed from the configuration, stopping its listener", chainID)
+		}
+		chainListen.Stop()
+		delete(chainListens, chainID)
+		delete(listenConfigs, chainID)
+	}
+	for _, clc := range cfg {
+		if _, ok := chainListens[clc.ChainID]; ok {
+			continue
+		}
 		core := NewChainListenCore(clc)
 		if core == nil {
-			panic(fmt.Sprintf("chain %s(%d) is not supported", clc.ChainName, clc.ChainID))
+			return fmt.Errorf("chain %s(%d) is not supported", clc.ChainName, clc.ChainID)
 		}
-		chainListen := NewChainListen(core, dao)
+		chainListen := NewChainListen(core, listenDB)
 		chainListen.Start()
-		chainListens[i] = chainListen
+		chainListens[clc.ChainID] = chainListen
+		listenConfigs[clc.ChainID] = clc
 	}
+	return nil
 }
 
 func StopCrossChainListen() {
-	for _, chainListen := range chainListens {
-		if chainListen != nil {
-			chainListen.Stop()
+	chainListensMu.Lock()
+	defer chainListensMu.Unlock()
+
+	for chainID, chainListen := range chainListens {
+		chainListen.Stop()
+		delete(chainListens, chainID)
+		delete(listenConfigs, chainID)
+	}
+	if listenDB != nil {
+		if err := listenDB.Close(); err != nil {
+			logs.Error("close listener database: %v", err)
 		}
+		listenDB = nil
 	}
 }
 
@@ -48,6 +123,7 @@ type ChainListenCore interface {
 	GetBatchSize() uint64
 	GetDefer() uint64
 	GetLatestHeight() (uint64, error)
+	GetTransactionReceipt(hash common.Hash) (*types.Receipt, error)
 	HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.DstTransaction, int, int, error)
 }
 
@@ -68,12 +144,12 @@ func NewChainListenCore(clc *conf.ChainListenConfig) ChainListenCore {
 
 type ChainListen struct {
 	core   ChainListenCore
-	db     *dao.BridgeDao
+	db     dao.Repository
 	height uint64
 	exit   chan bool
 }
 
-func NewChainListen(core ChainListenCore, db *dao.BridgeDao) *ChainListen {
+func NewChainListen(core ChainListenCore, db dao.Repository) *ChainListen {
 	return &ChainListen{
 		core: core,
 		db:   db,
@@ -150,8 +226,12 @@ func (cl *ChainListen) listenChain() (exit bool) {
 	}
 	timedelay := time.Second
 	ticker := time.NewTimer(timedelay)
+	relayTicker := time.NewTimer(relayCheckInterval)
 	for {
 		select {
+		case <-relayTicker.C:
+			cl.checkRelayingWrappers()
+			relayTicker.Reset(relayCheckInterval)
 		case <-ticker.C:
 			height, err := cl.core.GetLatestHeight()
 			if err != nil || height == 0 || height == math.MaxUint64 {
@@ -224,3 +304,37 @@ func (cl *ChainListen) listenChainHeight(height uint64) bool {
 	}
 	return true
 }
+
+// checkRelayingWrappers fails wrappers destined to this chain whose relayer
+// transaction reverted, or which were not executed within relayTimeout of
+// their first relay submission. Wrappers not submitted yet are left to the
+// relay and retry pipeline. Successful executions are finished by
+// UpdateEvents from the destination events.
+func (cl *ChainListen) checkRelayingWrappers() {
+	wrapperTransactions, err := cl.db.GetRelayingWrappers(cl.core.GetChainID())
+	if err != nil {
+		logs.Error("GetRelayingWrappers chain %d err: %v", cl.core.GetChainID(), err)
+		return
+	}
+	now := uint64(time.Now().Unix())
+	timeout := uint64(atomic.LoadInt64(&relayTimeout))
+	for _, wrapperTransaction := range wrapperTransactions {
+		failed := false
+		if wrapperTransaction.DstHash != "" {
+			receipt, err := cl.core.GetTransactionReceipt(common.HexToHash(wrapperTransaction.DstHash))
+			if err == nil && receipt != nil && receipt.Status == types.ReceiptStatusFailed {
+				logs.Warn("relay tx %s of wrapper %s reverted on chain %d", wrapperTransaction.DstHash, wrapperTransaction.Hash, cl.core.GetChainID())
+				failed = true
+			}
+		}
+		if !failed && wrapperTransaction.RelayTime > 0 && wrapperTransaction.RelayTime+timeout < now {
+			logs.Warn("wrapper %s not executed on chain %d in %ds", wrapperTransaction.Hash, cl.core.GetChainID(), timeout)
+			failed = true
+		}
+		if failed {
+			if err := cl.db.FailWrapper(wrapperTransaction); err != nil {
+				logs.Error("FailWrapper %s err: %v", wrapperTransaction.Hash, err)
+			}
+		}
+	}
+}
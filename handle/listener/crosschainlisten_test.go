@@ -0,0 +1,107 @@
+package listener
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"land-bridge/constant"
+	"land-bridge/handle/dao"
+	"land-bridge/models"
+)
+
+// fakeChain is a ChainListenCore serving canned events and receipts.
+type fakeChain struct {
+	chainID  uint64
+	height   uint64
+	events   map[uint64][]*models.WrapperTransaction
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (c *fakeChain) GetChainName() string             { return "fake" }
+func (c *fakeChain) GetChainID() uint64               { return c.chainID }
+func (c *fakeChain) GetChainListenSlot() uint64       { return 1 }
+func (c *fakeChain) GetBatchSize() uint64             { return 1 }
+func (c *fakeChain) GetDefer() uint64                 { return 0 }
+func (c *fakeChain) GetLatestHeight() (uint64, error) { return c.height, nil }
+
+func (c *fakeChain) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	receipt, ok := c.receipts[hash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return receipt, nil
+}
+
+func (c *fakeChain) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.DstTransaction, int, int, error) {
+	return c.events[height], nil, nil, 0, 0, nil
+}
+
+func TestListenChainHeight(t *testing.T) {
+	db := dao.NewMemoryDao()
+	chain := &fakeChain{
+		chainID: 2,
+		events: map[uint64][]*models.WrapperTransaction{
+			7: {{Hash: "aa", SrcChainID: 2, DstChainID: 6, Status: constant.STATE_SOURCE_DONE}},
+		},
+	}
+	cl := NewChainListen(chain, db)
+	if !cl.listenChainHeight(7) {
+		t.Fatal("listenChainHeight failed")
+	}
+	w, err := db.GetWrapper("aa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.DstChainID != 6 || w.Status != constant.STATE_SOURCE_DONE {
+		t.Errorf("stored wrapper %+v", w)
+	}
+}
+
+func TestCheckRelayingWrappers(t *testing.T) {
+	now := uint64(time.Now().Unix())
+	reverted := common.HexToHash("0x01")
+	pending := common.HexToHash("0x02")
+	chain := &fakeChain{
+		chainID: 6,
+		receipts: map[common.Hash]*types.Receipt{
+			reverted: {Status: types.ReceiptStatusFailed},
+		},
+	}
+	tests := []struct {
+		name    string
+		wrapper models.WrapperTransaction
+		failed  bool
+	}{
+		{"reverted", models.WrapperTransaction{Hash: "a1", DstHash: reverted.Hex()[2:], RelayTime: now}, true},
+		{"timed out", models.WrapperTransaction{Hash: "a2", DstHash: pending.Hex()[2:], RelayTime: now - defaultRelayTimeout - 1}, true},
+		{"in time", models.WrapperTransaction{Hash: "a3", DstHash: pending.Hex()[2:], RelayTime: now - 10}, false},
+		{"not submitted", models.WrapperTransaction{Hash: "a4", Time: now - defaultRelayTimeout - 1}, false},
+	}
+	db := dao.NewMemoryDao()
+	var wrappers []*models.WrapperTransaction
+	for i := range tests {
+		w := tests[i].wrapper
+		w.DstChainID = chain.chainID
+		w.Status = constant.STATE_SOURCE_CONFIRMED
+		wrappers = append(wrappers, &w)
+	}
+	if err := db.UpdateEvents(wrappers, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	NewChainListen(chain, db).checkRelayingWrappers()
+
+	for _, tt := range tests {
+		w, err := db.GetWrapper(tt.wrapper.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed := w.Status == constant.STATE_FAILED; failed != tt.failed {
+			t.Errorf("%s: failed = %v, want %v", tt.name, failed, tt.failed)
+		}
+	}
+}
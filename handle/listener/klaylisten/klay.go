@@ -10,6 +10,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 
 	"land-bridge/conf"
 	"land-bridge/constant"
@@ -57,6 +58,10 @@ func (k *KlayChainListen) GetLatestHeight() (uint64, error) {
 	return k.klaySdk.GetLatestHeight()
 }
 
+func (k *KlayChainListen) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	return k.klaySdk.GetTransactionReceipt(hash)
+}
+
 func (k *KlayChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.DstTransaction, int, int, error) {
 	header, err := k.klaySdk.GetHeaderByNumber(height)
 	if err != nil {
@@ -287,9 +292,9 @@ func (k *KlayChainListen) getECCMEventByBlockNumber(contractAddr string, startHe
 		evt := executeTxEvent.Event
 		Fee := k.GetConsumeGas(evt.Raw.TxHash)
 		eccmUnlockEvents = append(eccmUnlockEvents, &models.ECCMUnlockEvent{
-			Method: utils.Crosschainunlock,
-			TxHash: evt.Raw.TxHash.String()[2:],
-			//RTxHash:  utils.HexStringReverse(hex.EncodeToString(evt.CrossChainTxHash)),
+			Method:   utils.Crosschainunlock,
+			TxHash:   evt.Raw.TxHash.String()[2:],
+			RTxHash:  hex.EncodeToString(evt.FromChainTxHash),
 			Contract: hex.EncodeToString(evt.ToContract),
 			FChainID: uint32(evt.FromChainID),
 			Height:   evt.Raw.BlockNumber,
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 
 	"land-bridge/conf"
 	"land-bridge/constant"
@@ -56,6 +57,10 @@ func (g *PlatonChainListen) GetLatestHeight() (uint64, error) {
 	return g.platonSdk.GetLatestHeight()
 }
 
+func (g *PlatonChainListen) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	return g.platonSdk.GetTransactionReceipt(hash)
+}
+
 func (g *PlatonChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.DstTransaction, int, int, error) {
 	header, err := g.platonSdk.GetHeaderByNumber(height)
 	if err != nil {
@@ -275,9 +280,9 @@ func (g *PlatonChainListen) getECCMEventByBlockNumber(contractAddr string, start
 		evt := executeTxEvent.Event
 		Fee := g.GetConsumeGas(evt.Raw.TxHash)
 		eccmUnlockEvents = append(eccmUnlockEvents, &models.ECCMUnlockEvent{
-			Method: utils.Crosschainunlock,
-			TxHash: evt.Raw.TxHash.String()[2:],
-			//RTxHash:  utils.HexStringReverse(hex.EncodeToString(evt.CrossChainTxHash)),
+			Method:   utils.Crosschainunlock,
+			TxHash:   evt.Raw.TxHash.String()[2:],
+			RTxHash:  hex.EncodeToString(evt.FromChainTxHash),
 			Contract: hex.EncodeToString(evt.ToContract),
 			FChainID: uint32(evt.FromChainID),
 			Height:   evt.Raw.BlockNumber,
@@ -147,6 +147,10 @@ type WrapperTransaction struct {
 	FeeTokenHash string  `gorm:"size:66;not null"`
 	FeeAmount    *BigInt `gorm:"type:varchar(64);not null"`
 	Status       uint64  `gorm:"type:bigint(20);not null"`
+	LinQHeight   uint64  `gorm:"column:linq_height;type:bigint(20)"`
+	LinQHash     string  `gorm:"column:linq_hash;size:66"`
+	DstHash      string  `gorm:"index;size:66"`
+	RelayTime    uint64  `gorm:"type:bigint(20);not null;default:0"` // first relay submission
 }
 
 type SrcPolyDstRelation struct {
@@ -243,12 +247,28 @@ type ErrorTransaction struct {
 	TokenID      *BigInt `gorm:"type:varchar(86);not null"`
 	TokenURI     string  `gorm:"type:varchar(255);not null"`
 	Signature    string  `gorm:"not null"`
+	Signers      string  `gorm:"type:text"`
+	ValidatorSet string  `gorm:"size:66"`
 	State        uint    `gorm:"default:1"`
+	ErrorType    uint8   `gorm:"type:int(8);not null;default:0"`
+	Attempts     uint64  `gorm:"type:bigint(20);not null;default:0"`
+	NextRetry    uint64  `gorm:"type:bigint(20);not null;default:0"`
 	ErrorMsg     string
 }
 
+type RelayTask struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	TxHash    string `gorm:"uniqueIndex;size:66;not null"`
+	Height    uint64 `gorm:"type:bigint(20);not null"`
+	Rank      uint64 `gorm:"type:bigint(20);not null"`
+	Signers   string `gorm:"type:text;not null"`
+	Signature string `gorm:"type:text;not null"`
+	Deadline  uint64 `gorm:"type:bigint(20);not null"`
+	State     uint   `gorm:"default:1"`
+}
+
 type TxHashHistory struct {
 	ID      uint   `gorm:"primaryKey;autoIncrement"`
-	ChainID uint64 `json:"chain_id"`
-	TxHash  string `json:"uniqueIndex;tx_hash"`
+	ChainID uint64 `json:"chain_id" gorm:"uniqueIndex:idx_tx_hash_chain"`
+	TxHash  string `json:"tx_hash" gorm:"uniqueIndex:idx_tx_hash_chain;size:66"`
 }
@@ -29,3 +29,10 @@ type Block struct {
 	Height    uint64 `gorm:"not null;uniqueIndex:block_height,sort:desc;index:block_check,priority:2,sort:desc"`
 	Bytes     string
 }
+
+// Evidence is a signed proof of a validator equivocating in LBFT.
+type Evidence struct {
+	ID    int64  `gorm:"primaryKey;autoIncrement"`
+	Hash  string `gorm:"not null;uniqueIndex:evidence_hash;size:66"`
+	Bytes string `gorm:"type:mediumtext"`
+}
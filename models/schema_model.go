@@ -0,0 +1,12 @@
+package models
+
+// SchemaVersion records an applied schema migration.
+type SchemaVersion struct {
+	Version   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:128;not null"`
+	AppliedAt uint64 `gorm:"type:bigint(20);not null"`
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
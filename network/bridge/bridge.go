@@ -3,65 +3,137 @@ package bridge
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
+	"fmt"
 	"math/big"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"gorm.io/gorm"
+	lru "github.com/hashicorp/golang-lru"
 
 	"land-bridge/conf"
 	"land-bridge/constant"
 	"land-bridge/contracts/eccm"
+	"land-bridge/handle/dao"
 	"land-bridge/models"
 )
 
+var errBridgeClosed = errors.New("bridge is shutting down")
+
 type Bridge struct {
-	db         *gorm.DB
-	bq         *Queryer
-	proxyAddrs map[uint64]string
-	signer     *Signer
-	priv       *ecdsa.PrivateKey
-	chainMap   map[uint64]*conf.ChainListenConfig
+	db     dao.Repository
+	signer *Signer
+	priv   *ecdsa.PrivateKey
+
+	settingsMu sync.RWMutex
+	settings   *settings
+
+	requestSignatures SignatureRequester
+	verified          *lru.Cache // transfers checked against their source chain
+	quit              chan struct{}
+	wg                sync.WaitGroup
+
+	keepersMu sync.Mutex
+	keepers   map[uint64]*keeperSet // ECCM keepers of each destination chain
+
+	// ctx is cancelled once the shutdown deadline passes, aborting the
+	// submissions still in flight.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	closeMu  sync.RWMutex
+	closing  bool
+	inflight sync.WaitGroup
 }
 
-func NewBridge(db *gorm.DB, cfg *conf.Config, priv *ecdsa.PrivateKey) *Bridge {
-	mapProxyAddrs := make(map[uint64]string)
+// settings is the part of the bridge configuration which can be reloaded:
+// the RPC endpoints, contracts and gas policy of each chain, and the retry
+// and backup relay timing.
+type settings struct {
+	bq            *Queryer
+	proxyAddrs    map[uint64]string
+	chainMap      map[uint64]*conf.ChainListenConfig
+	retry         *conf.RetryConfig
+	backupTimeout uint64
+}
 
+func newSettings(cfg *conf.Config) *settings {
+	s := &settings{
+		bq:            NewBridgeQueryer(cfg),
+		proxyAddrs:    make(map[uint64]string),
+		chainMap:      make(map[uint64]*conf.ChainListenConfig),
+		retry:         newRetryConfig(cfg.Retry),
+		backupTimeout: defaultBackupTimeout,
+	}
 	for _, chain := range cfg.Chains {
-		mapProxyAddrs[chain.ChainID] = chain.NFTProxyContract
+		s.proxyAddrs[chain.ChainID] = chain.NFTProxyContract
+		s.chainMap[chain.ChainID] = chain
 	}
+	if cfg.Relay != nil && cfg.Relay.BackupTimeout > 0 {
+		s.backupTimeout = cfg.Relay.BackupTimeout
+	}
+	return s
+}
 
+func NewBridge(db dao.Repository, cfg *conf.Config, priv *ecdsa.PrivateKey) *Bridge {
 	addr := crypto.PubkeyToAddress(priv.PublicKey)
 	signer := NewSigner(priv, &addr)
-	bridgeQueryer := NewBridgeQueryer(cfg)
-
-	chainMap := make(map[uint64]*conf.ChainListenConfig)
-	for _, chain := range cfg.Chains {
-		chainMap[chain.ChainID] = chain
-	}
 
+	verified, _ := lru.New(verifiedCacheSize)
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Bridge{
-		db:         db,
-		bq:         bridgeQueryer,
-		proxyAddrs: mapProxyAddrs,
-		signer:     signer,
-		priv:       priv,
-		chainMap:   chainMap,
+		db:       db,
+		signer:   signer,
+		priv:     priv,
+		settings: newSettings(cfg),
+		verified: verified,
+		keepers:  make(map[uint64]*keeperSet),
+		quit:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 }
 
+// Reload applies the chain, retry and relay sections of cfg. Submissions
+// already in flight finish with the settings they started with.
+func (b *Bridge) Reload(cfg *conf.Config) {
+	s := newSettings(cfg)
+	b.settingsMu.Lock()
+	b.settings = s
+	b.settingsMu.Unlock()
+}
+
+func (b *Bridge) current() *settings {
+	b.settingsMu.RLock()
+	defer b.settingsMu.RUnlock()
+	return b.settings
+}
+
+// Sign signs a transfer which passed VerifyTxParams. A transfer no longer
+// remembered as verified is verified again first.
 func (b *Bridge) Sign(tx *TxParam) ([]byte, error) {
+	if !b.verified.Contains(paramKey(tx)) {
+		if err := b.verifyCommitted(tx); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUnverifiedTransfer, err)
+		}
+	}
 	return b.signer.Sign(tx)
 }
 
 func (b *Bridge) UpdateWrapper(txhash common.Hash) error {
-	hashStr := txhash.Hex()[2:]
-	wrapperTransaction := new(models.WrapperTransaction)
-	if err := b.db.Model(&wrapperTransaction).Where("hash = ?", hashStr).Update("status", constant.STATE_SOURCE_CONFIRMED).Error; err != nil {
-		logs.Error("GORM UpdateWrapper err", err)
+	if !b.track() {
+		return errBridgeClosed
+	}
+	defer b.inflight.Done()
+	if err := b.db.UpdateWrapperStatus(txhash.Hex()[2:], constant.STATE_SOURCE_CONFIRMED); err != nil {
+		logs.Error("UpdateWrapper err", err)
 		return err
 	}
 
@@ -69,95 +141,219 @@ func (b *Bridge) UpdateWrapper(txhash common.Hash) error {
 }
 
 func (b *Bridge) PendingWrapper(wt *models.WrapperTransaction) error {
-	b.db.Model(wt).Update("status", constant.STATE_PENDDING)
+	if err := b.db.UpdateWrapperStatus(wt.Hash, constant.STATE_PENDDING); err != nil {
+		return err
+	}
+	wt.Status = constant.STATE_PENDDING
 	return nil
 }
 
 func (b *Bridge) PendingWrapperSkip(wt *models.WrapperTransaction) error {
-	b.db.Model(wt).Update("status", constant.STATE_SOURCE_CONFIRMED)
+	if err := b.db.UpdateWrapperStatus(wt.Hash, constant.STATE_SOURCE_CONFIRMED); err != nil {
+		return err
+	}
+	wt.Status = constant.STATE_SOURCE_CONFIRMED
 	return nil
 }
 
 func (b *Bridge) BridgeMakeTx(wrapperTransaction *models.WrapperTransaction) (*TxParam, error) {
-	srcTransfer := new(models.SrcTransfer)
-	if err := b.db.Where("tx_hash = ?", wrapperTransaction.Hash).First(&srcTransfer).Error; err != nil {
+	srcTransfer, err := b.db.GetSrcTransfer(wrapperTransaction.Hash)
+	if err != nil {
 		return nil, err
 	}
-	tokenURI, err := b.bq.GetTokenURIByAssetWithID(wrapperTransaction.SrcChainID, srcTransfer.Asset, &srcTransfer.TokenID.Int)
+	s := b.current()
+	tokenURI, err := s.bq.GetTokenURIByAssetWithID(wrapperTransaction.SrcChainID, srcTransfer.Asset, &srcTransfer.TokenID.Int)
 	if err != nil {
 		logs.Error("GetTokenURIByAssetWithID error", err)
 		return nil, err
 	}
 
-	tx := ConstructTx(wrapperTransaction, srcTransfer, b.proxyAddrs[wrapperTransaction.DstChainID], tokenURI)
+	tx := ConstructTx(wrapperTransaction, srcTransfer, s.proxyAddrs[wrapperTransaction.DstChainID], tokenURI)
 
 	return tx, nil
 }
 
+// track registers an in-flight call unless the bridge is shutting down.
+func (b *Bridge) track() bool {
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+	if b.closing {
+		return false
+	}
+	b.inflight.Add(1)
+	return true
+}
+
+// BridgeToChainB relays a committed transfer to its destination chain. Once
+// the bridge is shutting down the transfer is left to the relay worker of the
+// next run instead.
 func (b *Bridge) BridgeToChainB(txhash common.Hash, signatures map[common.Address][]byte) error {
+	if !b.track() {
+		logs.Warn("bridge is shutting down, deferring relay of %s", txhash.Hex())
+		return b.createRelayTask(txhash, 0, 0, signatures)
+	}
+	defer b.inflight.Done()
+	return b.bridgeToChainB(txhash, signatures)
+}
+
+func (b *Bridge) bridgeToChainB(txhash common.Hash, signatures map[common.Address][]byte) error {
 	hashStr := txhash.Hex()[2:]
-	wrapperTransaction := new(models.WrapperTransaction)
-	if err := b.db.Where("hash = ?", hashStr).First(&wrapperTransaction).Error; err != nil {
+	wrapperTransaction, err := b.db.GetWrapper(hashStr)
+	if err != nil {
 		logs.Error("BridgeToChainB wrapperTransaction", err)
 		return err
 	}
-	srcTransfer := new(models.SrcTransfer)
-	if err := b.db.Where("tx_hash = ?", hashStr).First(&srcTransfer).Error; err != nil {
+	srcTransfer, err := b.db.GetSrcTransfer(hashStr)
+	if err != nil {
 		logs.Error("BridgeToChainB srcTransfer", err)
 		return err
 	}
 
-	tokenURI, err := b.bq.GetTokenURIByAssetWithID(wrapperTransaction.SrcChainID, srcTransfer.Asset, &srcTransfer.TokenID.Int)
+	s := b.current()
+	tokenURI, err := s.bq.GetTokenURIByAssetWithID(wrapperTransaction.SrcChainID, srcTransfer.Asset, &srcTransfer.TokenID.Int)
 	if err != nil {
 		logs.Error("GetTokenURIByAssetWithID error", err)
 		return err
 	}
 
-	tx := ConstructTx(wrapperTransaction, srcTransfer, b.proxyAddrs[wrapperTransaction.DstChainID], tokenURI)
-
-	b.db.Model(wrapperTransaction).Update("status", constant.STATE_SOURCE_CONFIRMED)
+	tx := ConstructTx(wrapperTransaction, srcTransfer, s.proxyAddrs[wrapperTransaction.DstChainID], tokenURI)
 
-	var argSignature []byte
-	for _, s := range signatures {
-		argSignature = append(argSignature, s...)
+	if err := b.db.UpdateWrapperStatus(hashStr, constant.STATE_SOURCE_CONFIRMED); err != nil {
+		logs.Error("BridgeToChainB update wrapper err", err)
 	}
-	tx.SetSignatures(argSignature)
 
-	chainConf := b.chainMap[tx.ChainID()]
-	var rawClient *ethclient.Client
-	for rawClient == nil {
-		for _, s := range chainConf.GetNodesURL() {
-			rawClient, _ = ethclient.Dial(s)
-			if rawClient != nil {
-				break
-			}
-		}
+	var execTxHash common.Hash
+	signers, argSignature, err := b.collectSignatures(tx, signatures)
+	if err == nil {
+		tx.SetSignatures(argSignature)
+		execTxHash, err = b.submit(tx)
+	} else {
+		// only the valid ones are kept, the retry worker asks the validators
+		// for the missing signatures
+		signers, argSignature = encodeSignatures(filterSignatures(tx, signatures, nil))
 	}
-	execTxHash, err := b.transactionExec(tx, chainConf, rawClient)
 	if err != nil {
 		logs.Error("transactionExec error", err)
+		errorType := classifyError(err)
+		nextRetry := uint64(time.Now().Unix()) + b.retryDelay(errorType, 0)
+		if b.ctx.Err() != nil {
+			// interrupted by the shutdown, retry as soon as the node is back
+			nextRetry = uint64(time.Now().Unix())
+		}
 		errorT := &models.ErrorTransaction{
 			TxHash:       wrapperTransaction.Hash,
 			FromChainID:  wrapperTransaction.SrcChainID,
 			FromContract: srcTransfer.Asset,
 			ToChainID:    wrapperTransaction.DstChainID,
-			ToContract:   b.proxyAddrs[wrapperTransaction.DstChainID],
+			ToContract:   s.proxyAddrs[wrapperTransaction.DstChainID],
 			ToAssetHash:  srcTransfer.DstAsset,
 			ToAddress:    srcTransfer.DstUser,
 			TokenID:      srcTransfer.TokenID,
 			TokenURI:     tokenURI,
 			Signature:    common.Bytes2Hex(argSignature),
+			Signers:      strings.Join(signers, ","),
+			ValidatorSet: b.keeperSetHash(tx.ChainID()),
+			State:        constant.ERROR_STATE_PENDING,
+			ErrorType:    errorType,
+			NextRetry:    nextRetry,
 			ErrorMsg:     err.Error(),
 		}
-		b.db.Create(errorT)
+		if err := b.db.CreateErrorTransaction(errorT); err != nil {
+			logs.Error("BridgeToChainB create error transaction err", err)
+		}
 		return err
 	}
 
-	logs.Info("bridge cross txHash:", execTxHash.Hex())
+	b.relayed(wrapperTransaction, execTxHash)
 
 	return nil
 }
 
+// relayed records the destination transaction which relays the wrapper.
+func (b *Bridge) relayed(wrapperTransaction *models.WrapperTransaction, execTxHash common.Hash) {
+	logs.Info("bridge cross txHash:", execTxHash.Hex())
+	if err := b.db.SetWrapperDstHash(wrapperTransaction.Hash, execTxHash.Hex()[2:]); err != nil {
+		logs.Error("update dst_hash err", err)
+	}
+}
+
+// submit sends a signed TxParam to the cross chain manager of its destination chain.
+func (b *Bridge) submit(tx *TxParam) (common.Hash, error) {
+	chainConf, ok := b.current().chainMap[tx.ChainID()]
+	if !ok {
+		return common.Hash{}, fmt.Errorf("chain %d is not configured", tx.ChainID())
+	}
+	rawClient, err := b.dialDestination(chainConf)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer rawClient.Close()
+
+	// An execution the destination would reject, for instance because another
+	// relayer already executed the transfer, is not worth paying for.
+	if err := b.dryRun(tx, chainConf, rawClient); err != nil {
+		return common.Hash{}, fmt.Errorf("dry run: %w", err)
+	}
+
+	return b.transactionExec(tx, chainConf, rawClient)
+}
+
+// dialDestination connects to the first node of a chain answering a call,
+// going over the nodes up to dialAttempts times with a growing pause. Its
+// errors are classified as ERROR_RPC_DOWN.
+func (b *Bridge) dialDestination(chainConf *conf.ChainListenConfig) (*ethclient.Client, error) {
+	urls := chainConf.GetNodesURL()
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("%w: chain %d has no nodes", errRPCDown, chainConf.ChainID)
+	}
+	var lastErr error
+	delay := dialBackoff
+	for attempt := 0; attempt < dialAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+				delay *= 2
+			case <-b.ctx.Done():
+				return nil, b.ctx.Err()
+			}
+		}
+		for _, url := range urls {
+			client, err := ethclient.DialContext(b.ctx, url)
+			if err == nil {
+				// dialing HTTP endpoints does not connect, the first call does
+				if _, err = client.ChainID(b.ctx); err == nil {
+					return client, nil
+				}
+				client.Close()
+			}
+			if b.ctx.Err() != nil {
+				return nil, b.ctx.Err()
+			}
+			lastErr = err
+		}
+	}
+	return nil, fmt.Errorf("%w: chain %d: %v", errRPCDown, chainConf.ChainID, lastErr)
+}
+
+// dryRun simulates the execution of tx on the destination chain with eth_call.
+func (b *Bridge) dryRun(tx *TxParam, chainConf *conf.ChainListenConfig, client *ethclient.Client) error {
+	parsed, err := abi.JSON(strings.NewReader(eccm.EthCrossChainManagerABI))
+	if err != nil {
+		return err
+	}
+	data, err := parsed.Pack("verifySigAndExecuteTx", tx.Serialize(), tx.GetSignatures())
+	if err != nil {
+		return err
+	}
+	ccmContractAddr := common.HexToAddress(chainConf.CCMContract)
+	_, err = client.CallContract(b.ctx, ethereum.CallMsg{
+		From: crypto.PubkeyToAddress(b.priv.PublicKey),
+		To:   &ccmContractAddr,
+		Data: data,
+	}, nil)
+	return err
+}
+
 func (b *Bridge) transactionExec(tx *TxParam, chainConf *conf.ChainListenConfig, client *ethclient.Client) (common.Hash, error) {
 	ccmContractAddr := common.HexToAddress(chainConf.CCMContract)
 	ccm, err := eccm.NewEthCrossChainManager(ccmContractAddr, client)
@@ -168,25 +364,10 @@ func (b *Bridge) transactionExec(tx *TxParam, chainConf *conf.ChainListenConfig,
 	if err != nil {
 		return common.Hash{}, err
 	}
-	auth.GasLimit = uint64(400000)
-
-	// TODO Test code - the lowest gasPrice
-	gasPrice := big.NewInt(0)
-	switch chainConf.ChainID {
-	case 97:
-		gasPrice = big.NewInt(10000000000)
-	case 1001:
-		gasPrice = big.NewInt(750000000000)
-	case 210309:
-		gasPrice = big.NewInt(1000000000)
-	default:
-		gasPrice, err = client.SuggestGasPrice(context.Background())
-		if err != nil {
-			return common.Hash{}, err
-		}
+	auth.Context = b.ctx
+	if err := setGas(b.ctx, auth, chainConf.Gas, client); err != nil {
+		return common.Hash{}, err
 	}
-	auth.GasPrice = gasPrice
-	// ------------
 
 	txback, err := ccm.VerifySigAndExecuteTx(auth, tx.Serialize(), tx.GetSignatures())
 	if err != nil {
@@ -0,0 +1,97 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+
+	"land-bridge/conf"
+)
+
+const defaultGasLimit = uint64(400000)
+
+func gwei(v uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(v), big.NewInt(params.GWei))
+}
+
+// capPrice limits price to ceiling gwei, a zero ceiling meaning no limit.
+func capPrice(price *big.Int, ceiling uint64) *big.Int {
+	if ceiling > 0 && price.Cmp(gwei(ceiling)) > 0 {
+		return gwei(ceiling)
+	}
+	return price
+}
+
+// setGas prices auth according to the gas strategy of the destination chain
+// and checks the worst-case cost against the configured spending cap.
+func setGas(ctx context.Context, auth *bind.TransactOpts, gas *conf.GasConfig, client *ethclient.Client) error {
+	if gas == nil {
+		gas = &conf.GasConfig{Strategy: conf.GasLegacy}
+	}
+	auth.GasLimit = defaultGasLimit
+	if gas.GasLimit > 0 {
+		auth.GasLimit = gas.GasLimit
+	}
+
+	var maxPrice *big.Int
+	switch gas.Strategy {
+	case conf.GasFixed:
+		auth.GasPrice = gwei(gas.GasPrice)
+		maxPrice = auth.GasPrice
+	case conf.GasMultiplier:
+		suggest, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return err
+		}
+		price, _ := new(big.Float).Mul(new(big.Float).SetInt(suggest), big.NewFloat(gas.Multiplier)).Int(nil)
+		auth.GasPrice = capPrice(price, gas.MaxGasPrice)
+		maxPrice = auth.GasPrice
+	case conf.GasEIP1559:
+		head, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if head.BaseFee == nil {
+			// pre-London chain, fall back to a capped legacy price
+			suggest, err := client.SuggestGasPrice(ctx)
+			if err != nil {
+				return err
+			}
+			auth.GasPrice = capPrice(suggest, gas.MaxGasPrice)
+			maxPrice = auth.GasPrice
+			break
+		}
+		tip, err := client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return err
+		}
+		auth.GasTipCap = capPrice(tip, gas.MaxPriorityFee)
+		feeCap := new(big.Int).Add(auth.GasTipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+		auth.GasFeeCap = capPrice(feeCap, gas.MaxGasPrice)
+		if auth.GasFeeCap.Cmp(auth.GasTipCap) < 0 {
+			auth.GasTipCap = auth.GasFeeCap
+		}
+		maxPrice = auth.GasFeeCap
+	case conf.GasLegacy, "":
+		suggest, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return err
+		}
+		auth.GasPrice = capPrice(suggest, gas.MaxGasPrice)
+		maxPrice = auth.GasPrice
+	default:
+		return fmt.Errorf("unknown gas strategy %q", gas.Strategy)
+	}
+
+	if gas.SpendingCap > 0 {
+		cost := new(big.Int).Mul(maxPrice, new(big.Int).SetUint64(auth.GasLimit))
+		if cost.Cmp(gwei(gas.SpendingCap)) > 0 {
+			return fmt.Errorf("transaction cost %s wei exceeds spending cap %d gwei", cost, gas.SpendingCap)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	polyCommon "github.com/polynetwork/poly/common"
+
+	"land-bridge/contracts/eccm"
+)
+
+// keepersTTL is how long the keeper set read from a destination chain is
+// reused before it is read again.
+const keepersTTL = time.Minute
+
+// eccdKeepersABI is the part of the EthCrossChainData ABI holding the keepers
+// the ECCM checks the relayed signatures against.
+const eccdKeepersABI = `[{"inputs":[],"name":"getCurEpochConPubKeyBytes","outputs":[{"internalType":"bytes","name":"","type":"bytes"}],"stateMutability":"view","type":"function"}]`
+
+type keeperSet struct {
+	keepers []common.Address
+	read    time.Time
+}
+
+// destinationKeepers returns the keepers of the ECCM of a destination chain.
+func (b *Bridge) destinationKeepers(chainID uint64) ([]common.Address, error) {
+	b.keepersMu.Lock()
+	cached, ok := b.keepers[chainID]
+	b.keepersMu.Unlock()
+	if ok && time.Since(cached.read) < keepersTTL {
+		return cached.keepers, nil
+	}
+
+	keepers, err := b.readKeepers(chainID)
+	if err != nil {
+		return nil, err
+	}
+	b.keepersMu.Lock()
+	b.keepers[chainID] = &keeperSet{keepers: keepers, read: time.Now()}
+	b.keepersMu.Unlock()
+	return keepers, nil
+}
+
+// readKeepers reads the current keepers from the EthCrossChainData contract
+// behind the ECCM of a destination chain.
+func (b *Bridge) readKeepers(chainID uint64) ([]common.Address, error) {
+	chainConf, ok := b.current().chainMap[chainID]
+	if !ok {
+		return nil, fmt.Errorf("chain %d is not configured", chainID)
+	}
+	client, err := b.dialDestination(chainConf)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ccm, err := eccm.NewEthCrossChainManagerCaller(common.HexToAddress(chainConf.CCMContract), client)
+	if err != nil {
+		return nil, err
+	}
+	eccd, err := ccm.EthCrossChainDataAddress(nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cross chain data address: %v", errRPCDown, err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(eccdKeepersABI))
+	if err != nil {
+		return nil, err
+	}
+	data, err := parsed.Pack("getCurEpochConPubKeyBytes")
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.CallContract(b.ctx, ethereum.CallMsg{To: &eccd, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: keepers: %v", errRPCDown, err)
+	}
+	values, err := parsed.Unpack("getCurEpochConPubKeyBytes", out)
+	if err != nil {
+		return nil, err
+	}
+	keepers, err := decodeKeepers(values[0].([]byte))
+	if err != nil {
+		return nil, fmt.Errorf("keepers of chain %d: %v", chainID, err)
+	}
+	return keepers, nil
+}
+
+// decodeKeepers parses the keeper set the way the ECCM serializes it: their
+// count as a uint64 followed by each address as var bytes.
+func decodeKeepers(raw []byte) ([]common.Address, error) {
+	source := polyCommon.NewZeroCopySource(raw)
+	n, eof := source.NextUint64()
+	if eof {
+		return nil, errors.New("truncated keeper count")
+	}
+	if n > uint64(len(raw)) {
+		return nil, fmt.Errorf("%d keepers in %d bytes", n, len(raw))
+	}
+	keepers := make([]common.Address, 0, n)
+	for i := uint64(0); i < n; i++ {
+		addr, eof := source.NextVarBytes()
+		if eof || len(addr) != common.AddressLength {
+			return nil, fmt.Errorf("malformed keeper %d", i)
+		}
+		keepers = append(keepers, common.BytesToAddress(addr))
+	}
+	if len(keepers) == 0 {
+		return nil, errors.New("no keepers")
+	}
+	return keepers, nil
+}
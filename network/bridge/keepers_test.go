@@ -0,0 +1,48 @@
+package bridge
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	polyCommon "github.com/polynetwork/poly/common"
+)
+
+func encodeKeepers(n uint64, keepers ...[]byte) []byte {
+	sink := polyCommon.NewZeroCopySink(nil)
+	sink.WriteUint64(n)
+	for _, keeper := range keepers {
+		sink.WriteVarBytes(keeper)
+	}
+	return sink.Bytes()
+}
+
+func TestDecodeKeepers(t *testing.T) {
+	a, b := common.HexToAddress("0x0a"), common.HexToAddress("0x0b")
+	keepers, err := decodeKeepers(encodeKeepers(2, a.Bytes(), b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []common.Address{a, b}; !reflect.DeepEqual(keepers, want) {
+		t.Fatalf("keepers %v, want %v", keepers, want)
+	}
+
+	full := encodeKeepers(2, a.Bytes(), b.Bytes())
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{"empty", nil},
+		{"truncated count", full[:4]},
+		{"truncated keeper", full[:len(full)-1]},
+		{"missing keeper", encodeKeepers(2, a.Bytes())},
+		{"count beyond the data", encodeKeepers(1<<40, a.Bytes())},
+		{"short keeper", encodeKeepers(1, a.Bytes()[:19])},
+		{"no keepers", encodeKeepers(0)},
+	}
+	for _, tt := range tests {
+		if keepers, err := decodeKeepers(tt.raw); err == nil {
+			t.Errorf("%s: decoded %v", tt.name, keepers)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package bridge
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 
@@ -54,3 +56,14 @@ func (bq *Queryer) GetTokenURIByAssetWithID(chainID uint64, asset string, tokenI
 	_, tokenURI, err := query.GetAndCheckTokenUrl(nil, common.HexToAddress(asset), common.HexToAddress(bq.lockProxies[chainID]), tokenID)
 	return tokenURI, err
 }
+
+// tokenURI queries the token URI through a client already connected to the
+// source chain, within ctx.
+func (bq *Queryer) tokenURI(ctx context.Context, caller bind.ContractCaller, chainID uint64, asset common.Address, tokenID *big.Int) (string, error) {
+	query, err := nftquery.NewPolyNFTQueryCaller(common.HexToAddress(bq.nftQueries[chainID]), caller)
+	if err != nil {
+		return "", err
+	}
+	_, tokenURI, err := query.GetAndCheckTokenUrl(&bind.CallOpts{Context: ctx}, asset, common.HexToAddress(bq.lockProxies[chainID]), tokenID)
+	return tokenURI, err
+}
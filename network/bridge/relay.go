@@ -0,0 +1,123 @@
+package bridge
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+
+	"land-bridge/constant"
+	"land-bridge/models"
+)
+
+const defaultBackupTimeout = 300 // seconds
+
+// BackupRelay keeps the committed signatures of a transfer relayed by another
+// validator. If no destination execution is observed before the deadline of
+// the given rank, this node submits the transfer itself.
+func (b *Bridge) BackupRelay(txhash common.Hash, height uint64, rank uint64, signatures map[common.Address][]byte) error {
+	if !b.track() {
+		return errBridgeClosed
+	}
+	defer b.inflight.Done()
+	return b.createRelayTask(txhash, height, rank, signatures)
+}
+
+func (b *Bridge) createRelayTask(txhash common.Hash, height uint64, rank uint64, signatures map[common.Address][]byte) error {
+	signers, signature := encodeSignatures(signatures)
+	task := &models.RelayTask{
+		TxHash:    txhash.Hex()[2:],
+		Height:    height,
+		Rank:      rank,
+		Signers:   strings.Join(signers, ","),
+		Signature: common.Bytes2Hex(signature),
+		Deadline:  uint64(time.Now().Unix()) + rank*b.current().backupTimeout,
+		State:     constant.RELAY_STATE_WAITING,
+	}
+	if err := b.db.CreateRelayTask(task); err != nil {
+		logs.Error("BackupRelay create relay task err", err)
+		return err
+	}
+	return nil
+}
+
+func (b *Bridge) backupRelays() {
+	tasks, err := b.db.GetDueRelayTasks(uint64(time.Now().Unix()))
+	if err != nil {
+		logs.Error("backupRelays find err", err)
+		return
+	}
+	for _, task := range tasks {
+		select {
+		case <-b.quit:
+			return
+		default:
+		}
+		if err := b.backupRelay(task); err != nil {
+			logs.Error("backup relay %s err: %v", task.TxHash, err)
+		}
+	}
+}
+
+func (b *Bridge) backupRelay(task *models.RelayTask) error {
+	wrapperTransaction, err := b.db.GetWrapper(task.TxHash)
+	if err != nil {
+		return err
+	}
+	// The destination listener finishes the wrapper once any relayer's
+	// execution is observed, and dst_hash is set once we relayed ourselves.
+	if wrapperTransaction.Status == constant.STATE_FINISHED || wrapperTransaction.DstHash != "" {
+		return b.db.FinishRelayTask(task)
+	}
+	count, err := b.db.CountPendingErrorTransactions(task.TxHash)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		// already in the retry pipeline of this node
+		return b.db.FinishRelayTask(task)
+	}
+
+	logs.Warn("no destination execution of %s observed, relaying as backup rank %d", task.TxHash, task.Rank)
+	signatures := decodeSignatures(task.Signers, common.Hex2Bytes(task.Signature))
+	if err := b.db.FinishRelayTask(task); err != nil {
+		return err
+	}
+	return b.bridgeToChainB(common.HexToHash(task.TxHash), signatures)
+}
+
+// encodeSignatures concatenates signatures ordered by signer address.
+func encodeSignatures(signatures map[common.Address][]byte) ([]string, []byte) {
+	addrs := make([]common.Address, 0, len(signatures))
+	for addr := range signatures {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+	var signature []byte
+	signers := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		signers = append(signers, addr.Hex())
+		signature = append(signature, signatures[addr]...)
+	}
+	return signers, signature
+}
+
+func decodeSignatures(signers string, signature []byte) map[common.Address][]byte {
+	signatures := make(map[common.Address][]byte)
+	if signers == "" {
+		return signatures
+	}
+	addrs := strings.Split(signers, ",")
+	if len(signature) != len(addrs)*signatureSize {
+		return signatures
+	}
+	for i, addr := range addrs {
+		signatures[common.HexToAddress(addr)] = signature[i*signatureSize : (i+1)*signatureSize]
+	}
+	return signatures
+}
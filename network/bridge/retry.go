@@ -0,0 +1,368 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"land-bridge/conf"
+	"land-bridge/constant"
+	"land-bridge/models"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultBaseDelay   = 30   // seconds
+	defaultMaxDelay    = 3600 // seconds
+
+	// maxRevertAttempts bounds the retries of submissions reverted by the
+	// destination, which seldom succeed without operator intervention.
+	maxRevertAttempts = 2
+
+	retryInterval = 10 * time.Second
+	signatureSize = 65
+
+	// resignTimeout is how long the validators are given to sign a transfer
+	// again.
+	resignTimeout = 30 * time.Second
+
+	// cancelGrace is how long Shutdown waits for cancelled submissions to
+	// record their state.
+	cancelGrace = 5 * time.Second
+
+	// dialAttempts is the number of times the nodes of a destination chain are
+	// tried before a submission fails, dialBackoff the first pause between them.
+	dialAttempts = 3
+	dialBackoff  = time.Second
+)
+
+// errRPCDown is returned when no node of the destination chain answers.
+var errRPCDown = errors.New("destination chain unreachable")
+
+func newRetryConfig(cfg *conf.RetryConfig) *conf.RetryConfig {
+	retry := &conf.RetryConfig{
+		MaxAttempts: defaultMaxAttempts,
+		BaseDelay:   defaultBaseDelay,
+		MaxDelay:    defaultMaxDelay,
+	}
+	if cfg == nil {
+		return retry
+	}
+	if cfg.MaxAttempts > 0 {
+		retry.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.BaseDelay > 0 {
+		retry.BaseDelay = cfg.BaseDelay
+	}
+	if cfg.MaxDelay > 0 {
+		retry.MaxDelay = cfg.MaxDelay
+	}
+	return retry
+}
+
+// revertErrorCode is the JSON-RPC error code of a reverted execution.
+const revertErrorCode = 3
+
+// Node error texts telling the class of a failed submission, matched as whole
+// words when neither the type nor the code of the error tells it.
+var (
+	underpricedPattern = regexp.MustCompile(`\b(underpriced|fee cap|tip cap|less than block base fee)\b`)
+	noncePattern       = regexp.MustCompile(`\bnonce too (low|high)\b`)
+	revertPattern      = regexp.MustCompile(`\b(execution reverted|reverted|gas required exceeds allowance)\b`)
+	rpcDownPattern     = regexp.MustCompile(`\b(connection refused|connection reset|no such host|i/o timeout)\b`)
+)
+
+// classifyError maps a submission error onto the ErrorTransaction error taxonomy.
+func classifyError(err error) uint8 {
+	var (
+		netErr  net.Error
+		httpErr rpc.HTTPError
+		rpcErr  rpc.Error
+	)
+	switch {
+	case errors.Is(err, errRPCDown), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &netErr):
+		return constant.ERROR_RPC_DOWN
+	case errors.As(err, &httpErr):
+		switch httpErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return constant.ERROR_RPC_DOWN
+		}
+		return constant.ERROR_UNKNOWN
+	case errors.As(err, &rpcErr) && rpcErr.ErrorCode() == revertErrorCode:
+		return constant.ERROR_REVERT
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case underpricedPattern.MatchString(msg):
+		return constant.ERROR_UNDERPRICED
+	case noncePattern.MatchString(msg):
+		return constant.ERROR_NONCE
+	case revertPattern.MatchString(msg):
+		return constant.ERROR_REVERT
+	case rpcDownPattern.MatchString(msg):
+		return constant.ERROR_RPC_DOWN
+	default:
+		return constant.ERROR_UNKNOWN
+	}
+}
+
+// retryDelay returns the backoff in seconds before the next attempt.
+// Nonce conflicts and unreachable RPC endpoints are retried at a flat
+// interval, everything else backs off exponentially up to MaxDelay.
+func (b *Bridge) retryDelay(errorType uint8, attempts uint64) uint64 {
+	retry := b.current().retry
+	switch errorType {
+	case constant.ERROR_NONCE, constant.ERROR_RPC_DOWN:
+		return retry.BaseDelay
+	}
+	if attempts >= 32 {
+		return retry.MaxDelay
+	}
+	delay := retry.BaseDelay << attempts
+	if delay > retry.MaxDelay || delay < retry.BaseDelay {
+		return retry.MaxDelay
+	}
+	return delay
+}
+
+func (b *Bridge) maxAttempts(errorType uint8) uint64 {
+	retry := b.current().retry
+	if errorType == constant.ERROR_REVERT && retry.MaxAttempts > maxRevertAttempts {
+		return maxRevertAttempts
+	}
+	return retry.MaxAttempts
+}
+
+// SignatureRequester asks the validators to sign again the transfer txHash of
+// the committed LinQ block, and returns the signatures received before ctx is
+// done.
+type SignatureRequester func(ctx context.Context, block common.Hash, txHash common.Hash) [][]byte
+
+// SetSignatureRequester sets the function collecting new signatures of a
+// committed transfer whose stored ones no longer reach the quorum.
+func (b *Bridge) SetSignatureRequester(fn SignatureRequester) {
+	b.requestSignatures = fn
+}
+
+// keeperSetHash identifies the keeper set of a destination chain the stored
+// signatures were checked against, empty if it is unknown.
+func (b *Bridge) keeperSetHash(chainID uint64) string {
+	keepers, err := b.destinationKeepers(chainID)
+	if err != nil || len(keepers) == 0 {
+		return ""
+	}
+	sorted := make([]common.Address, len(keepers))
+	copy(sorted, keepers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Bytes(), sorted[j].Bytes()) < 0
+	})
+	var buf []byte
+	for _, addr := range sorted {
+		buf = append(buf, addr.Bytes()...)
+	}
+	return crypto.Keccak256Hash(buf).Hex()[2:]
+}
+
+// Start launches the worker retrying ErrorTransactions and backup relays.
+func (b *Bridge) Start() {
+	b.wg.Add(1)
+	go b.retryLoop()
+}
+
+// Shutdown refuses new submissions, stops the retry worker and waits for the
+// submissions in flight to finish. Once ctx expires they are cancelled: an
+// interrupted relay is stored as an ErrorTransaction due right away, so the
+// next run picks it up.
+func (b *Bridge) Shutdown(ctx context.Context) error {
+	b.closeMu.Lock()
+	if b.closing {
+		b.closeMu.Unlock()
+		return nil
+	}
+	b.closing = true
+	b.closeMu.Unlock()
+	close(b.quit)
+
+	done := make(chan struct{})
+	go func() {
+		b.inflight.Wait()
+		b.wg.Wait()
+		close(done)
+	}()
+	defer b.cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	logs.Warn("bridge shutdown deadline reached, cancelling in-flight submissions")
+	b.cancel()
+	select {
+	case <-done:
+	case <-time.After(cancelGrace):
+		logs.Error("bridge submissions did not stop within %v", cancelGrace)
+	}
+	return ctx.Err()
+}
+
+func (b *Bridge) retryLoop() {
+	defer b.wg.Done()
+	t := time.NewTimer(retryInterval)
+	for {
+		select {
+		case <-t.C:
+			b.backupRelays()
+			b.retryErrorTransactions()
+			t.Reset(retryInterval)
+		case <-b.quit:
+			t.Stop()
+			return
+		}
+	}
+}
+
+func (b *Bridge) retryErrorTransactions() {
+	errorTransactions, err := b.db.GetDueErrorTransactions(uint64(time.Now().Unix()))
+	if err != nil {
+		logs.Error("retryErrorTransactions find err", err)
+		return
+	}
+	for _, et := range errorTransactions {
+		select {
+		case <-b.quit:
+			return
+		default:
+		}
+		if err := b.retryErrorTransaction(et); err != nil {
+			logs.Error("retry error transaction %d err: %v", et.ID, err)
+		}
+	}
+}
+
+func (b *Bridge) retryErrorTransaction(et *models.ErrorTransaction) error {
+	wrapperTransaction, err := b.db.GetWrapper(et.TxHash)
+	if err != nil {
+		return err
+	}
+	if wrapperTransaction.Status == constant.STATE_FINISHED {
+		et.State = constant.ERROR_STATE_RESOLVED
+		return b.db.UpdateErrorTransaction(et)
+	}
+	srcTransfer, err := b.db.GetSrcTransfer(et.TxHash)
+	if err != nil {
+		return err
+	}
+
+	tx := ConstructTx(wrapperTransaction, srcTransfer, et.ToContract, et.TokenURI)
+	stored := b.storedSignatures(et, tx.ChainID())
+	_, signatures, err := b.collectSignatures(tx, stored)
+	if err != nil {
+		logs.Warn("error transaction %d needs new signatures: %v", et.ID, err)
+		if signatures, err = b.resign(et, wrapperTransaction, tx, stored); err != nil {
+			return b.retryLater(et, constant.ERROR_NO_QUORUM, err)
+		}
+	}
+	tx.SetSignatures(signatures)
+
+	execTxHash, err := b.submit(tx)
+	if err != nil && b.ctx.Err() != nil {
+		// interrupted by the shutdown, the entry stays due for the next run
+		return err
+	}
+	if err != nil {
+		return b.retryLater(et, classifyError(err), err)
+	}
+
+	b.relayed(wrapperTransaction, execTxHash)
+	et.State = constant.ERROR_STATE_RESOLVED
+	et.Attempts++
+	return b.db.UpdateErrorTransaction(et)
+}
+
+// retryLater counts a failed attempt and schedules the next one, or abandons
+// the entry once it ran out of attempts.
+func (b *Bridge) retryLater(et *models.ErrorTransaction, errorType uint8, err error) error {
+	et.ErrorType = errorType
+	et.Attempts++
+	logs.Error("retry error transaction %d attempt %d err: %v", et.ID, et.Attempts, err)
+	if et.Attempts >= b.maxAttempts(et.ErrorType) {
+		return b.abandon(et, err.Error())
+	}
+	et.NextRetry = uint64(time.Now().Unix()) + b.retryDelay(et.ErrorType, et.Attempts)
+	et.ErrorMsg = err.Error()
+	return b.db.UpdateErrorTransaction(et)
+}
+
+// storedSignatures returns the signatures stored with the entry, none if they
+// were checked against another keeper set than the current one of the
+// destination chain.
+func (b *Bridge) storedSignatures(et *models.ErrorTransaction, chainID uint64) map[common.Address][]byte {
+	if current := b.keeperSetHash(chainID); et.ValidatorSet != "" && current != "" && et.ValidatorSet != current {
+		logs.Info("keepers of chain %d changed since error transaction %d was signed", chainID, et.ID)
+		return make(map[common.Address][]byte)
+	}
+	return decodeSignatures(et.Signers, common.Hex2Bytes(et.Signature))
+}
+
+// resign asks the validators to sign the transfer of its committed LinQ block
+// again, and stores the new signatures with the entry once they reach the
+// quorum. The transfer is never proposed again: a second block would record
+// and relay it twice.
+func (b *Bridge) resign(et *models.ErrorTransaction, wrapperTransaction *models.WrapperTransaction, tx *TxParam, signatures map[common.Address][]byte) ([]byte, error) {
+	if b.requestSignatures == nil {
+		return nil, errors.New("no signature requester")
+	}
+	if wrapperTransaction.LinQHash == "" {
+		return nil, fmt.Errorf("wrapper %s is in no LinQ block", wrapperTransaction.Hash)
+	}
+	if own, err := b.Sign(tx); err == nil {
+		signatures[*b.signer.addr] = own
+	}
+	ctx, cancel := context.WithTimeout(b.ctx, resignTimeout)
+	defer cancel()
+	hash := tx.Hash()
+	for _, sig := range b.requestSignatures(ctx, common.HexToHash(wrapperTransaction.LinQHash), tx.TxHash) {
+		if len(sig) != signatureSize {
+			continue
+		}
+		if pub, err := crypto.SigToPub(hash, sig); err == nil {
+			signatures[crypto.PubkeyToAddress(*pub)] = sig
+		}
+	}
+
+	signers, signature, err := b.collectSignatures(tx, signatures)
+	if err != nil {
+		return nil, err
+	}
+	et.Signers = strings.Join(signers, ",")
+	et.Signature = common.Bytes2Hex(signature)
+	et.ValidatorSet = b.keeperSetHash(tx.ChainID())
+	return signature, nil
+}
+
+func (b *Bridge) abandon(et *models.ErrorTransaction, reason string) error {
+	logs.Warn("abandon error transaction %d of wrapper %s: %s", et.ID, et.TxHash, reason)
+	if err := b.db.UpdateErrorTransaction(et); err != nil {
+		return fmt.Errorf("abandon error transaction %d: %v", et.ID, err)
+	}
+	if err := b.db.AbandonErrorTransaction(et.ID, reason); err != nil {
+		return fmt.Errorf("abandon error transaction %d: %v", et.ID, err)
+	}
+	return nil
+}
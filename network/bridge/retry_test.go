@@ -0,0 +1,215 @@
+package bridge
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"land-bridge/conf"
+	"land-bridge/constant"
+	"land-bridge/handle/dao"
+	"land-bridge/models"
+)
+
+func newTestBridge(t *testing.T, db dao.Repository) *Bridge {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewBridge(db, &conf.Config{}, key)
+	t.Cleanup(b.cancel)
+	return b
+}
+
+// setKeepers fills the keeper cache of a destination chain, so that it is not
+// read from the chain.
+func (b *Bridge) setKeepers(chainID uint64, keepers []common.Address) {
+	b.keepersMu.Lock()
+	defer b.keepersMu.Unlock()
+	b.keepers[chainID] = &keeperSet{keepers: keepers, read: time.Now()}
+}
+
+type codeError struct {
+	code int
+	msg  string
+}
+
+func (e codeError) Error() string  { return e.msg }
+func (e codeError) ErrorCode() int { return e.code }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want uint8
+	}{
+		{fmt.Errorf("%w: chain 2: dial failed", errRPCDown), constant.ERROR_RPC_DOWN},
+		{context.DeadlineExceeded, constant.ERROR_RPC_DOWN},
+		{fmt.Errorf("receipt: %w", io.ErrUnexpectedEOF), constant.ERROR_RPC_DOWN},
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, constant.ERROR_RPC_DOWN},
+		{rpc.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"}, constant.ERROR_RPC_DOWN},
+		{rpc.HTTPError{StatusCode: 401, Status: "401 Unauthorized"}, constant.ERROR_UNKNOWN},
+		{fmt.Errorf("dry run: %w", codeError{revertErrorCode, "execution reverted: used nonce"}), constant.ERROR_REVERT},
+		{codeError{-32000, "nonce too low"}, constant.ERROR_NONCE},
+		{errors.New("replacement transaction underpriced"), constant.ERROR_UNDERPRICED},
+		{errors.New("max fee per gas less than block base fee"), constant.ERROR_UNDERPRICED},
+		{errors.New("gas required exceeds allowance (8000000)"), constant.ERROR_REVERT},
+		{errors.New("dial tcp 10.0.0.1:8545: connect: connection refused"), constant.ERROR_RPC_DOWN},
+		// hashes and reasons merely containing the words of another class
+		{errors.New("transfer 0xdeadbeef5030eof504 rejected"), constant.ERROR_UNKNOWN},
+		{errors.New("wrapper 502eofcafe has no nonces left"), constant.ERROR_UNKNOWN},
+	}
+	for i, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("test %d: %q classified %d, want %d", i, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	b := newTestBridge(t, dao.NewMemoryDao())
+	tests := []struct {
+		errorType uint8
+		attempts  uint64
+		want      uint64
+	}{
+		{constant.ERROR_NONCE, 4, defaultBaseDelay},
+		{constant.ERROR_RPC_DOWN, 4, defaultBaseDelay},
+		{constant.ERROR_UNKNOWN, 0, defaultBaseDelay},
+		{constant.ERROR_UNKNOWN, 3, defaultBaseDelay << 3},
+		{constant.ERROR_UNDERPRICED, 7, defaultMaxDelay},
+		{constant.ERROR_UNKNOWN, 64, defaultMaxDelay},
+	}
+	for i, tt := range tests {
+		if got := b.retryDelay(tt.errorType, tt.attempts); got != tt.want {
+			t.Errorf("test %d: delay %d, want %d", i, got, tt.want)
+		}
+	}
+	if got := b.maxAttempts(constant.ERROR_REVERT); got != maxRevertAttempts {
+		t.Errorf("%d attempts for a revert, want %d", got, maxRevertAttempts)
+	}
+}
+
+func TestRetryLater(t *testing.T) {
+	db := dao.NewMemoryDao()
+	b := newTestBridge(t, db)
+	et := &models.ErrorTransaction{TxHash: "aa", State: constant.ERROR_STATE_PENDING}
+	if err := db.CreateErrorTransaction(et); err != nil {
+		t.Fatal(err)
+	}
+
+	before := uint64(time.Now().Unix())
+	if err := b.retryLater(et, constant.ERROR_NONCE, errors.New("nonce too low")); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := db.GetErrorTransactions(constant.ERROR_STATE_PENDING)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].NextRetry < before+defaultBaseDelay {
+		t.Fatalf("entry after a failed attempt: %+v", pending)
+	}
+
+	// a reverted submission is abandoned after maxRevertAttempts
+	if err := b.retryLater(et, constant.ERROR_REVERT, errors.New("execution reverted")); err != nil {
+		t.Fatal(err)
+	}
+	abandoned, err := db.GetErrorTransactions(constant.ERROR_STATE_ABANDONED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abandoned) != 1 {
+		t.Fatalf("%d abandoned entries, want 1", len(abandoned))
+	}
+}
+
+func TestRetryFinishedWrapper(t *testing.T) {
+	db := dao.NewMemoryDao()
+	b := newTestBridge(t, db)
+	wrapper := &models.WrapperTransaction{Hash: "aa", Status: constant.STATE_FINISHED}
+	if err := db.UpdateEvents([]*models.WrapperTransaction{wrapper}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	et := &models.ErrorTransaction{TxHash: "aa", State: constant.ERROR_STATE_PENDING}
+	if err := db.CreateErrorTransaction(et); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.retryErrorTransaction(et); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := db.GetErrorTransactions(constant.ERROR_STATE_RESOLVED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved) != 1 {
+		t.Fatalf("%d resolved entries, want 1", len(resolved))
+	}
+}
+
+func TestStoredSignatures(t *testing.T) {
+	b := newTestBridge(t, dao.NewMemoryDao())
+	tx := testTxParam()
+	keys, keepers := testKeepers(t, 4)
+	b.setKeepers(tx.ChainID(), keepers)
+
+	signers, signature := encodeSignatures(signTx(t, tx, keys[:3]...))
+	et := &models.ErrorTransaction{
+		Signers:      strings.Join(signers, ","),
+		Signature:    common.Bytes2Hex(signature),
+		ValidatorSet: b.keeperSetHash(tx.ChainID()),
+	}
+	if got := b.storedSignatures(et, tx.ChainID()); len(got) != 3 {
+		t.Fatalf("%d signatures of the current keepers, want 3", len(got))
+	}
+
+	// the keepers changed since the signatures were stored
+	b.setKeepers(tx.ChainID(), keepers[1:])
+	if got := b.storedSignatures(et, tx.ChainID()); len(got) != 0 {
+		t.Fatalf("%d signatures of former keepers, want none", len(got))
+	}
+}
+
+func testTxParam() *TxParam {
+	return &TxParam{
+		TxHash:       common.HexToHash("0x01"),
+		FromChainID:  1,
+		FromContract: common.HexToAddress("0x02"),
+		ToChainID:    2,
+		ToContract:   common.HexToAddress("0x03"),
+		Args:         []byte{4},
+	}
+}
+
+func testKeepers(t *testing.T, n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]common.Address, n)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i], addrs[i] = key, crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, addrs
+}
+
+func signTx(t *testing.T, tx *TxParam, keys ...*ecdsa.PrivateKey) map[common.Address][]byte {
+	signatures := make(map[common.Address][]byte, len(keys))
+	for _, key := range keys {
+		sig, err := crypto.Sign(tx.Hash(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signatures[crypto.PubkeyToAddress(key.PublicKey)] = sig
+	}
+	return signatures
+}
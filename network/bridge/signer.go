@@ -2,7 +2,9 @@ package bridge
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 
+	"github.com/beego/beego/v2/core/logs"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
@@ -23,3 +25,60 @@ func (val *Signer) Sign(tx *TxParam) ([]byte, error) {
 	hash := tx.Hash()
 	return crypto.Sign(hash, val.priv)
 }
+
+// collectSignatures recovers the signer of every signature over tx and keeps
+// one signature per keeper of the destination ECCM, ordered by signer address
+// so every relayer submits the same calldata. It fails when fewer than a
+// quorum of keepers signed, which the ECCM would reject.
+func (b *Bridge) collectSignatures(tx *TxParam, signatures map[common.Address][]byte) ([]string, []byte, error) {
+	keepers, err := b.destinationKeepers(tx.ChainID())
+	if err != nil {
+		return nil, nil, err
+	}
+	members := make(map[common.Address]bool, len(keepers))
+	for _, addr := range keepers {
+		members[addr] = true
+	}
+
+	kept := filterSignatures(tx, signatures, members)
+	if quorum := quorumSize(len(keepers)); len(kept) < quorum {
+		return nil, nil, fmt.Errorf("%d valid signatures on %s, quorum is %d of %d keepers", len(kept), tx.TxHash.Hex(), quorum, len(keepers))
+	}
+	signers, signature := encodeSignatures(kept)
+	return signers, signature, nil
+}
+
+// filterSignatures keeps the signatures over tx made by their claimed signer,
+// and by a member of members unless it is nil.
+func filterSignatures(tx *TxParam, signatures map[common.Address][]byte, members map[common.Address]bool) map[common.Address][]byte {
+	hash := tx.Hash()
+	kept := make(map[common.Address][]byte)
+	for claimed, sig := range signatures {
+		if len(sig) != signatureSize {
+			logs.Warn("drop signature of %s on %s: length %d", claimed.Hex(), tx.TxHash.Hex(), len(sig))
+			continue
+		}
+		pub, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			logs.Warn("drop signature of %s on %s: %v", claimed.Hex(), tx.TxHash.Hex(), err)
+			continue
+		}
+		signer := crypto.PubkeyToAddress(*pub)
+		if signer != claimed {
+			logs.Warn("drop signature of %s on %s: signed by %s", claimed.Hex(), tx.TxHash.Hex(), signer.Hex())
+			continue
+		}
+		if members != nil && !members[signer] {
+			logs.Warn("drop signature of %s on %s: not a keeper", signer.Hex(), tx.TxHash.Hex())
+			continue
+		}
+		kept[signer] = sig
+	}
+	return kept
+}
+
+// quorumSize is the number of validator signatures the ECCM requires out of n
+// keepers: all but the f = (n-1)/3 that may be faulty.
+func quorumSize(n int) int {
+	return n - (n-1)/3
+}
@@ -0,0 +1,128 @@
+package bridge
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"land-bridge/handle/dao"
+)
+
+func TestQuorumSize(t *testing.T) {
+	tests := []struct {
+		n, want int
+	}{
+		{1, 1}, {2, 2}, {3, 3}, {4, 3}, {5, 4}, {6, 5}, {7, 5}, {10, 7}, {100, 67},
+	}
+	for _, tt := range tests {
+		if got := quorumSize(tt.n); got != tt.want {
+			t.Errorf("quorum of %d keepers is %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFilterSignatures(t *testing.T) {
+	tx := testTxParam()
+	keys, keepers := testKeepers(t, 3)
+	members := map[common.Address]bool{keepers[0]: true, keepers[1]: true}
+	valid := signTx(t, tx, keys...)
+
+	otherTx := testTxParam()
+	otherTx.Args = []byte{5}
+	other := signTx(t, otherTx, keys[0])
+
+	tests := []struct {
+		name       string
+		signatures map[common.Address][]byte
+		members    map[common.Address]bool
+		want       []common.Address
+	}{
+		{"all members", valid, members, keepers[:2]},
+		{"any signer", valid, nil, keepers},
+		{"wrong signer", map[common.Address][]byte{keepers[1]: valid[keepers[0]]}, members, nil},
+		{"other transfer", map[common.Address][]byte{keepers[0]: other[keepers[0]]}, members, nil},
+		{"non-keeper", map[common.Address][]byte{keepers[2]: valid[keepers[2]]}, members, nil},
+		{"short signature", map[common.Address][]byte{keepers[0]: valid[keepers[0]][:signatureSize-1]}, members, nil},
+		{"bad recovery id", map[common.Address][]byte{keepers[0]: append(append([]byte{}, valid[keepers[0]][:signatureSize-1]...), 9)}, members, nil},
+	}
+	for _, tt := range tests {
+		kept := filterSignatures(tx, tt.signatures, tt.members)
+		if len(kept) != len(tt.want) {
+			t.Errorf("%s: kept %d signatures, want %d", tt.name, len(kept), len(tt.want))
+			continue
+		}
+		for _, addr := range tt.want {
+			if !bytes.Equal(kept[addr], valid[addr]) {
+				t.Errorf("%s: signature of %s not kept", tt.name, addr.Hex())
+			}
+		}
+	}
+}
+
+func TestCollectSignatures(t *testing.T) {
+	b := newTestBridge(t, dao.NewMemoryDao())
+	tx := testTxParam()
+	keys, keepers := testKeepers(t, 4)
+	b.setKeepers(tx.ChainID(), keepers)
+
+	outsider, _ := testKeepers(t, 1)
+	below := signTx(t, tx, keys[0], keys[1], outsider[0])
+	if _, _, err := b.collectSignatures(tx, below); err == nil {
+		t.Fatal("signatures collected below quorum")
+	}
+
+	at := signTx(t, tx, keys[3], keys[1], keys[0], outsider[0])
+	signers, signature, err := b.collectSignatures(tx, at)
+	if err != nil {
+		t.Fatalf("signatures at quorum: %v", err)
+	}
+	if len(signers) != 3 || len(signature) != 3*signatureSize {
+		t.Fatalf("%d signers, %d signature bytes, want 3 and %d", len(signers), len(signature), 3*signatureSize)
+	}
+	// ordered by signer address, whatever the keeper order
+	for i := 1; i < len(signers); i++ {
+		if bytes.Compare(common.HexToAddress(signers[i-1]).Bytes(), common.HexToAddress(signers[i]).Bytes()) >= 0 {
+			t.Fatalf("signers out of order: %v", signers)
+		}
+	}
+	for i, signer := range signers {
+		pub, err := crypto.SigToPub(tx.Hash(), signature[i*signatureSize:(i+1)*signatureSize])
+		if err != nil || crypto.PubkeyToAddress(*pub) != common.HexToAddress(signer) {
+			t.Fatalf("signature %d is not by %s", i, signer)
+		}
+	}
+}
+
+func TestEncodeSignatures(t *testing.T) {
+	tx := testTxParam()
+	keys, _ := testKeepers(t, 3)
+	signatures := signTx(t, tx, keys...)
+
+	signers, signature := encodeSignatures(signatures)
+	if got := decodeSignatures(strings.Join(signers, ","), signature); !reflect.DeepEqual(got, signatures) {
+		t.Fatalf("decoded %v, want %v", got, signatures)
+	}
+	again, _ := encodeSignatures(decodeSignatures(strings.Join(signers, ","), signature))
+	if !reflect.DeepEqual(again, signers) {
+		t.Fatalf("signers %v encoded again as %v", signers, again)
+	}
+
+	tests := []struct {
+		name      string
+		signers   string
+		signature []byte
+	}{
+		{"no signers", "", signature},
+		{"truncated signature", strings.Join(signers, ","), signature[:len(signature)-1]},
+		{"missing signer", strings.Join(signers[:2], ","), signature},
+	}
+	for _, tt := range tests {
+		if got := decodeSignatures(tt.signers, tt.signature); len(got) != 0 {
+			t.Errorf("%s: decoded %d signatures", tt.name, len(got))
+		}
+	}
+}
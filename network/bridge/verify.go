@@ -0,0 +1,274 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"land-bridge/conf"
+	"land-bridge/contracts/eccm"
+	"land-bridge/contracts/nftlp"
+	"land-bridge/models"
+)
+
+const (
+	// verifyTimeout bounds the source chain queries made to verify a single
+	// committed transfer before signing it again.
+	verifyTimeout = 15 * time.Second
+
+	// verifiedCacheSize is the number of verified transfers remembered, so a
+	// proposal re-sent in a later round is not queried again.
+	verifiedCacheSize = 1024
+)
+
+// sourceClient is the part of a source chain client used to verify transfers.
+type sourceClient interface {
+	bind.ContractCaller
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	Close()
+}
+
+// dialSource connects to a source chain to verify transfers.
+var dialSource = func(ctx context.Context, chainConf *conf.ChainListenConfig) (sourceClient, error) {
+	return dialChain(ctx, chainConf)
+}
+
+var (
+	errUnverifiedTransfer = errors.New("transfer not verified against the source chain")
+	errTransferMismatch   = errors.New("transfer does not match the source chain")
+	errTransferCommitted  = errors.New("transfer already committed in a LinQ block")
+)
+
+// VerifyTxParams re-derives every TxParam from this node's own view of the
+// source chain and fails unless all of them match. A transfer committed in an
+// earlier block is rejected, so that it is never relayed twice. Only verified
+// transfers can be signed afterwards.
+//
+// ctx bounds the source chain queries. The transfers verified before it
+// expires are remembered even if others are not, so a proposal sent again in
+// a later round only waits for the rest.
+func (b *Bridge) VerifyTxParams(ctx context.Context, params []TxParam) error {
+	for i := range params {
+		if b.db.HasTxHash(common.Bytes2Hex(params[i].TxHash[:]), params[i].FromChainID) {
+			return fmt.Errorf("transfer %s: %w", params[i].TxHash.Hex(), errTransferCommitted)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-b.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// one client per source chain, shared by the transfers coming from it
+	s := b.current()
+	clients := make(map[uint64]sourceClient)
+	dialErrs := make(map[uint64]error)
+	defer func() {
+		for _, client := range clients {
+			client.Close()
+		}
+	}()
+
+	errs := make([]error, len(params))
+	var wg sync.WaitGroup
+	for i := range params {
+		if b.verified.Contains(paramKey(&params[i])) {
+			continue
+		}
+		chainID := params[i].FromChainID
+		chainConf, ok := s.chainMap[chainID]
+		if !ok {
+			errs[i] = fmt.Errorf("source chain %d is not configured", chainID)
+			continue
+		}
+		if _, ok := clients[chainID]; !ok && dialErrs[chainID] == nil {
+			clients[chainID], dialErrs[chainID] = dialSource(ctx, chainConf)
+			if dialErrs[chainID] != nil {
+				delete(clients, chainID)
+			}
+		}
+		if errs[i] = dialErrs[chainID]; errs[i] != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, client sourceClient) {
+			defer wg.Done()
+			errs[i] = b.verifyTxParam(ctx, s, client, &params[i])
+		}(i, clients[chainID])
+	}
+	wg.Wait()
+
+	var firstErr error
+	for i, err := range errs {
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("transfer %s: %w", params[i].TxHash.Hex(), err)
+			}
+			continue
+		}
+		b.verified.Add(paramKey(&params[i]), true)
+	}
+	return firstErr
+}
+
+// verifyCommitted verifies a single transfer which left the verified cache,
+// typically one committed before a restart and signed again for the retry
+// worker of another validator.
+func (b *Bridge) verifyCommitted(param *TxParam) error {
+	ctx, cancel := context.WithTimeout(b.ctx, verifyTimeout)
+	defer cancel()
+
+	s := b.current()
+	chainConf, ok := s.chainMap[param.FromChainID]
+	if !ok {
+		return fmt.Errorf("source chain %d is not configured", param.FromChainID)
+	}
+	client, err := dialSource(ctx, chainConf)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := b.verifyTxParam(ctx, s, client, param); err != nil {
+		return err
+	}
+	b.verified.Add(paramKey(param), true)
+	return nil
+}
+
+// verifyTxParam checks that the source transaction of param succeeded with
+// enough confirmations, emitted the ECCM cross chain event and the NFT proxy
+// lock event, and that the TxParam built from them and the current token URI
+// is the one proposed.
+func (b *Bridge) verifyTxParam(ctx context.Context, s *settings, client sourceClient, param *TxParam) error {
+	chainConf := s.chainMap[param.FromChainID]
+	receipt, err := client.TransactionReceipt(ctx, param.TxHash)
+	if err != nil {
+		return fmt.Errorf("source receipt: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("%w: source transaction failed", errTransferMismatch)
+	}
+	latest, err := client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("source height: %w", err)
+	}
+	height := receipt.BlockNumber.Uint64()
+	if latest < height+chainConf.Defer {
+		return fmt.Errorf("source transaction has %d of %d confirmations", latest-height, chainConf.Defer)
+	}
+	header, err := client.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("source header: %w", err)
+	}
+	if header.Hash() != receipt.BlockHash {
+		return fmt.Errorf("source block %d was reorganized", height)
+	}
+
+	lock, err := findLockEvents(receipt, chainConf)
+	if err != nil {
+		return err
+	}
+
+	wrapper := &models.WrapperTransaction{
+		Hash:       strings.ToLower(param.TxHash.Hex()[2:]),
+		SrcChainID: param.FromChainID,
+		DstChainID: lock.ToChainId,
+	}
+	transfer := &models.SrcTransfer{
+		Asset:    strings.ToLower(lock.FromAssetHash.Hex()[2:]),
+		TokenID:  models.NewBigInt(lock.TokenId),
+		DstAsset: hex.EncodeToString(lock.ToAssetHash),
+		DstUser:  hex.EncodeToString(lock.ToAddress),
+	}
+	tokenURI, err := s.bq.tokenURI(ctx, client, param.FromChainID, lock.FromAssetHash, lock.TokenId)
+	if err != nil {
+		return fmt.Errorf("source token URI: %w", err)
+	}
+
+	expected := ConstructTx(wrapper, transfer, s.proxyAddrs[wrapper.DstChainID], tokenURI)
+	if !bytes.Equal(expected.Serialize(), param.Serialize()) {
+		return fmt.Errorf("%w: proposed %x, source chain gives %x", errTransferMismatch, param.Hash(), expected.Hash())
+	}
+	return nil
+}
+
+// findLockEvents returns the NFT proxy lock event of a source receipt after
+// checking the ECCM announced the transfer to the same destination chain.
+func findLockEvents(receipt *types.Receipt, chainConf *conf.ChainListenConfig) (*nftlp.PolyNFTLockProxyLockEvent, error) {
+	ccmAddr := common.HexToAddress(chainConf.CCMContract)
+	proxyAddr := common.HexToAddress(chainConf.NFTProxyContract)
+	ccm, err := eccm.NewEthCrossChainManagerFilterer(ccmAddr, nil)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := nftlp.NewPolyNFTLockProxyFilterer(proxyAddr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		crossChain *eccm.EthCrossChainManagerCrossChainEvent
+		lock       *nftlp.PolyNFTLockProxyLockEvent
+	)
+	for _, log := range receipt.Logs {
+		switch {
+		case log.Address == ccmAddr && crossChain == nil:
+			if evt, err := ccm.ParseCrossChainEvent(*log); err == nil {
+				crossChain = evt
+			}
+		case log.Address == proxyAddr && lock == nil:
+			if evt, err := proxy.ParseLockEvent(*log); err == nil {
+				lock = evt
+			}
+		}
+	}
+	if crossChain == nil {
+		return nil, fmt.Errorf("%w: no cross chain event", errTransferMismatch)
+	}
+	if lock == nil {
+		return nil, fmt.Errorf("%w: no lock event", errTransferMismatch)
+	}
+	if crossChain.ProxyOrAssetContract != proxyAddr || crossChain.ToChainId != lock.ToChainId {
+		return nil, fmt.Errorf("%w: cross chain event disagrees with the lock event", errTransferMismatch)
+	}
+	return lock, nil
+}
+
+// dialChain connects to the first reachable node of a chain.
+func dialChain(ctx context.Context, chainConf *conf.ChainListenConfig) (*ethclient.Client, error) {
+	var lastErr error
+	for _, url := range chainConf.GetNodesURL() {
+		client, err := ethclient.DialContext(ctx, url)
+		if err == nil {
+			return client, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("chain %d has no nodes", chainConf.ChainID)
+	}
+	return nil, lastErr
+}
+
+func paramKey(param *TxParam) common.Hash {
+	return common.BytesToHash(param.Hash())
+}
@@ -0,0 +1,317 @@
+package bridge
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"land-bridge/conf"
+	"land-bridge/contracts/eccm"
+	"land-bridge/contracts/nftlp"
+	"land-bridge/contracts/nftquery"
+	"land-bridge/handle/dao"
+	"land-bridge/models"
+)
+
+var (
+	testCCM      = common.HexToAddress("0xcc")
+	testProxy    = common.HexToAddress("0x11")
+	testDstProxy = common.HexToAddress("0x22")
+	testAsset    = common.HexToAddress("0xa5")
+)
+
+// fakeSource serves a source chain from memory.
+type fakeSource struct {
+	receipts map[common.Hash]*types.Receipt
+	headers  map[uint64]*types.Header
+	latest   uint64
+	tokenURI string
+	hang     bool // block every query until its context is done
+}
+
+func (f *fakeSource) wait(ctx context.Context) error {
+	if f.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (f *fakeSource) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	if receipt, ok := f.receipts[txHash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeSource) BlockNumber(ctx context.Context) (uint64, error) {
+	return f.latest, f.wait(ctx)
+}
+
+func (f *fakeSource) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	if header, ok := f.headers[number.Uint64()]; ok {
+		return header, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeSource) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (f *fakeSource) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	parsed, err := abi.JSON(strings.NewReader(nftquery.PolyNFTQueryABI))
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Methods["getAndCheckTokenUrl"].Outputs.Pack(true, f.tokenURI)
+}
+
+func (f *fakeSource) Close() {}
+
+// useSource makes the bridge verify against source for the rest of the test.
+func useSource(t *testing.T, source sourceClient) {
+	dial := dialSource
+	dialSource = func(context.Context, *conf.ChainListenConfig) (sourceClient, error) {
+		if source == nil {
+			return nil, errors.New("source chain unreachable")
+		}
+		return source, nil
+	}
+	t.Cleanup(func() { dialSource = dial })
+}
+
+func newVerifyBridge(t *testing.T, db dao.Repository) *Bridge {
+	b := newTestBridge(t, db)
+	b.Reload(&conf.Config{Chains: []*conf.ChainListenConfig{
+		{
+			ChainID:          1,
+			Defer:            3,
+			Nodes:            []*conf.Restful{{URL: "http://source"}},
+			CCMContract:      testCCM.Hex(),
+			NFTProxyContract: testProxy.Hex(),
+			NFTQueryContract: "0x33",
+		},
+		{ChainID: 2, Nodes: []*conf.Restful{{URL: "http://destination"}}, NFTProxyContract: testDstProxy.Hex()},
+	}})
+	return b
+}
+
+func eventLog(t *testing.T, contractABI string, name string, address common.Address, args ...interface{}) *types.Log {
+	parsed, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := parsed.Events[name]
+	topics := []common.Hash{event.ID}
+	var data []interface{}
+	for i, input := range event.Inputs {
+		if input.Indexed {
+			topics = append(topics, common.BytesToHash(args[i].(common.Address).Bytes()))
+		} else {
+			data = append(data, args[i])
+		}
+	}
+	packed, err := event.Inputs.NonIndexed().Pack(data...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Log{Address: address, Topics: topics, Data: packed}
+}
+
+// lockedTransfer puts a transfer of token 7 towards chain 2 in a source block
+// at height 10, and returns the parameters a proposer derives from it.
+func lockedTransfer(t *testing.T, source *fakeSource, txHash common.Hash) TxParam {
+	toAsset, toAddress := common.HexToAddress("0xd5").Bytes(), common.HexToAddress("0xd0").Bytes()
+	tokenID := big.NewInt(7)
+	logs := []*types.Log{
+		eventLog(t, eccm.EthCrossChainManagerABI, "CrossChainEvent", testCCM,
+			common.HexToAddress("0x5e"), []byte{1}, testProxy, uint64(2), testDstProxy.Bytes(), []byte{}),
+		eventLog(t, nftlp.PolyNFTLockProxyABI, "LockEvent", testProxy,
+			testAsset, common.HexToAddress("0xf0"), toAsset, toAddress, uint64(2), tokenID),
+	}
+	header := &types.Header{Number: big.NewInt(10)}
+	source.headers[10] = header
+	source.receipts[txHash] = &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		BlockNumber: header.Number,
+		BlockHash:   header.Hash(),
+		Logs:        logs,
+	}
+
+	wrapper := &models.WrapperTransaction{Hash: txHash.Hex()[2:], SrcChainID: 1, DstChainID: 2}
+	transfer := &models.SrcTransfer{
+		Asset:    testAsset.Hex()[2:],
+		TokenID:  models.NewBigInt(tokenID),
+		DstAsset: hex.EncodeToString(toAsset),
+		DstUser:  hex.EncodeToString(toAddress),
+	}
+	return *ConstructTx(wrapper, transfer, testDstProxy.Hex(), "ipfs://token/7")
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		receipts: make(map[common.Hash]*types.Receipt),
+		headers:  make(map[uint64]*types.Header),
+		latest:   20,
+		tokenURI: "ipfs://token/7",
+	}
+}
+
+func TestVerifyTxParams(t *testing.T) {
+	b := newVerifyBridge(t, dao.NewMemoryDao())
+	source := newFakeSource()
+	useSource(t, source)
+
+	param := lockedTransfer(t, source, common.HexToHash("0x01"))
+	if err := b.VerifyTxParams(context.Background(), []TxParam{param}); err != nil {
+		t.Fatalf("transfer matching the source chain: %v", err)
+	}
+	// a verified transfer is not queried again
+	useSource(t, nil)
+	if err := b.VerifyTxParams(context.Background(), []TxParam{param}); err != nil {
+		t.Fatalf("verified transfer: %v", err)
+	}
+}
+
+func TestVerifyTxParamsMismatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, source *fakeSource, param *TxParam)
+		err    error
+		msg    string
+	}{
+		{
+			name: "token URI changed",
+			modify: func(t *testing.T, source *fakeSource, param *TxParam) {
+				source.tokenURI = "ipfs://token/8"
+			},
+			err: errTransferMismatch,
+		},
+		{
+			name: "other destination contract",
+			modify: func(t *testing.T, source *fakeSource, param *TxParam) {
+				param.ToContract = common.HexToAddress("0x23")
+			},
+			err: errTransferMismatch,
+		},
+		{
+			name: "no lock event",
+			modify: func(t *testing.T, source *fakeSource, param *TxParam) {
+				receipt := source.receipts[param.TxHash]
+				receipt.Logs = receipt.Logs[:1]
+			},
+			err: errTransferMismatch,
+		},
+		{
+			name: "failed source transaction",
+			modify: func(t *testing.T, source *fakeSource, param *TxParam) {
+				source.receipts[param.TxHash].Status = types.ReceiptStatusFailed
+			},
+			err: errTransferMismatch,
+		},
+		{
+			name: "too few confirmations",
+			modify: func(t *testing.T, source *fakeSource, param *TxParam) {
+				source.latest = 12
+			},
+			msg: "2 of 3 confirmations",
+		},
+		{
+			name: "reorganized source block",
+			modify: func(t *testing.T, source *fakeSource, param *TxParam) {
+				source.headers[10] = &types.Header{Number: big.NewInt(10), Extra: []byte{1}}
+			},
+			msg: "reorganized",
+		},
+	}
+	for _, tt := range tests {
+		b := newVerifyBridge(t, dao.NewMemoryDao())
+		source := newFakeSource()
+		useSource(t, source)
+		param := lockedTransfer(t, source, common.HexToHash("0x01"))
+		tt.modify(t, source, &param)
+
+		err := b.VerifyTxParams(context.Background(), []TxParam{param})
+		switch {
+		case err == nil:
+			t.Errorf("%s: transfer verified", tt.name)
+		case tt.err != nil && !errors.Is(err, tt.err):
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+		case tt.msg != "" && !strings.Contains(err.Error(), tt.msg):
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.msg)
+		}
+		if b.verified.Contains(paramKey(&param)) {
+			t.Errorf("%s: rejected transfer remembered as verified", tt.name)
+		}
+	}
+}
+
+func TestVerifyTxParamsCommitted(t *testing.T) {
+	db := dao.NewMemoryDao()
+	b := newVerifyBridge(t, db)
+	source := newFakeSource()
+	useSource(t, source)
+
+	committed := lockedTransfer(t, source, common.HexToHash("0x01"))
+	if err := db.RecordBlockTransfers(5, "05", []*models.TxHashHistory{{TxHash: committed.TxHash.Hex()[2:], ChainID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	pending := lockedTransfer(t, source, common.HexToHash("0x02"))
+
+	err := b.VerifyTxParams(context.Background(), []TxParam{pending, committed})
+	if !errors.Is(err, errTransferCommitted) {
+		t.Fatalf("error %v, want %v", err, errTransferCommitted)
+	}
+	if err := b.VerifyTxParams(context.Background(), []TxParam{pending}); err != nil {
+		t.Fatalf("transfer not committed yet: %v", err)
+	}
+}
+
+func TestVerifyTxParamsDeadline(t *testing.T) {
+	b := newVerifyBridge(t, dao.NewMemoryDao())
+	source := newFakeSource()
+	useSource(t, source)
+
+	fast := lockedTransfer(t, source, common.HexToHash("0x01"))
+	slow := lockedTransfer(t, source, common.HexToHash("0x02"))
+	if err := b.VerifyTxParams(context.Background(), []TxParam{fast}); err != nil {
+		t.Fatal(err)
+	}
+
+	source.hang = true
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := b.VerifyTxParams(ctx, []TxParam{fast, slow}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("verification returned after %v", elapsed)
+	}
+
+	// the transfers verified in time are kept for the next round
+	source.hang = false
+	useSource(t, nil)
+	if err := b.VerifyTxParams(context.Background(), []TxParam{fast}); err != nil {
+		t.Fatalf("transfer verified in an earlier round: %v", err)
+	}
+}
@@ -18,6 +18,8 @@
 package consensus
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
 
@@ -87,6 +89,16 @@ type Engine interface {
 	Close() error
 }
 
+var (
+	// ErrInvalidMessage is returned by Handler.HandleMsg for a message which
+	// cannot be decoded.
+	ErrInvalidMessage = errors.New("invalid consensus message")
+
+	// ErrDuplicateMessage is returned by Handler.HandleMsg for a message the
+	// peer already sent. The message is ignored but the peer is kept.
+	ErrDuplicateMessage = errors.New("duplicate consensus message")
+)
+
 // Handler should be implemented is the consensus needs to handle and send peer's message
 type Handler interface {
 	// NewBlock handles a new head block comes
@@ -108,4 +120,8 @@ type LBFT interface {
 
 	// Stop stops the engine
 	Stop() error
+
+	// SetValidatorListener sets the function called with the validator set
+	// of the head block once the engine starts and after every new head.
+	SetValidatorListener(fn func(validators []common.Address))
 }
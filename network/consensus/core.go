@@ -1,6 +1,10 @@
 package consensus
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
 
 type Core interface {
 	Start() error
@@ -14,4 +18,22 @@ type Core interface {
 	// pending request is populated right at the preprepare stage so this would give us the earliest verification
 	// to avoid any race condition of coming propagated blocks
 	IsCurrentProposal(blockHash common.Hash) bool
+
+	// RoundState returns a summary of the current round.
+	RoundState() *RoundStateInfo
+}
+
+// RoundStateInfo summarizes the round a consensus core is in.
+type RoundStateInfo struct {
+	Sequence              *big.Int       `json:"sequence"`
+	Round                 *big.Int       `json:"round"`
+	State                 string         `json:"state"`
+	Proposer              common.Address `json:"proposer"`
+	IsProposer            bool           `json:"isProposer"`
+	Proposal              common.Hash    `json:"proposal"`   // hash of the accepted proposal, zero if none
+	LockedHash            common.Hash    `json:"lockedHash"` // hash of the locked proposal, zero if unlocked
+	Prepares              int            `json:"prepares"`
+	Commits               int            `json:"commits"`
+	RoundChanges          int            `json:"roundChanges"` // ROUND CHANGE messages for the next round
+	WaitingForRoundChange bool           `json:"waitingForRoundChange"`
 }
@@ -49,9 +49,9 @@ type Backend interface {
 	// Gossip sends a message to all validators (exclude self)
 	Gossip(valSet ValidatorSet, payload []byte) error
 
-	// Commit delivers an approved proposal to backend.
+	// Commit delivers an approved proposal, committed in round, to backend.
 	// The delivered proposal will be put into blockchain.
-	Commit(proposal consensus.Proposal, seals [][]byte) error
+	Commit(proposal consensus.Proposal, seals [][]byte, round *big.Int) error
 
 	// Verify verifies the proposal. If a consensus.ErrFutureBlock error is returned,
 	// the time difference of the proposal and current time is also returned.
@@ -76,5 +76,12 @@ type Backend interface {
 	// ParentValidators returns the validator set of the given proposal's parent block
 	ParentValidators(proposal consensus.Proposal) ValidatorSet
 
+	// StoreEvidence persists equivocation evidence, reporting false if it was already known
+	StoreEvidence(hash common.Hash, blob []byte) (bool, error)
+
+	// RoundChanged reports that the round of a sequence proposed by proposer
+	// is abandoned, and why
+	RoundChanged(sequence, round *big.Int, proposer common.Address, cause RoundChangeCause)
+
 	Close() error
 }
@@ -0,0 +1,266 @@
+package backend
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"land-bridge/network/consensus"
+	"land-bridge/network/consensus/lbft"
+	"land-bridge/network/consensus/lbft/core"
+	"land-bridge/network/utils"
+)
+
+// API is the operator facing API of the LBFT engine: it lists the recorded
+// equivocation evidence and the validator participation, and casts the
+// validator votes carried by the blocks this node proposes.
+type API struct {
+	backend *backend
+}
+
+// APIs returns the RPC APIs of the engine.
+func (sb *backend) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "lbft",
+		Version:   "1.0",
+		Service:   &API{backend: sb},
+	}}
+}
+
+// EvidenceInfo is the JSON form of equivocation evidence.
+type EvidenceInfo struct {
+	Hash      common.Hash    `json:"hash"`
+	Validator common.Address `json:"validator"`
+	Message   string         `json:"message"`
+	Sequence  *big.Int       `json:"sequence"`
+	Round     *big.Int       `json:"round"`
+	First     hexutil.Bytes  `json:"first"`
+	Second    hexutil.Bytes  `json:"second"`
+}
+
+var messageNames = map[uint64]string{
+	core.MsgPreprepare: "preprepare",
+	core.MsgPrepare:    "prepare",
+	core.MsgCommit:     "commit",
+}
+
+// GetEvidence lists the equivocation evidence recorded by this node.
+func (api *API) GetEvidence() ([]*EvidenceInfo, error) {
+	var list []*EvidenceInfo
+	err := api.backend.db.IterateEvidence(func(hash common.Hash, blob []byte) error {
+		evidence, err := core.DecodeEvidence(blob)
+		if err != nil {
+			return fmt.Errorf("evidence %s: %v", hash.Hex(), err)
+		}
+		list = append(list, &EvidenceInfo{
+			Hash:      hash,
+			Validator: evidence.Validator,
+			Message:   messageNames[evidence.Code],
+			Sequence:  evidence.Sequence,
+			Round:     evidence.Round,
+			First:     evidence.First,
+			Second:    evidence.Second,
+		})
+		return nil
+	})
+	return list, err
+}
+
+// ProposeRemoval votes to drop the validator convicted by the evidence with
+// the given hash. The vote is cast in the blocks this node proposes until the
+// validator is removed or the vote is discarded.
+func (api *API) ProposeRemoval(hash common.Hash) error {
+	var offender *common.Address
+	err := api.backend.db.IterateEvidence(func(h common.Hash, blob []byte) error {
+		if h != hash {
+			return nil
+		}
+		evidence, err := core.DecodeEvidence(blob)
+		if err != nil {
+			return err
+		}
+		offender = &evidence.Validator
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if offender == nil {
+		return fmt.Errorf("unknown evidence %s", hash.Hex())
+	}
+	api.Propose(*offender, false)
+	return nil
+}
+
+// Propose injects a new authorization candidate that this node will vote for.
+func (api *API) Propose(address common.Address, auth bool) {
+	api.backend.candidatesLock.Lock()
+	defer api.backend.candidatesLock.Unlock()
+
+	api.backend.candidates[address] = auth
+}
+
+// Discard drops a currently running candidate, stopping this node from
+// voting further for or against it.
+func (api *API) Discard(address common.Address) {
+	api.backend.candidatesLock.Lock()
+	defer api.backend.candidatesLock.Unlock()
+
+	delete(api.backend.candidates, address)
+}
+
+// ProposeWeight votes to set the proposer weight of a validator, from 1 to
+// 255. Weights are used by the weighted and random proposer policies.
+func (api *API) ProposeWeight(address common.Address, weight uint64) error {
+	if weight == 0 || weight > maxProposerWeight {
+		return fmt.Errorf("weight must be between 1 and %d", maxProposerWeight)
+	}
+	api.backend.candidatesLock.Lock()
+	defer api.backend.candidatesLock.Unlock()
+
+	api.backend.weightCandidates[address] = weight
+	return nil
+}
+
+// DiscardWeight stops this node from voting on the proposer weight of a
+// validator.
+func (api *API) DiscardWeight(address common.Address) {
+	api.backend.candidatesLock.Lock()
+	defer api.backend.candidatesLock.Unlock()
+
+	delete(api.backend.weightCandidates, address)
+}
+
+// WeightCandidates returns the proposer weights this node votes for.
+func (api *API) WeightCandidates() map[common.Address]uint64 {
+	api.backend.candidatesLock.RLock()
+	defer api.backend.candidatesLock.RUnlock()
+
+	weights := make(map[common.Address]uint64, len(api.backend.weightCandidates))
+	for address, weight := range api.backend.weightCandidates {
+		weights[address] = weight
+	}
+	return weights
+}
+
+// Weights returns the proposer weight of every validator at the head.
+func (api *API) Weights() map[common.Address]uint64 {
+	block := api.backend.currentBlock()
+	valSet := api.backend.getValidators(block.NumberU64(), block.Hash())
+	weights := make(map[common.Address]uint64, valSet.Size())
+	for _, val := range valSet.List() {
+		weights[val.Address()] = valSet.Weight(val.Address())
+	}
+	return weights
+}
+
+// Participation returns the uptime, proposals and missed proposals of every
+// validator over the blocks of the last window seconds, with the causes of
+// the round changes this node went through. Only the last 4096 blocks and
+// round changes are kept: a window reaching beyond them is an error.
+func (api *API) Participation(window uint64) (*ParticipationInfo, error) {
+	return api.backend.participation.since(now().Add(-time.Duration(window) * time.Second))
+}
+
+// RoundChangeInfo is a round this node abandoned.
+type RoundChangeInfo struct {
+	Time     time.Time             `json:"time"`
+	Sequence uint64                `json:"sequence"`
+	Round    uint64                `json:"round"`
+	Proposer common.Address        `json:"proposer"`
+	Cause    lbft.RoundChangeCause `json:"cause"`
+}
+
+// RoundChanges lists the rounds this node abandoned in the last window
+// seconds, among the last 4096 of them.
+func (api *API) RoundChanges(window uint64) []*RoundChangeInfo {
+	p := api.backend.participation
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	since := now().Add(-time.Duration(window) * time.Second)
+	list := make([]*RoundChangeInfo, 0)
+	for _, rc := range p.roundChanges {
+		if rc.Time.Before(since) {
+			continue
+		}
+		list = append(list, &RoundChangeInfo{
+			Time:     rc.Time,
+			Sequence: rc.Sequence,
+			Round:    rc.Round,
+			Proposer: rc.Proposer,
+			Cause:    rc.Cause,
+		})
+	}
+	return list
+}
+
+// Candidates returns the current candidates this node votes for.
+func (api *API) Candidates() map[common.Address]bool {
+	api.backend.candidatesLock.RLock()
+	defer api.backend.candidatesLock.RUnlock()
+
+	proposals := make(map[common.Address]bool, len(api.backend.candidates))
+	for address, auth := range api.backend.candidates {
+		proposals[address] = auth
+	}
+	return proposals
+}
+
+// block returns the block with the given number, the head if nil or latest.
+func (api *API) block(number *rpc.BlockNumber) (*utils.Block, error) {
+	sb := api.backend
+	sb.coreMu.RLock()
+	started := sb.coreStarted
+	sb.coreMu.RUnlock()
+	if !started {
+		return nil, lbft.ErrStoppedEngine
+	}
+
+	var block *utils.Block
+	if number == nil || *number == rpc.LatestBlockNumber || *number == rpc.PendingBlockNumber {
+		block = sb.currentBlock()
+	} else if *number >= 0 {
+		block = sb.chain.GetBlockByNumber(uint64(*number))
+	}
+	if block == nil {
+		return nil, errUnknownBlock
+	}
+	return block, nil
+}
+
+// GetSnapshot returns the voting snapshot at the given block number, at the
+// head if not given.
+func (api *API) GetSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
+	block, err := api.block(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.backend.snapshot(api.backend.chain, block.NumberU64(), block.Hash(), nil)
+}
+
+// GetValidators returns the validators at the given block number, at the head
+// if not given.
+func (api *API) GetValidators(number *rpc.BlockNumber) ([]common.Address, error) {
+	snap, err := api.GetSnapshot(number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.validators(), nil
+}
+
+// RoundState returns the sequence, round and state this node is in, with the
+// messages it gathered for the round.
+func (api *API) RoundState() (*consensus.RoundStateInfo, error) {
+	sb := api.backend
+	sb.coreMu.RLock()
+	defer sb.coreMu.RUnlock()
+	if !sb.coreStarted {
+		return nil, lbft.ErrStoppedEngine
+	}
+	return sb.core.RoundState(), nil
+}
@@ -1,8 +1,8 @@
 package backend
 
 import (
+	"context"
 	"crypto/ecdsa"
-	"gorm.io/gorm"
 	"math/big"
 	"sync"
 	"time"
@@ -19,6 +19,7 @@ import (
 	"land-bridge/network/consensus/lbft/core"
 	"land-bridge/network/consensus/lbft/validator"
 	"land-bridge/network/linq/txblock"
+	"land-bridge/network/storage"
 	"land-bridge/network/utils"
 )
 
@@ -28,10 +29,11 @@ const (
 )
 
 // New creates an Ethereum backend for LBFT core engine.
-func New(config *lbft.Config, privateKey *ecdsa.PrivateKey, db *gorm.DB, bridge *bridge.Bridge, pool *txblock.BlockPool) *backend {
+func New(config *lbft.Config, privateKey *ecdsa.PrivateKey, db storage.ChainStore, bridge *bridge.Bridge, pool *txblock.BlockPool) *backend {
 	// Allocate the snapshot caches and create the engine
 	recents, _ := lru.NewARC(inmemorySnapshots)
 	recentMessages, _ := lru.NewARC(inmemoryPeers)
+	receivedMessages, _ := lru.NewARC(inmemoryPeers)
 	knownMessages, _ := lru.NewARC(inmemoryMessages)
 
 	sb := &backend{
@@ -45,8 +47,11 @@ func New(config *lbft.Config, privateKey *ecdsa.PrivateKey, db *gorm.DB, bridge
 		commitCh:          make(chan *utils.Block, 1),
 		recents:           recents,
 		candidates:        make(map[common.Address]bool),
+		weightCandidates:  make(map[common.Address]uint64),
+		participation:     newParticipation(),
 		coreStarted:       false,
 		recentMessages:    recentMessages,
+		receivedMessages:  receivedMessages,
 		knownMessages:     knownMessages,
 	}
 
@@ -68,7 +73,7 @@ type backend struct {
 
 	consensusEventMux *event.TypeMux
 
-	db *gorm.DB
+	db storage.ChainStore
 
 	commitCh          chan *utils.Block
 	proposedBlockHash common.Hash
@@ -78,15 +83,25 @@ type backend struct {
 
 	// Current list of candidates we are pushing
 	candidates map[common.Address]bool
+	// Proposer weights we are voting for
+	weightCandidates map[common.Address]uint64
+
+	participation *participation
+
+	// Called with the validator set of every new head
+	validatorListener func([]common.Address)
+	// Serializes the calls of validatorListener
+	notifyMu sync.Mutex
 	// Protects the signer fields
 	candidatesLock sync.RWMutex
 
 	// event subscription for ChainHeadEvent event
 	broadcaster consensus.Broadcaster
 
-	recents        *lru.ARCCache
-	recentMessages *lru.ARCCache // the cache of peer's messages
-	knownMessages  *lru.ARCCache // the cache of self messages
+	recents          *lru.ARCCache
+	recentMessages   *lru.ARCCache // the cache of peer's messages
+	receivedMessages *lru.ARCCache // the cache of messages received from each peer
+	knownMessages    *lru.ARCCache // the cache of self messages
 }
 
 func (sb *backend) Address() common.Address {
@@ -157,7 +172,7 @@ func (sb *backend) Gossip(valSet lbft.ValidatorSet, payload []byte) error {
 	return nil
 }
 
-func (sb *backend) Commit(proposal consensus.Proposal, seals [][]byte) error {
+func (sb *backend) Commit(proposal consensus.Proposal, seals [][]byte, round *big.Int) error {
 	// Check if the proposal is a valid block
 	block := &utils.CBlock{}
 	block, ok := proposal.(*utils.CBlock)
@@ -172,7 +187,10 @@ func (sb *backend) Commit(proposal consensus.Proposal, seals [][]byte) error {
 		return err
 	}
 
-	sb.pool.Delete(proposal.TxHash().Hex()[2:])
+	for _, txHash := range proposal.TxHashes() {
+		sb.pool.Delete(txHash.Hex()[2:])
+	}
+	sb.participation.committed(proposal.Hash(), block.Height, round.Uint64())
 
 	// - if the proposed and committed blocks are the same, send the proposed hash
 	//   to commit channel, which is being watched inside the engine.Seal() function.
@@ -203,15 +221,40 @@ func (sb *backend) Verify(proposal consensus.Proposal) (time.Duration, error) {
 		return 0, errInvalidProposal
 	}
 
+	if err := verifyTransfers(block); err != nil {
+		return 0, err
+	}
+
 	// verify the header of proposed block
 	err := sb.VerifyHeader(sb.chain, block, false)
 	// ignore errEmptyCommittedSeals error because we don't have the committed seals yet
-	if err == nil || err == errEmptyCommittedSeals {
-		return 0, nil
-	} else if err == lbft.ErrFutureBlock {
+	if err == lbft.ErrFutureBlock {
 		return time.Unix(int64(block.Time), 0).Sub(now()), lbft.ErrFutureBlock
+	} else if err != nil && err != errEmptyCommittedSeals {
+		return 0, err
+	}
+
+	// every validator checks the transfers against its own view of the source
+	// chains before it prepares or signs them. Verify runs on the core event
+	// loop, so the source chain queries are bounded by the round timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(sb.config.RequestTimeout)*time.Millisecond)
+	defer cancel()
+	if err := sb.bridge.VerifyTxParams(ctx, block.GetTxParams()); err != nil {
+		logs.Warn("Proposal %d rejected by source chain verification: %v", block.Height, err)
+		return 0, err
+	}
+	return 0, nil
+}
+
+// StoreEvidence implements lbft.Backend.StoreEvidence
+func (sb *backend) StoreEvidence(hash common.Hash, blob []byte) (bool, error) {
+	if sb.db.HasEvidence(hash) {
+		return false, nil
+	}
+	if err := sb.db.WriteEvidence(hash, blob); err != nil {
+		return false, err
 	}
-	return 0, err
+	return true, nil
 }
 
 func (sb *backend) Sign(data []byte) ([]byte, error) {
@@ -280,6 +323,41 @@ func (sb *backend) getValidators(number uint64, hash common.Hash) lbft.Validator
 	return snap.ValSet
 }
 
+// currentValidators returns the validator addresses at the current head.
+func (sb *backend) currentValidators() []common.Address {
+	block := sb.currentBlock()
+	valSet := sb.getValidators(block.NumberU64(), block.Hash())
+	validators := make([]common.Address, 0, valSet.Size())
+	for _, val := range valSet.List() {
+		validators = append(validators, val.Address())
+	}
+	return validators
+}
+
+// SetValidatorListener implements consensus.LBFT.SetValidatorListener
+func (sb *backend) SetValidatorListener(fn func(validators []common.Address)) {
+	sb.coreMu.Lock()
+	defer sb.coreMu.Unlock()
+	sb.validatorListener = fn
+}
+
+// notifyValidators reports the validator set of the head to the listener.
+// Calls are serialized and read the set once their turn comes, so the last
+// one to run always reports the set of the latest head, whatever the order
+// the goroutines calling it were scheduled in.
+func (sb *backend) notifyValidators() {
+	sb.notifyMu.Lock()
+	defer sb.notifyMu.Unlock()
+
+	sb.coreMu.RLock()
+	fn, started := sb.validatorListener, sb.coreStarted
+	sb.coreMu.RUnlock()
+	if fn == nil || !started {
+		return
+	}
+	fn(sb.currentValidators())
+}
+
 func (sb *backend) Start(chain consensus.ChainReader, currentBlock func() *utils.Block) error {
 	sb.coreMu.Lock()
 	defer sb.coreMu.Unlock()
@@ -304,6 +382,8 @@ func (sb *backend) Start(chain consensus.ChainReader, currentBlock func() *utils
 	}
 
 	sb.coreStarted = true
+	go sb.recordParticipation()
+	go sb.notifyValidators()
 
 	return nil
 }
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+
+	"land-bridge/network/consensus"
+	"land-bridge/network/consensus/lbft"
+	"land-bridge/network/consensus/lbft/validator"
+	"land-bridge/network/utils"
+)
+
+var (
+	// errInvalidCheckpoint is returned when a checkpoint block or its snapshot
+	// is malformed or inconsistent.
+	errInvalidCheckpoint = errors.New("invalid checkpoint")
+	// errUntrustedCheckpoint is returned when too few of the trusted signers
+	// committed a checkpoint block.
+	errUntrustedCheckpoint = errors.New("checkpoint not committed by the trusted signers")
+	// errWeightedCheckpoint is returned when starting from a checkpoint under
+	// a proposer policy relying on the weights, which no block commits to.
+	errWeightedCheckpoint = errors.New("checkpoint sync is not supported under the weighted proposer policies")
+)
+
+// ExportSnapshot returns the encoded voting snapshot at the given block, for a
+// peer starting its chain from that block.
+func (sb *backend) ExportSnapshot(chain consensus.ChainReader, number uint64, hash common.Hash) ([]byte, error) {
+	snap, err := sb.snapshot(chain, number, hash, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(snap)
+}
+
+// CheckpointHeight returns the height of the last epoch block at or below
+// head, the block a peer starting its chain from this one is served.
+func (sb *backend) CheckpointHeight(head uint64) uint64 {
+	return head - head%sb.config.Epoch
+}
+
+// ImportCheckpoint stores the voting snapshot of a checkpoint block, from
+// which the chain is then verified forward without the ancestors of the block.
+//
+// The block must be an epoch block, where the pending votes are reset, and be
+// committed by a quorum of the validators its header lists, those of its
+// parent. The snapshot is rebuilt by applying the block to them and must
+// match the one served. Unless the caller already trusts the block hash,
+// signers lists trusted validators, more than two thirds of which must have
+// committed the block. The proposer weights are not committed in blocks, so
+// checkpoints are refused under the weighted proposer policies.
+func (sb *backend) ImportCheckpoint(block *utils.Block, blob []byte, signers []common.Address) error {
+	if sb.config.ProposerPolicy.Weighted() {
+		return errWeightedCheckpoint
+	}
+	if block.Height == 0 {
+		return fmt.Errorf("%w: genesis block", errInvalidCheckpoint)
+	}
+	if block.Height%sb.config.Epoch != 0 {
+		return fmt.Errorf("%w: block %d is not an epoch block", errInvalidCheckpoint, block.Height)
+	}
+	proposal := utils.LBFTBlockForEncode(block, true)
+	if proposal == nil || proposal.Hash() != block.Hash() {
+		return fmt.Errorf("%w: block hash does not match its content", errInvalidCheckpoint)
+	}
+	served := new(Snapshot)
+	if err := json.Unmarshal(blob, served); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidCheckpoint, err)
+	}
+	if served.Number != block.Height || served.Hash != block.Hash() {
+		return fmt.Errorf("%w: snapshot of block %d [%x…] for block %d [%x…]", errInvalidCheckpoint,
+			served.Number, served.Hash[:4], block.Height, block.Hash().Bytes()[:4])
+	}
+	if served.ValSet.Policy() != sb.config.ProposerPolicy {
+		return fmt.Errorf("%w: proposer policy %d", errInvalidCheckpoint, served.ValSet.Policy())
+	}
+
+	cblock := block.ToCBlock()
+	extra, err := utils.LBFTBlockExtra(cblock)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidCheckpoint, err)
+	}
+	committers, err := sb.Signers(cblock)
+	if err != nil {
+		return err
+	}
+	parent := validator.NewSet(extra.Validators, sb.config.ProposerPolicy)
+	if n, ok := countSigners(committers, extra.Validators); !ok || n < sb.quorumSize(block.Height, parent) {
+		return errInvalidCommittedSeals
+	}
+	if signers != nil {
+		if n, _ := countSigners(committers, signers); 3*n <= 2*len(signers) {
+			return fmt.Errorf("%w: %d of %d", errUntrustedCheckpoint, n, len(signers))
+		}
+	}
+
+	snap, err := newSnapshot(sb.config.Epoch, block.Height-1, block.ParentHash, parent).apply([]*utils.Block{block})
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidCheckpoint, err)
+	}
+	if !sameValidators(snap.validators(), served.validators()) || len(served.ValSet.Weights()) > 0 {
+		return fmt.Errorf("%w: snapshot differs from the one rebuilt from the block", errInvalidCheckpoint)
+	}
+
+	if err := snap.store(sb.db); err != nil {
+		return err
+	}
+	sb.recents.Add(snap.Hash, snap)
+	logs.Info("Imported voting snapshot of checkpoint %d [%x…] with %d validators", snap.Number, snap.Hash[:4], snap.ValSet.Size())
+	return nil
+}
+
+// quorumSize returns the number of commits the consensus core needs to commit
+// a block of the given height with the validator set.
+func (sb *backend) quorumSize(number uint64, valSet lbft.ValidatorSet) int {
+	if sb.config.Ceil2Nby3Block == nil || new(big.Int).SetUint64(number).Cmp(sb.config.Ceil2Nby3Block) < 0 {
+		return 2*valSet.F() + 1
+	}
+	return int(math.Ceil(float64(2*valSet.Size()) / 3))
+}
+
+// countSigners returns the number of distinct committers in set, and false if
+// some committer is not in set.
+func countSigners(committers, set []common.Address) (int, bool) {
+	members := make(map[common.Address]bool, len(set))
+	for _, addr := range set {
+		members[addr] = true
+	}
+	seen := make(map[common.Address]bool, len(committers))
+	ok := true
+	for _, addr := range committers {
+		if !members[addr] {
+			ok = false
+			continue
+		}
+		seen[addr] = true
+	}
+	return len(seen), ok
+}
+
+// sameValidators reports whether the two validator lists hold the same
+// accounts.
+func sameValidators(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	members := make(map[common.Address]bool, len(a))
+	for _, addr := range a {
+		members[addr] = true
+	}
+	for _, addr := range b {
+		if !members[addr] {
+			return false
+		}
+	}
+	return true
+}
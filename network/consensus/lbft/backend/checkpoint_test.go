@@ -0,0 +1,224 @@
+package backend
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"land-bridge/network/consensus/lbft"
+	"land-bridge/network/consensus/lbft/core"
+	"land-bridge/network/consensus/lbft/validator"
+	"land-bridge/network/storage"
+	"land-bridge/network/utils"
+)
+
+const testEpoch = 10
+
+func newCheckpointBackend(t *testing.T, policy lbft.ProposerPolicy) *backend {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := *lbft.DefaultConfig
+	config.Epoch, config.ProposerPolicy = testEpoch, policy
+	return New(&config, key, storage.NewMemoryStore(), nil, nil)
+}
+
+func validatorKeys(t *testing.T, n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]common.Address, n)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i], addrs[i] = key, crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, addrs
+}
+
+// checkpointBlock builds a block of the given height listing validators,
+// proposed by the first of keys and committed by all of them.
+func checkpointBlock(t *testing.T, height uint64, validators []common.Address, keys ...*ecdsa.PrivateKey) *utils.Block {
+	cblock := &utils.CBlock{Height: height, ParentHash: common.HexToHash("0x09"), Time: 1}
+	extra, err := prepareExtra(cblock, validators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &utils.Block{Height: cblock.Height, ParentHash: cblock.ParentHash, Time: cblock.Time, ExtraData: extra}
+	seal, err := crypto.Sign(crypto.Keccak256(sigHash(block).Bytes()), keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSeal(block, seal); err != nil {
+		t.Fatal(err)
+	}
+
+	hash := block.Hash()
+	seals := make([][]byte, len(keys))
+	for i, key := range keys {
+		if seals[i], err = crypto.Sign(crypto.Keccak256(core.PrepareCommittedSeal(hash)), key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cblock = block.ToCBlock()
+	if err := writeCommittedSeals(cblock, seals); err != nil {
+		t.Fatal(err)
+	}
+	return cblock.ToBlock()
+}
+
+// servedSnapshot encodes the snapshot a peer serves with a checkpoint block.
+func servedSnapshot(t *testing.T, block *utils.Block, validators []common.Address, policy lbft.ProposerPolicy) []byte {
+	snap := newSnapshot(testEpoch, block.Height, block.Hash(), validator.NewSet(validators, policy))
+	blob, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func TestImportCheckpoint(t *testing.T) {
+	sb := newCheckpointBackend(t, lbft.RoundRobin)
+	keys, vals := validatorKeys(t, 4)
+	block := checkpointBlock(t, testEpoch, vals, keys[:3]...)
+
+	if err := sb.ImportCheckpoint(block, servedSnapshot(t, block, vals, lbft.RoundRobin), vals[:3]); err != nil {
+		t.Fatalf("checkpoint committed by a quorum: %v", err)
+	}
+	blob, err := sb.db.ReadSnapshot(block.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := new(Snapshot)
+	if err := json.Unmarshal(blob, stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Number != block.Height || !sameValidators(stored.validators(), vals) {
+		t.Fatalf("stored snapshot of block %d with validators %v", stored.Number, stored.validators())
+	}
+}
+
+func TestImportCheckpointInvalid(t *testing.T) {
+	keys, vals := validatorKeys(t, 4)
+	strangerKeys, strangers := validatorKeys(t, 1)
+
+	tests := []struct {
+		name    string
+		block   func() *utils.Block
+		served  func(block *utils.Block) []byte
+		signers []common.Address
+		err     error
+	}{
+		{
+			name:  "non-epoch block",
+			block: func() *utils.Block { return checkpointBlock(t, testEpoch+1, vals, keys...) },
+			err:   errInvalidCheckpoint,
+		},
+		{
+			name:  "genesis block",
+			block: func() *utils.Block { return checkpointBlock(t, 0, vals, keys...) },
+			err:   errInvalidCheckpoint,
+		},
+		{
+			name:  "seals below quorum",
+			block: func() *utils.Block { return checkpointBlock(t, testEpoch, vals, keys[:2]...) },
+			err:   errInvalidCommittedSeals,
+		},
+		{
+			name: "seal of a non-validator",
+			block: func() *utils.Block {
+				return checkpointBlock(t, testEpoch, vals, append(keys[:2:2], strangerKeys[0], keys[2])...)
+			},
+			err: errInvalidCommittedSeals,
+		},
+		{
+			name:    "untrusted signers",
+			block:   func() *utils.Block { return checkpointBlock(t, testEpoch, vals, keys[:3]...) },
+			signers: []common.Address{vals[2], vals[3], strangers[0]},
+			err:     errUntrustedCheckpoint,
+		},
+		{
+			name:    "two thirds of the trusted signers",
+			block:   func() *utils.Block { return checkpointBlock(t, testEpoch, vals, keys[:3]...) },
+			signers: []common.Address{vals[1], vals[2], vals[3]},
+			err:     errUntrustedCheckpoint,
+		},
+		{
+			name:  "snapshot with other validators",
+			block: func() *utils.Block { return checkpointBlock(t, testEpoch, vals, keys...) },
+			served: func(block *utils.Block) []byte {
+				return servedSnapshot(t, block, append(vals[:3:3], strangers[0]), lbft.RoundRobin)
+			},
+			err: errInvalidCheckpoint,
+		},
+		{
+			name:  "snapshot of another block",
+			block: func() *utils.Block { return checkpointBlock(t, testEpoch, vals, keys...) },
+			served: func(block *utils.Block) []byte {
+				other := checkpointBlock(t, 2*testEpoch, vals, keys...)
+				return servedSnapshot(t, other, vals, lbft.RoundRobin)
+			},
+			err: errInvalidCheckpoint,
+		},
+		{
+			name:  "snapshot with proposer weights",
+			block: func() *utils.Block { return checkpointBlock(t, testEpoch, vals, keys...) },
+			served: func(block *utils.Block) []byte {
+				snap := newSnapshot(testEpoch, block.Height, block.Hash(), validator.NewSet(vals, lbft.RoundRobin))
+				snap.ValSet.SetWeight(vals[1], 5)
+				blob, err := json.Marshal(snap)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return blob
+			},
+			err: errInvalidCheckpoint,
+		},
+		{
+			name:  "snapshot under another policy",
+			block: func() *utils.Block { return checkpointBlock(t, testEpoch, vals, keys...) },
+			served: func(block *utils.Block) []byte {
+				return servedSnapshot(t, block, vals, lbft.Sticky)
+			},
+			err: errInvalidCheckpoint,
+		},
+		{
+			name:  "malformed snapshot",
+			block: func() *utils.Block { return checkpointBlock(t, testEpoch, vals, keys...) },
+			served: func(block *utils.Block) []byte {
+				return []byte("{")
+			},
+			err: errInvalidCheckpoint,
+		},
+	}
+	for _, tt := range tests {
+		sb := newCheckpointBackend(t, lbft.RoundRobin)
+		block := tt.block()
+		served := servedSnapshot(t, block, vals, lbft.RoundRobin)
+		if tt.served != nil {
+			served = tt.served(block)
+		}
+		if err := sb.ImportCheckpoint(block, served, tt.signers); !errors.Is(err, tt.err) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+		}
+		if _, err := sb.db.ReadSnapshot(block.Hash()); err == nil {
+			t.Errorf("%s: rejected snapshot stored", tt.name)
+		}
+	}
+}
+
+func TestImportCheckpointWeighted(t *testing.T) {
+	keys, vals := validatorKeys(t, 4)
+	for _, policy := range []lbft.ProposerPolicy{lbft.WeightedRoundRobin, lbft.WeightedRandom} {
+		sb := newCheckpointBackend(t, policy)
+		block := checkpointBlock(t, testEpoch, vals, keys...)
+		if err := sb.ImportCheckpoint(block, servedSnapshot(t, block, vals, policy), nil); !errors.Is(err, errWeightedCheckpoint) {
+			t.Errorf("policy %d: error %v, want %v", policy, err, errWeightedCheckpoint)
+		}
+	}
+}
@@ -30,6 +30,12 @@ const (
 var (
 	// errInvalidProposal is returned when a prposal is malformed.
 	errInvalidProposal = errors.New("invalid proposal")
+	// errInvalidTxRoot is returned if the transfers of a proposal do not match its transaction root.
+	errInvalidTxRoot = errors.New("invalid transaction root")
+	// errTooManyTransfers is returned if a proposal carries more than utils.MaxBlockTransfers transfers.
+	errTooManyTransfers = errors.New("too many transfers")
+	// errDuplicateTransfer is returned if a proposal carries the same transfer twice.
+	errDuplicateTransfer = errors.New("duplicate transfer")
 	// errInvalidSignature is returned when given signature is not signed by given
 	// address.
 	errInvalidSignature = errors.New("invalid signature")
@@ -59,6 +65,10 @@ var (
 	nonceAuthVote = hexutil.MustDecode("0x0001") // Magic nonce number to vote on adding a new validator
 	nonceDropVote = hexutil.MustDecode("0x0000") // Magic nonce number to vote on removing a validator.
 
+	nonceWeightVote byte = 0x02 // Magic nonce prefix to vote on the proposer weight held by the second nonce byte
+
+	maxProposerWeight uint64 = 0xff // Largest proposer weight a vote can carry
+
 	inmemoryAddresses  = 20 // Number of recent addresses from ecrecover
 	recentAddresses, _ = lru.NewARC(inmemoryAddresses)
 )
@@ -267,11 +277,21 @@ func (sb *backend) Prepare(chain consensus.ChainReader, block *utils.CBlock) err
 	// get valid candidate list
 	sb.candidatesLock.RLock()
 	var addresses []common.Address
-	var authorizes []bool
+	var nonces [][]byte
 	for address, authorize := range sb.candidates {
 		if snap.checkVote(address, authorize) {
 			addresses = append(addresses, address)
-			authorizes = append(authorizes, authorize)
+			if authorize {
+				nonces = append(nonces, nonceAuthVote)
+			} else {
+				nonces = append(nonces, nonceDropVote)
+			}
+		}
+	}
+	for address, weight := range sb.weightCandidates {
+		if snap.checkWeightVote(address, weight) {
+			addresses = append(addresses, address)
+			nonces = append(nonces, []byte{nonceWeightVote, byte(weight)})
 		}
 	}
 	sb.candidatesLock.RUnlock()
@@ -281,11 +301,8 @@ func (sb *backend) Prepare(chain consensus.ChainReader, block *utils.CBlock) err
 		index := rand.Intn(len(addresses))
 		// add validator voting in coinbase
 		block.Coinbase = addresses[index]
-		if authorizes[index] {
-			copy(block.CBytes[20:], nonceAuthVote)
-		} else {
-			copy(block.CBytes[20:], nonceDropVote)
-		}
+		copy(block.CBytes[:20], addresses[index][:])
+		copy(block.CBytes[20:], nonces[index])
 	}
 
 	// add validators in snapshot to extraData's validators section
@@ -392,10 +409,45 @@ func (sb *backend) updateBlock(block *utils.CBlock) (*utils.CBlock, error) {
 	newblock := b.ToCBlock()
 
 	newblock.TxParam = block.TxParam
+	newblock.TxParams = block.TxParams
 
 	return newblock, nil
 }
 
+// verifyTransfers checks the transfers and signed parameters of a proposal
+// against its transaction root, and that no transfer is carried twice.
+func verifyTransfers(block *utils.CBlock) error {
+	if len(block.Txs) == 0 {
+		return nil
+	}
+	if len(block.Txs) > utils.MaxBlockTransfers {
+		return errTooManyTransfers
+	}
+	if len(block.TxParams) != len(block.Txs) {
+		return errInvalidTxRoot
+	}
+	type transfer struct {
+		hash    common.Hash
+		chainID uint64
+	}
+	seen := make(map[transfer]struct{}, len(block.Txs))
+	for i, tx := range block.Txs {
+		param := block.TxParams[i]
+		if param.TxHash != common.BytesToHash(tx.TxHash) || param.FromChainID != tx.ChainID {
+			return errInvalidTxRoot
+		}
+		key := transfer{param.TxHash, param.FromChainID}
+		if _, ok := seen[key]; ok {
+			return errDuplicateTransfer
+		}
+		seen[key] = struct{}{}
+	}
+	if utils.DeriveTxRoot(block.Txs, block.TxParams) != block.TxRoot {
+		return errInvalidTxRoot
+	}
+	return nil
+}
+
 // Protocol returns the protocol for this consensus
 func (sb *backend) Protocol() consensus.Protocol {
 	return consensus.LinQProtocol
@@ -460,6 +512,14 @@ func (sb *backend) snapshot(chain consensus.ChainReader, number uint64, hash com
 			// No explicit parents (or no more left), reach out to the database
 			block = chain.GetBlock(hash, number)
 			if block == nil {
+				// A chain started from a checkpoint lacks the ancestors of the
+				// checkpoint block, whose snapshot was stored on import
+				if n := len(blocks); n > 0 {
+					if s, err := loadSnapshot(sb.config.Epoch, sb.db, blocks[n-1].Hash()); err == nil {
+						snap, blocks = s, blocks[:n-1]
+						break
+					}
+				}
 				return nil, lbft.ErrUnknownAncestor
 			}
 		}
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"land-bridge/network/bridge"
+	"land-bridge/network/utils"
+)
+
+// transferBlock builds a block carrying the same source transaction hash on
+// each of the given chains.
+func transferBlock(chainIDs ...uint64) *utils.CBlock {
+	block := &utils.CBlock{}
+	hash := common.BigToHash(common.Big1)
+	for _, chainID := range chainIDs {
+		block.Txs = append(block.Txs, utils.TxInfo{ChainID: chainID, TxHash: hash.Bytes()})
+		block.TxParams = append(block.TxParams, bridge.TxParam{TxHash: hash, FromChainID: chainID, ToChainID: 9})
+	}
+	block.TxRoot = utils.DeriveTxRoot(block.Txs, block.TxParams)
+	return block
+}
+
+func TestVerifyTransfers(t *testing.T) {
+	// the same source transaction hash on two chains are two transfers
+	block := transferBlock(1, 2)
+	if err := verifyTransfers(block); err != nil {
+		t.Fatalf("same hash on two chains: %v", err)
+	}
+
+	// a transfer carried twice
+	block = transferBlock(1, 2)
+	block.Txs = append(block.Txs, block.Txs[0])
+	block.TxParams = append(block.TxParams, block.TxParams[0])
+	block.TxRoot = utils.DeriveTxRoot(block.Txs, block.TxParams)
+	if err := verifyTransfers(block); err != errDuplicateTransfer {
+		t.Errorf("duplicate transfer: %v, want %v", err, errDuplicateTransfer)
+	}
+
+	// a parameter not matching its transfer
+	block = transferBlock(1, 2)
+	block.TxParams[1].FromChainID = 3
+	block.TxRoot = utils.DeriveTxRoot(block.Txs, block.TxParams)
+	if err := verifyTransfers(block); err != errInvalidTxRoot {
+		t.Errorf("mismatched parameter: %v, want %v", err, errInvalidTxRoot)
+	}
+
+	// a root not committing to the transfers
+	block = transferBlock(1, 2)
+	block.TxParams[0].Args = []byte{1}
+	if err := verifyTransfers(block); err != errInvalidTxRoot {
+		t.Errorf("stale root: %v, want %v", err, errInvalidTxRoot)
+	}
+
+	// more transfers than a block may carry
+	chains := make([]uint64, utils.MaxBlockTransfers+1)
+	for i := range chains {
+		chains[i] = uint64(i + 1)
+	}
+	if err := verifyTransfers(transferBlock(chains[:utils.MaxBlockTransfers]...)); err != nil {
+		t.Fatalf("full block: %v", err)
+	}
+	if err := verifyTransfers(transferBlock(chains...)); err != errTooManyTransfers {
+		t.Errorf("oversized block: %v, want %v", err, errTooManyTransfers)
+	}
+}
@@ -2,7 +2,7 @@ package backend
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
 	"io/ioutil"
 	"math/big"
 	"reflect"
@@ -13,6 +13,7 @@ import (
 
 	"land-bridge/network/consensus"
 	"land-bridge/network/consensus/lbft"
+	"land-bridge/network/consensus/lbft/core"
 	"land-bridge/network/p2p"
 	"land-bridge/network/utils"
 )
@@ -24,7 +25,7 @@ const (
 
 var (
 	// errDecodeFailed is returned when decode message fails
-	errDecodeFailed = errors.New("fail to decode lbft message")
+	errDecodeFailed = fmt.Errorf("%w: fail to decode lbft message", consensus.ErrInvalidMessage)
 )
 
 func (sb *backend) NewBlock() error {
@@ -34,6 +35,8 @@ func (sb *backend) NewBlock() error {
 		return lbft.ErrStoppedEngine
 	}
 	go sb.consensusEventMux.Post(consensus.FinalCommittedEvent{})
+	go sb.recordParticipation()
+	go sb.notifyValidators()
 	return nil
 }
 
@@ -46,6 +49,15 @@ func (sb *backend) decode(msg p2p.Msg) ([]byte, common.Hash, error) {
 	return data, lbft.RLPHash(data), nil
 }
 
+// resent reports whether a message the peer already sent is signed by the peer
+// itself: a validator sends its own messages again when it replays its WAL
+// after a restart, which is not a misbehaviour.
+func resent(addr common.Address, data []byte) bool {
+	var msg core.Message
+	err := msg.FromPayload(data, lbft.GetSignatureAddress)
+	return err == nil && msg.Address == addr
+}
+
 func (sb *backend) HandleMsg(addr common.Address, msg p2p.Msg) (bool, error) {
 	sb.coreMu.Lock()
 	defer sb.coreMu.Unlock()
@@ -58,6 +70,23 @@ func (sb *backend) HandleMsg(addr common.Address, msg p2p.Msg) (bool, error) {
 		if err != nil {
 			return true, errDecodeFailed
 		}
+		// Reject the messages the peer already sent
+		rs, ok := sb.receivedMessages.Get(addr)
+		var r *lru.ARCCache
+		if ok {
+			r, _ = rs.(*lru.ARCCache)
+		} else {
+			r, _ = lru.NewARC(inmemoryMessages)
+			sb.receivedMessages.Add(addr, r)
+		}
+		if r.Contains(hash) {
+			if resent(addr, data) {
+				return true, nil
+			}
+			return true, consensus.ErrDuplicateMessage
+		}
+		r.Add(hash, true)
+
 		// Mark peer's message
 		ms, ok := sb.recentMessages.Get(addr)
 		var m *lru.ARCCache
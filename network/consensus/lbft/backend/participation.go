@@ -0,0 +1,320 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+
+	"land-bridge/network/consensus/lbft"
+	"land-bridge/network/utils"
+)
+
+const (
+	// participationBlocks is the number of recent blocks whose proposer and
+	// committers are kept.
+	participationBlocks = 4096
+
+	// participationRoundChanges is the number of recent round changes kept.
+	participationRoundChanges = 4096
+
+	// maxInferredRound bounds the search of the round a block was proposed in.
+	maxInferredRound = 64
+)
+
+// errWindowTooLong is returned for a window starting before the oldest block
+// or round change still kept.
+var errWindowTooLong = errors.New("window reaches beyond the kept participation")
+
+// blockParticipation records who took part in a block.
+type blockParticipation struct {
+	Number     uint64
+	Time       uint64
+	Proposer   common.Address
+	Round      int64            // round the block was committed in, -1 if unknown
+	Inferred   bool             // whether Round was inferred from the proposer, for a block synced from peers
+	Missed     []common.Address // proposers of the earlier rounds of the sequence
+	Committers []common.Address
+	Validators []common.Address
+}
+
+// roundChange records a round this node abandoned.
+type roundChange struct {
+	Time     time.Time
+	Sequence uint64
+	Round    uint64
+	Proposer common.Address
+	Cause    lbft.RoundChangeCause
+}
+
+// commitRound is the round this node committed a block in.
+type commitRound struct {
+	number uint64
+	round  uint64
+}
+
+// participation keeps the participation of the validators in the recent
+// blocks and the round changes this node went through.
+type participation struct {
+	mu           sync.Mutex
+	next         uint64 // next block number to record, 0 before the first one
+	first        uint64 // lowest block number held above the genesis, 0 until known
+	blocks       []*blockParticipation
+	roundChanges []*roundChange
+	commitRounds map[common.Hash]commitRound // blocks committed by this node, not recorded yet
+}
+
+func newParticipation() *participation {
+	return &participation{commitRounds: make(map[common.Hash]commitRound)}
+}
+
+// committed remembers the round this node committed a block in, until the
+// block is recorded.
+func (p *participation) committed(hash common.Hash, number uint64, round uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.commitRounds[hash] = commitRound{number: number, round: round}
+}
+
+func (p *participation) addRoundChange(rc *roundChange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.roundChanges = append(p.roundChanges, rc)
+	if n := len(p.roundChanges); n > participationRoundChanges {
+		p.roundChanges = append(p.roundChanges[:0:0], p.roundChanges[n-participationRoundChanges:]...)
+	}
+}
+
+// RoundChanged implements lbft.Backend.RoundChanged
+func (sb *backend) RoundChanged(sequence, round *big.Int, proposer common.Address, cause lbft.RoundChangeCause) {
+	sb.participation.addRoundChange(&roundChange{
+		Time:     now(),
+		Sequence: sequence.Uint64(),
+		Round:    round.Uint64(),
+		Proposer: proposer,
+		Cause:    cause,
+	})
+}
+
+// recordParticipation records the blocks inserted since the last call, up to
+// the head. The first call backfills the last participationBlocks blocks.
+// The round of a block this node committed is the one reported by the core,
+// the round of a block synced from peers is inferred.
+//
+// A chain started from a checkpoint holds no block before the checkpoint
+// block, which is not recorded either: the snapshot of its parent, the
+// participation is inferred from, is missing.
+func (sb *backend) recordParticipation() {
+	p := sb.participation
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	head := sb.currentBlock().NumberU64()
+	if p.first == 0 && head > 0 {
+		p.first = sb.firstBlock(head)
+	}
+	from := p.next
+	if from == 0 || head >= from+participationBlocks {
+		from = 1
+		if head > participationBlocks {
+			from = head - participationBlocks + 1
+		}
+	}
+	if p.first > 1 && from <= p.first {
+		from = p.first + 1
+	}
+	for number := from; number <= head; number++ {
+		block := sb.chain.GetBlockByNumber(number)
+		if block == nil {
+			break
+		}
+		round := int64(-1)
+		if c, ok := p.commitRounds[block.Hash()]; ok {
+			round = int64(c.round)
+		}
+		record, err := sb.blockParticipation(block, round)
+		if err != nil {
+			logs.Warn("record participation in block %d: %v", number, err)
+			break
+		}
+		p.blocks = append(p.blocks, record)
+		p.next = number + 1
+	}
+	if n := len(p.blocks); n > participationBlocks {
+		p.blocks = append(p.blocks[:0:0], p.blocks[n-participationBlocks:]...)
+	}
+	// forget the recorded blocks, and the ones committed but never inserted
+	for hash, c := range p.commitRounds {
+		if c.number < p.next || c.number+participationBlocks <= head {
+			delete(p.commitRounds, hash)
+		}
+	}
+}
+
+// firstBlock returns the lowest block number above the genesis the chain
+// holds up to head: 1, or the height of the checkpoint the chain was started
+// from. The blocks are held without gaps from there to the head.
+func (sb *backend) firstBlock(head uint64) uint64 {
+	lo, hi := uint64(1), head
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if sb.chain.GetBlockByNumber(mid) != nil {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// blockParticipation recovers the proposer and committers of a block. The
+// proposers of the rounds before round missed their turn. A negative round
+// is unknown, and inferred from the proposer selection of the parent
+// validator set.
+func (sb *backend) blockParticipation(block *utils.Block, round int64) (*blockParticipation, error) {
+	proposer, err := sb.Author(block)
+	if err != nil {
+		return nil, err
+	}
+	committers, err := sb.Signers(block.ToCBlock())
+	if err != nil {
+		return nil, err
+	}
+	valSet := sb.getValidators(block.NumberU64()-1, block.ParentHash).Copy()
+	record := &blockParticipation{
+		Number:     block.NumberU64(),
+		Time:       block.Time,
+		Proposer:   proposer,
+		Round:      -1,
+		Committers: committers,
+	}
+	for _, val := range valSet.List() {
+		record.Validators = append(record.Validators, val.Address())
+	}
+
+	lastProposer := sb.GetProposer(block.NumberU64() - 1)
+	var missed []common.Address
+	if round >= 0 {
+		for r := uint64(0); r < uint64(round); r++ {
+			valSet.CalcProposer(lastProposer, r)
+			if expected := valSet.GetProposer(); expected != nil {
+				missed = append(missed, expected.Address())
+			}
+		}
+		record.Round, record.Missed = round, missed
+		return record, nil
+	}
+
+	record.Inferred = true
+	for r := uint64(0); r < maxInferredRound; r++ {
+		valSet.CalcProposer(lastProposer, r)
+		expected := valSet.GetProposer()
+		if expected == nil {
+			break
+		}
+		if expected.Address() == proposer {
+			record.Round, record.Missed = int64(r), missed
+			break
+		}
+		missed = append(missed, expected.Address())
+	}
+	return record, nil
+}
+
+// ValidatorParticipation is the participation of a validator over a window.
+type ValidatorParticipation struct {
+	Address         common.Address `json:"address"`
+	Blocks          uint64         `json:"blocks"`          // blocks it was a validator for
+	Committed       uint64         `json:"committed"`       // blocks carrying its committed seal
+	Uptime          float64        `json:"uptime"`          // committed over blocks, in percent
+	Proposed        uint64         `json:"proposed"`        // blocks it proposed
+	MissedProposals uint64         `json:"missedProposals"` // rounds it should have proposed in but the sequence moved on
+	LastCommitted   uint64         `json:"lastCommitted"`   // number of the last block carrying its committed seal
+}
+
+// ParticipationInfo summarizes the validator participation over a window.
+type ParticipationInfo struct {
+	From         uint64                           `json:"from"`     // first block of the window
+	To           uint64                           `json:"to"`       // last block of the window
+	Inferred     uint64                           `json:"inferred"` // blocks synced from peers, whose missed proposals are inferred
+	Validators   []*ValidatorParticipation        `json:"validators"`
+	RoundChanges map[lbft.RoundChangeCause]uint64 `json:"roundChanges"`
+	Timeouts     map[common.Address]uint64        `json:"timeouts"` // round timeouts by proposer of the abandoned round
+}
+
+// since summarizes the blocks and round changes since a time. It fails if
+// the blocks or round changes of the window were dropped to keep only the
+// last participationBlocks and participationRoundChanges of them.
+func (p *participation) since(since time.Time) (*ParticipationInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.blocks) >= participationBlocks && int64(p.blocks[0].Time) > since.Unix() {
+		return nil, fmt.Errorf("%w: only the last %d blocks are kept, since block %d", errWindowTooLong, participationBlocks, p.blocks[0].Number)
+	}
+	if len(p.roundChanges) >= participationRoundChanges && p.roundChanges[0].Time.After(since) {
+		return nil, fmt.Errorf("%w: only the last %d round changes are kept, since %v", errWindowTooLong, participationRoundChanges, p.roundChanges[0].Time)
+	}
+
+	info := &ParticipationInfo{
+		RoundChanges: make(map[lbft.RoundChangeCause]uint64),
+		Timeouts:     make(map[common.Address]uint64),
+	}
+	stats := make(map[common.Address]*ValidatorParticipation)
+	get := func(addr common.Address) *ValidatorParticipation {
+		s, ok := stats[addr]
+		if !ok {
+			s = &ValidatorParticipation{Address: addr}
+			stats[addr] = s
+			info.Validators = append(info.Validators, s)
+		}
+		return s
+	}
+
+	for _, block := range p.blocks {
+		if int64(block.Time) < since.Unix() {
+			continue
+		}
+		if info.From == 0 {
+			info.From = block.Number
+		}
+		info.To = block.Number
+		if block.Inferred {
+			info.Inferred++
+		}
+		for _, addr := range block.Validators {
+			get(addr).Blocks++
+		}
+		for _, addr := range block.Committers {
+			s := get(addr)
+			s.Committed++
+			s.LastCommitted = block.Number
+		}
+		get(block.Proposer).Proposed++
+		for _, addr := range block.Missed {
+			get(addr).MissedProposals++
+		}
+	}
+	for _, s := range info.Validators {
+		if s.Blocks > 0 {
+			s.Uptime = float64(s.Committed) * 100 / float64(s.Blocks)
+		}
+	}
+
+	for _, rc := range p.roundChanges {
+		if rc.Time.Before(since) {
+			continue
+		}
+		info.RoundChanges[rc.Cause]++
+		if rc.Cause == lbft.RoundChangeTimeout {
+			info.Timeouts[rc.Proposer]++
+		}
+	}
+	return info, nil
+}
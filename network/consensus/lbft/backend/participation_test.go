@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParticipationSince(t *testing.T) {
+	vals := testValidators(4)
+	now := time.Now()
+	p := newParticipation()
+	p.blocks = []*blockParticipation{
+		// committed by this node in round 1, after the first proposer failed
+		{Number: 1, Time: uint64(now.Unix()), Proposer: vals[1], Round: 1, Missed: vals[:1], Committers: vals[:3], Validators: vals},
+		// synced from peers
+		{Number: 2, Time: uint64(now.Unix()), Proposer: vals[2], Round: 0, Inferred: true, Committers: vals[1:], Validators: vals},
+	}
+
+	info, err := p.since(now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.From != 1 || info.To != 2 || info.Inferred != 1 {
+		t.Fatalf("window %d-%d with %d inferred blocks, want 1-2 with 1", info.From, info.To, info.Inferred)
+	}
+	for _, s := range info.Validators {
+		missed := uint64(0)
+		if s.Address == vals[0] {
+			missed = 1
+		}
+		if s.Blocks != 2 || s.MissedProposals != missed {
+			t.Errorf("%s: %d blocks and %d missed proposals, want 2 and %d", s.Address.Hex(), s.Blocks, s.MissedProposals, missed)
+		}
+	}
+}
@@ -6,21 +6,21 @@ import (
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/ethereum/go-ethereum/common"
-	"gorm.io/gorm"
 
-	"land-bridge/models"
 	"land-bridge/network/consensus/lbft"
 	"land-bridge/network/consensus/lbft/validator"
+	"land-bridge/network/storage"
 	"land-bridge/network/utils"
 )
 
 // Vote represents a single vote that an authorized validator made to modify the
 // list of authorizations.
 type Vote struct {
-	Validator common.Address `json:"validator"` // Authorized validator that cast this vote
-	Block     uint64         `json:"block"`     // Block number the vote was cast in (expire old votes)
-	Address   common.Address `json:"address"`   // Account being voted on to change its authorization
-	Authorize bool           `json:"authorize"` // Whether to authorize or deauthorize the voted account
+	Validator common.Address `json:"validator"`        // Authorized validator that cast this vote
+	Block     uint64         `json:"block"`            // Block number the vote was cast in (expire old votes)
+	Address   common.Address `json:"address"`          // Account being voted on to change its authorization
+	Authorize bool           `json:"authorize"`        // Whether to authorize or deauthorize the voted account
+	Weight    uint64         `json:"weight,omitempty"` // Proposer weight voted for the account, 0 for an authorization vote
 }
 
 // Tally is a simple vote tally to keep the current score of votes. Votes that
@@ -33,11 +33,12 @@ type Tally struct {
 type Snapshot struct {
 	Epoch uint64 // The number of blocks after which to checkpoint and reset the pending votes
 
-	Number uint64                   // Block number where the snapshot was created
-	Hash   common.Hash              // Block hash where the snapshot was created
-	Votes  []*Vote                  // List of votes cast in chronological order
-	Tally  map[common.Address]Tally // Current vote tally to avoid recalculating
-	ValSet lbft.ValidatorSet        // Set of authorized validators at this moment
+	Number      uint64                            // Block number where the snapshot was created
+	Hash        common.Hash                       // Block hash where the snapshot was created
+	Votes       []*Vote                           // List of votes cast in chronological order
+	Tally       map[common.Address]Tally          // Current vote tally to avoid recalculating
+	WeightTally map[common.Address]map[uint64]int // Current proposer weight votes per account and weight
+	ValSet      lbft.ValidatorSet                 // Set of authorized validators and their weights at this moment
 }
 
 // newSnapshot create a new snapshot with the specified startup parameters. This
@@ -45,24 +46,25 @@ type Snapshot struct {
 // the genesis block.
 func newSnapshot(epoch uint64, number uint64, hash common.Hash, valSet lbft.ValidatorSet) *Snapshot {
 	snap := &Snapshot{
-		Epoch:  epoch,
-		Number: number,
-		Hash:   hash,
-		ValSet: valSet,
-		Tally:  make(map[common.Address]Tally),
+		Epoch:       epoch,
+		Number:      number,
+		Hash:        hash,
+		ValSet:      valSet,
+		Tally:       make(map[common.Address]Tally),
+		WeightTally: make(map[common.Address]map[uint64]int),
 	}
+	valSet.SetParent(number, hash)
 	return snap
 }
 
-// loadSnapshot loads an existing snapshot from the database.
-func loadSnapshot(epoch uint64, db *gorm.DB, hash common.Hash) (*Snapshot, error) {
-	ssModel := &models.Snapshot{}
-	if err := db.Where("hash = ?", hash.Hex()).Find(ssModel).Error; err != nil {
-		logs.Error("GORM snapshot err: ", err)
+// loadSnapshot loads an existing snapshot from the chain store.
+func loadSnapshot(epoch uint64, db storage.ChainStore, hash common.Hash) (*Snapshot, error) {
+	blob, err := db.ReadSnapshot(hash)
+	if err != nil {
 		return nil, err
 	}
 	snap := new(Snapshot)
-	if err := json.Unmarshal([]byte(ssModel.Bytes), snap); err != nil {
+	if err := json.Unmarshal(blob, snap); err != nil {
 		return nil, err
 	}
 	snap.Epoch = epoch
@@ -70,38 +72,36 @@ func loadSnapshot(epoch uint64, db *gorm.DB, hash common.Hash) (*Snapshot, error
 	return snap, nil
 }
 
-// store inserts the snapshot into the database.
-func (s *Snapshot) store(db *gorm.DB) error {
+// store inserts the snapshot into the chain store.
+func (s *Snapshot) store(db storage.ChainStore) error {
 	blob, err := json.Marshal(s)
 	if err != nil {
 		return err
 	}
-
-	ssModel := &models.Snapshot{}
-	if err := db.Model(&models.Snapshot{}).Where("hash = ?", s.Hash.Hex()).First(&ssModel).Error; err != nil && err != gorm.ErrRecordNotFound {
-		return err
-	}
-
-	ssModel.Hash = s.Hash.Hex()
-	ssModel.Bytes = string(blob)
-
-	return db.Save(ssModel).Error
+	return db.WriteSnapshot(s.Hash, blob)
 }
 
 // copy creates a deep copy of the snapshot, though not the individual votes.
 func (s *Snapshot) copy() *Snapshot {
 	cpy := &Snapshot{
-		Epoch:  s.Epoch,
-		Number: s.Number,
-		Hash:   s.Hash,
-		ValSet: s.ValSet.Copy(),
-		Votes:  make([]*Vote, len(s.Votes)),
-		Tally:  make(map[common.Address]Tally),
+		Epoch:       s.Epoch,
+		Number:      s.Number,
+		Hash:        s.Hash,
+		ValSet:      s.ValSet.Copy(),
+		Votes:       make([]*Vote, len(s.Votes)),
+		Tally:       make(map[common.Address]Tally),
+		WeightTally: make(map[common.Address]map[uint64]int),
 	}
 
 	for address, tally := range s.Tally {
 		cpy.Tally[address] = tally
 	}
+	for address, tallies := range s.WeightTally {
+		cpy.WeightTally[address] = make(map[uint64]int, len(tallies))
+		for weight, votes := range tallies {
+			cpy.WeightTally[address][weight] = votes
+		}
+	}
 	copy(cpy.Votes, s.Votes)
 
 	return cpy
@@ -152,6 +152,51 @@ func (s *Snapshot) uncast(address common.Address, authorize bool) bool {
 	return true
 }
 
+// checkWeightVote return whether it's a valid proposer weight vote
+func (s *Snapshot) checkWeightVote(address common.Address, weight uint64) bool {
+	current := s.ValSet.Weight(address)
+	return current != 0 && weight != 0 && weight != current
+}
+
+// castWeight adds a new proposer weight vote into the tally of its weight.
+// Votes for different weights of the same account are tallied apart.
+func (s *Snapshot) castWeight(address common.Address, weight uint64) bool {
+	if !s.checkWeightVote(address, weight) {
+		return false
+	}
+	tallies, ok := s.WeightTally[address]
+	if !ok {
+		tallies = make(map[uint64]int)
+		s.WeightTally[address] = tallies
+	}
+	tallies[weight]++
+	return true
+}
+
+// uncastWeight removes a previously cast proposer weight vote from the tally.
+func (s *Snapshot) uncastWeight(address common.Address, weight uint64) bool {
+	tallies := s.WeightTally[address]
+	if tallies[weight] == 0 {
+		return false
+	}
+	if tallies[weight]--; tallies[weight] == 0 {
+		delete(tallies, weight)
+	}
+	if len(tallies) == 0 {
+		delete(s.WeightTally, address)
+	}
+	return true
+}
+
+// uncastVote removes a vote of either kind from the tally.
+func (s *Snapshot) uncastVote(vote *Vote) {
+	if vote.Weight != 0 {
+		s.uncastWeight(vote.Address, vote.Weight)
+	} else {
+		s.uncast(vote.Address, vote.Authorize)
+	}
+}
+
 // apply creates a new authorization snapshot by applying the given headers to
 // the original one.
 func (s *Snapshot) apply(blocks []*utils.Block) (*Snapshot, error) {
@@ -177,6 +222,7 @@ func (s *Snapshot) apply(blocks []*utils.Block) (*Snapshot, error) {
 		if number%s.Epoch == 0 {
 			snap.Votes = nil
 			snap.Tally = make(map[common.Address]Tally)
+			snap.WeightTally = make(map[common.Address]map[uint64]int)
 		}
 		// Resolve the authorization key and check against validators
 		validator, err := ecrecover(block)
@@ -187,27 +233,38 @@ func (s *Snapshot) apply(blocks []*utils.Block) (*Snapshot, error) {
 			return nil, errUnauthorized
 		}
 		candidate := common.BytesToAddress(block.CBytes[:20])
-		// Header authorized, discard any previous votes from the validator
+		// Tally up the new vote from the validator
+		var (
+			authorize bool
+			weight    uint64
+		)
+		switch {
+		case bytes.Compare(block.CBytes[20:], nonceAuthVote) == 0:
+			authorize = true
+		case bytes.Compare(block.CBytes[20:], nonceDropVote) == 0:
+			authorize = false
+		case block.CBytes[20] == nonceWeightVote && block.CBytes[21] != 0:
+			weight = uint64(block.CBytes[21])
+		default:
+			return nil, errInvalidVote
+		}
+
+		// Header authorized, discard any previous vote of the same kind from
+		// the validator
 		for i, vote := range snap.Votes {
-			if vote.Validator == validator && vote.Address == candidate {
+			if vote.Validator == validator && vote.Address == candidate && (vote.Weight != 0) == (weight != 0) {
 				// Uncast the vote from the cached tally
-				snap.uncast(vote.Address, vote.Authorize)
+				snap.uncastVote(vote)
 
 				// Uncast the vote from the chronological list
 				snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
 				break // only one vote allowed
 			}
 		}
-		// Tally up the new vote from the validator
-		var authorize bool
 
-		switch {
-		case bytes.Compare(block.CBytes[20:], nonceAuthVote) == 0:
-			authorize = true
-		case bytes.Compare(block.CBytes[20:], nonceDropVote) == 0:
-			authorize = false
-		default:
-			return nil, errInvalidVote
+		if weight != 0 {
+			snap.applyWeightVote(validator, number, candidate, weight)
+			continue
 		}
 
 		if snap.cast(candidate, authorize) {
@@ -231,7 +288,7 @@ func (s *Snapshot) apply(blocks []*utils.Block) (*Snapshot, error) {
 				for i := 0; i < len(snap.Votes); i++ {
 					if snap.Votes[i].Validator == candidate {
 						// Uncast the vote from the cached tally
-						snap.uncast(snap.Votes[i].Address, snap.Votes[i].Authorize)
+						snap.uncastVote(snap.Votes[i])
 
 						// Uncast the vote from the chronological list
 						snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
@@ -243,6 +300,7 @@ func (s *Snapshot) apply(blocks []*utils.Block) (*Snapshot, error) {
 			// Discard any previous votes around the just changed account
 			for i := 0; i < len(snap.Votes); i++ {
 				if snap.Votes[i].Address == candidate {
+					snap.uncastVote(snap.Votes[i])
 					snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
 					i--
 				}
@@ -252,10 +310,37 @@ func (s *Snapshot) apply(blocks []*utils.Block) (*Snapshot, error) {
 	}
 	snap.Number += uint64(len(blocks))
 	snap.Hash = blocks[len(blocks)-1].Hash()
+	snap.ValSet.SetParent(snap.Number, snap.Hash)
 
 	return snap, nil
 }
 
+// applyWeightVote tallies a proposer weight vote and sets the weight once a
+// majority of the validators voted for it.
+func (s *Snapshot) applyWeightVote(validator common.Address, number uint64, candidate common.Address, weight uint64) {
+	if s.castWeight(candidate, weight) {
+		s.Votes = append(s.Votes, &Vote{
+			Validator: validator,
+			Block:     number,
+			Address:   candidate,
+			Weight:    weight,
+		})
+	}
+	if votes := s.WeightTally[candidate][weight]; votes > s.ValSet.Size()/2 {
+		logs.Info("proposer weight of %s set to %d", candidate.Hex(), weight)
+		s.ValSet.SetWeight(candidate, weight)
+
+		// Discard the weight votes around the account
+		for i := 0; i < len(s.Votes); i++ {
+			if s.Votes[i].Address == candidate && s.Votes[i].Weight != 0 {
+				s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+				i--
+			}
+		}
+		delete(s.WeightTally, candidate)
+	}
+}
+
 // validators retrieves the list of authorized validators in ascending order.
 func (s *Snapshot) validators() []common.Address {
 	validators := make([]common.Address, 0, s.ValSet.Size())
@@ -273,26 +358,30 @@ func (s *Snapshot) validators() []common.Address {
 }
 
 type snapshotJSON struct {
-	Epoch  uint64                   `json:"epoch"`
-	Number uint64                   `json:"number"`
-	Hash   common.Hash              `json:"hash"`
-	Votes  []*Vote                  `json:"votes"`
-	Tally  map[common.Address]Tally `json:"tally"`
+	Epoch       uint64                            `json:"epoch"`
+	Number      uint64                            `json:"number"`
+	Hash        common.Hash                       `json:"hash"`
+	Votes       []*Vote                           `json:"votes"`
+	Tally       map[common.Address]Tally          `json:"tally"`
+	WeightTally map[common.Address]map[uint64]int `json:"weightTallies,omitempty"`
 
 	// for validator set
-	Validators []common.Address    `json:"validators"`
-	Policy     lbft.ProposerPolicy `json:"policy"`
+	Validators []common.Address          `json:"validators"`
+	Policy     lbft.ProposerPolicy       `json:"policy"`
+	Weights    map[common.Address]uint64 `json:"weights,omitempty"`
 }
 
 func (s *Snapshot) toJSONStruct() *snapshotJSON {
 	return &snapshotJSON{
-		Epoch:      s.Epoch,
-		Number:     s.Number,
-		Hash:       s.Hash,
-		Votes:      s.Votes,
-		Tally:      s.Tally,
-		Validators: s.validators(),
-		Policy:     s.ValSet.Policy(),
+		Epoch:       s.Epoch,
+		Number:      s.Number,
+		Hash:        s.Hash,
+		Votes:       s.Votes,
+		Tally:       s.Tally,
+		WeightTally: s.WeightTally,
+		Validators:  s.validators(),
+		Policy:      s.ValSet.Policy(),
+		Weights:     s.ValSet.Weights(),
 	}
 }
 
@@ -308,7 +397,15 @@ func (s *Snapshot) UnmarshalJSON(b []byte) error {
 	s.Hash = j.Hash
 	s.Votes = j.Votes
 	s.Tally = j.Tally
+	s.WeightTally = j.WeightTally
+	if s.WeightTally == nil {
+		s.WeightTally = make(map[common.Address]map[uint64]int)
+	}
 	s.ValSet = validator.NewSet(j.Validators, j.Policy)
+	for address, weight := range j.Weights {
+		s.ValSet.SetWeight(address, weight)
+	}
+	s.ValSet.SetParent(j.Number, j.Hash)
 	return nil
 }
 
@@ -0,0 +1,123 @@
+package backend
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"land-bridge/network/consensus/lbft"
+	"land-bridge/network/consensus/lbft/validator"
+)
+
+func testValidators(n int) []common.Address {
+	addrs := make([]common.Address, n)
+	for i := range addrs {
+		addrs[i] = common.BytesToAddress([]byte{byte(i + 1)})
+	}
+	return addrs
+}
+
+func TestApplyWeightVote(t *testing.T) {
+	vals := testValidators(4)
+	snap := newSnapshot(30000, 0, common.Hash{}, validator.NewSet(vals, lbft.WeightedRoundRobin))
+	candidate := vals[3]
+
+	// votes for different weights are tallied apart
+	snap.applyWeightVote(vals[0], 1, candidate, 3)
+	snap.applyWeightVote(vals[1], 2, candidate, 5)
+	snap.applyWeightVote(vals[2], 3, candidate, 3)
+	if w := snap.ValSet.Weight(candidate); w != lbft.DefaultWeight {
+		t.Fatalf("weight %d set by split votes", w)
+	}
+	if want := map[uint64]int{3: 2, 5: 1}; !reflect.DeepEqual(snap.WeightTally[candidate], want) {
+		t.Fatalf("tally %v, want %v", snap.WeightTally[candidate], want)
+	}
+
+	// a vote for the current weight is not counted
+	snap.applyWeightVote(vals[1], 4, candidate, lbft.DefaultWeight)
+	if len(snap.Votes) != 3 {
+		t.Fatalf("%d votes, want 3", len(snap.Votes))
+	}
+
+	// more than half of the 4 validators for the same weight
+	snap.applyWeightVote(vals[3], 5, candidate, 3)
+	if w := snap.ValSet.Weight(candidate); w != 3 {
+		t.Fatalf("weight %d after a majority voted 3", w)
+	}
+	if len(snap.Votes) != 0 || len(snap.WeightTally) != 0 {
+		t.Fatalf("votes %d and tallies %v left after the weight changed", len(snap.Votes), snap.WeightTally)
+	}
+}
+
+func TestApplyWeightVoteThreshold(t *testing.T) {
+	tests := []struct {
+		validators int
+		needed     int
+	}{
+		{1, 1}, {2, 2}, {3, 2}, {4, 3}, {5, 3}, {7, 4},
+	}
+	for _, tt := range tests {
+		vals := testValidators(tt.validators)
+		snap := newSnapshot(30000, 0, common.Hash{}, validator.NewSet(vals, lbft.WeightedRandom))
+		for i := 0; i < tt.needed; i++ {
+			if w := snap.ValSet.Weight(vals[0]); w != lbft.DefaultWeight {
+				t.Fatalf("%d validators: weight set after %d votes, want %d", tt.validators, i, tt.needed)
+			}
+			snap.applyWeightVote(vals[i], uint64(i+1), vals[0], 4)
+		}
+		if w := snap.ValSet.Weight(vals[0]); w != 4 {
+			t.Errorf("%d validators: weight %d after %d votes", tt.validators, w, tt.needed)
+		}
+	}
+}
+
+func TestSnapshotWeightsJSON(t *testing.T) {
+	vals := testValidators(4)
+	snap := newSnapshot(30000, 12, common.HexToHash("0x12"), validator.NewSet(vals, lbft.WeightedRoundRobin))
+	snap.ValSet.SetWeight(vals[1], 5)
+	snap.applyWeightVote(vals[0], 10, vals[2], 2)
+
+	blob, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := new(Snapshot)
+	if err := json.Unmarshal(blob, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ValSet.Policy() != lbft.WeightedRoundRobin {
+		t.Errorf("policy %d, want %d", loaded.ValSet.Policy(), lbft.WeightedRoundRobin)
+	}
+	if got, want := loaded.ValSet.Weights(), snap.ValSet.Weights(); !reflect.DeepEqual(got, want) {
+		t.Errorf("weights %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(loaded.WeightTally, snap.WeightTally) {
+		t.Errorf("tallies %v, want %v", loaded.WeightTally, snap.WeightTally)
+	}
+	if len(loaded.Votes) != 1 || *loaded.Votes[0] != *snap.Votes[0] {
+		t.Errorf("votes %v, want %v", loaded.Votes, snap.Votes)
+	}
+	// the loaded snapshot picks the same proposers
+	for round := uint64(0); round < 12; round++ {
+		snap.ValSet.CalcProposer(common.Address{}, round)
+		loaded.ValSet.CalcProposer(common.Address{}, round)
+		if got, want := loaded.ValSet.GetProposer().Address(), snap.ValSet.GetProposer().Address(); got != want {
+			t.Fatalf("round %d: loaded snapshot picks %s, want %s", round, got.Hex(), want.Hex())
+		}
+	}
+
+	// the default weights are left out
+	plain := newSnapshot(30000, 12, common.HexToHash("0x12"), validator.NewSet(vals, lbft.WeightedRoundRobin))
+	if blob, err = json.Marshal(plain); err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(blob, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["weights"]; ok {
+		t.Errorf("default weights encoded: %s", blob)
+	}
+}
@@ -1,14 +1,50 @@
 package lbft
 
-import "math/big"
+import (
+	"fmt"
+	"math/big"
+
+	"land-bridge/conf"
+	"land-bridge/network/utils"
+)
 
 type ProposerPolicy uint64
 
 const (
 	RoundRobin ProposerPolicy = iota
 	Sticky
+	WeightedRoundRobin // round robin visiting each validator as often as its weight
+	WeightedRandom     // weighted pseudo-random pick seeded by the parent block hash
+)
+
+// Weighted reports whether the policy selects the proposers by their weights.
+func (p ProposerPolicy) Weighted() bool {
+	return p == WeightedRoundRobin || p == WeightedRandom
+}
+
+// RoundChangeCause tells why a validator left a round.
+type RoundChangeCause string
+
+const (
+	RoundChangeTimeout         RoundChangeCause = "timeout"          // the round timer expired
+	RoundChangeInvalidProposal RoundChangeCause = "invalid proposal" // the proposal failed verification
+	RoundChangeLockMismatch    RoundChangeCause = "lock mismatch"    // the proposal differs from the locked one
+	RoundChangeCommitFailed    RoundChangeCause = "commit failed"    // the committed block could not be inserted
+	RoundChangeCatchUp         RoundChangeCause = "catch up"         // F+1 validators already moved to a later round
+	RoundChangeQuorum          RoundChangeCause = "quorum"           // a quorum of validators moved to a later round
 )
 
+// DefaultWeight is the proposer weight of a validator no vote changed.
+const DefaultWeight = 1
+
+var proposerPolicies = map[string]ProposerPolicy{
+	"":                      RoundRobin,
+	conf.ProposerRoundRobin: RoundRobin,
+	conf.ProposerSticky:     Sticky,
+	conf.ProposerWeighted:   WeightedRoundRobin,
+	conf.ProposerRandom:     WeightedRandom,
+}
+
 type Config struct {
 	RequestTimeout         uint64         `toml:",omitempty"` // The timeout for each LBFT round in milliseconds.
 	BlockPeriod            uint64         `toml:",omitempty"` // Default minimum difference between two consecutive block's timestamps in second
@@ -16,6 +52,7 @@ type Config struct {
 	Epoch                  uint64         `toml:",omitempty"` // The number of blocks after which to checkpoint and reset the pending votes
 	Ceil2Nby3Block         *big.Int       `toml:",omitempty"` // Number of confirmations required to move from one state to next [2F + 1 to Ceil(2N/3)]
 	AllowedFutureBlockTime uint64         `toml:",omitempty"` // Max time (in seconds) from current time allowed for blocks, before they're considered future blocks
+	WALPath                string         `toml:",omitempty"` // File logging the round state and signed messages, no log if empty
 }
 
 var DefaultConfig = &Config{
@@ -26,3 +63,86 @@ var DefaultConfig = &Config{
 	Ceil2Nby3Block:         big.NewInt(0),
 	AllowedFutureBlockTime: 0,
 }
+
+// NewConfig returns DefaultConfig with the non-zero fields of the node
+// configuration applied.
+func NewConfig(cc *conf.ConsensusConfig) (*Config, error) {
+	c := *DefaultConfig
+	c.Ceil2Nby3Block = new(big.Int).Set(DefaultConfig.Ceil2Nby3Block)
+	if cc == nil {
+		return &c, nil
+	}
+	policy, ok := proposerPolicies[cc.ProposerPolicy]
+	if !ok {
+		return nil, fmt.Errorf("unknown proposer policy %q", cc.ProposerPolicy)
+	}
+	if cc.ProposerPolicy != "" {
+		c.ProposerPolicy = policy
+	}
+	if cc.RequestTimeout != 0 {
+		c.RequestTimeout = cc.RequestTimeout
+	}
+	if cc.AllowedFutureBlockTime != 0 {
+		c.AllowedFutureBlockTime = cc.AllowedFutureBlockTime
+	}
+	if cc.BlockPeriod != 0 {
+		c.BlockPeriod = cc.BlockPeriod
+	}
+	if cc.Epoch != 0 {
+		c.Epoch = cc.Epoch
+	}
+	if cc.Ceil2Nby3Block != 0 {
+		c.Ceil2Nby3Block.SetUint64(cc.Ceil2Nby3Block)
+	}
+	return &c, nil
+}
+
+// Params returns the parameters that must be identical network-wide.
+func (c *Config) Params() *utils.ConsensusParams {
+	return &utils.ConsensusParams{
+		BlockPeriod:    c.BlockPeriod,
+		ProposerPolicy: uint64(c.ProposerPolicy),
+		Epoch:          c.Epoch,
+		Ceil2Nby3Block: c.Ceil2Nby3Block.Uint64(),
+	}
+}
+
+// Forks returns the heights activating consensus rule changes, which the
+// fork identifier of the chain is derived from.
+func (c *Config) Forks() []uint64 {
+	var forks []uint64
+	if c.Ceil2Nby3Block != nil && c.Ceil2Nby3Block.Sign() > 0 {
+		forks = append(forks, c.Ceil2Nby3Block.Uint64())
+	}
+	return forks
+}
+
+// ApplyGenesis replaces the network-wide parameters by the ones the genesis
+// block commits to. It fails if the node configuration explicitly sets a
+// different value, so a misconfigured node does not start.
+func (c *Config) ApplyGenesis(cc *conf.ConsensusConfig, genesis *utils.ConsensusParams) error {
+	if cc != nil {
+		policy := proposerPolicies[cc.ProposerPolicy]
+		switch {
+		case cc.BlockPeriod != 0 && cc.BlockPeriod != genesis.BlockPeriod:
+			return fmt.Errorf("Consensus.BlockPeriod is %d but the genesis block commits to %d", cc.BlockPeriod, genesis.BlockPeriod)
+		case cc.ProposerPolicy != "" && uint64(policy) != genesis.ProposerPolicy:
+			return fmt.Errorf("Consensus.ProposerPolicy is %s but the genesis block commits to policy %d", cc.ProposerPolicy, genesis.ProposerPolicy)
+		case cc.Epoch != 0 && cc.Epoch != genesis.Epoch:
+			return fmt.Errorf("Consensus.Epoch is %d but the genesis block commits to %d", cc.Epoch, genesis.Epoch)
+		case cc.Ceil2Nby3Block != 0 && cc.Ceil2Nby3Block != genesis.Ceil2Nby3Block:
+			return fmt.Errorf("Consensus.Ceil2Nby3Block is %d but the genesis block commits to %d", cc.Ceil2Nby3Block, genesis.Ceil2Nby3Block)
+		}
+	}
+	if genesis.Epoch == 0 {
+		return fmt.Errorf("genesis block commits to a zero epoch")
+	}
+	if genesis.ProposerPolicy > uint64(WeightedRandom) {
+		return fmt.Errorf("genesis block commits to unknown proposer policy %d", genesis.ProposerPolicy)
+	}
+	c.BlockPeriod = genesis.BlockPeriod
+	c.ProposerPolicy = ProposerPolicy(genesis.ProposerPolicy)
+	c.Epoch = genesis.Epoch
+	c.Ceil2Nby3Block = new(big.Int).SetUint64(genesis.Ceil2Nby3Block)
+	return nil
+}
@@ -60,14 +60,25 @@ func (c *core) handleCommit(msg *Message, src lbft.Validator) error {
 	// by committing the proposal without PREPARE messages.
 	if c.current.Commits.Size() >= c.QuorumSize() && c.state.Cmp(StateCommitted) < 0 {
 		bridge := c.backend.Bridge()
-		if c.IsProposer() {
+		proposal := c.current.Proposal()
+		rank, backup := c.backupRank()
+		for i, txHash := range proposal.TxHashes() {
 			signatures := make(map[common.Address][]byte)
 			for addr, message := range c.current.Commits.messages {
-				signatures[addr] = message.HashSign
+				if i < len(message.HashSigns) {
+					signatures[addr] = message.HashSigns[i]
+				}
+			}
+			if c.IsProposer() {
+				go bridge.BridgeToChainB(txHash, signatures)
+			} else {
+				go bridge.UpdateWrapper(txHash)
+				// Every other validator keeps the signatures and takes over in
+				// backup order if the proposer's relay is never observed.
+				if backup {
+					go bridge.BackupRelay(txHash, proposal.Number().Uint64(), rank, signatures)
+				}
 			}
-			go bridge.BridgeToChainB(c.current.Proposal().TxHash(), signatures)
-		} else {
-			go bridge.UpdateWrapper(c.current.Proposal().TxHash())
 		}
 
 		// Still need to call LockHash here since state can skip Prepared state and jump directly to the Committed state.
@@ -78,6 +89,19 @@ func (c *core) handleCommit(msg *Message, src lbft.Validator) error {
 	return nil
 }
 
+// backupRank returns the position of this validator in the backup relayer
+// order of the current round: the validator set rotated to start right
+// after the proposer, which relays first with rank 0.
+func (c *core) backupRank() (uint64, bool) {
+	proposerIndex, _ := c.valSet.GetByAddress(c.valSet.GetProposer().Address())
+	selfIndex, _ := c.valSet.GetByAddress(c.Address())
+	if proposerIndex < 0 || selfIndex < 0 {
+		return 0, false
+	}
+	n := c.valSet.Size()
+	return uint64((selfIndex - proposerIndex + n) % n), true
+}
+
 // verifyCommit verifies if the received COMMIT message is equivalent to our subject
 func (c *core) verifyCommit(commit *consensus.Subject, src lbft.Validator) error {
 	sub := c.current.Subject()
@@ -95,6 +119,7 @@ func (c *core) acceptCommit(msg *Message, src lbft.Validator) error {
 		logs.Error("Failed to record commit message", "msg", msg, "err", err)
 		return err
 	}
+	c.persistReceived(msg)
 
 	return nil
 }
@@ -16,6 +16,9 @@ import (
 	"land-bridge/network/consensus/lbft"
 )
 
+// roundStateTimeout bounds the wait of RoundState for the event loop.
+const roundStateTimeout = 5 * time.Second
+
 // New creates an LBFT consensus core
 func New(backend lbft.Backend, config *lbft.Config) *core {
 	c := &core{
@@ -29,6 +32,7 @@ func New(backend lbft.Backend, config *lbft.Config) *core {
 		pendingRequests:    prque.New(),
 		pendingRequestsMu:  new(sync.Mutex),
 		consensusTimestamp: time.Time{},
+		equivocations:      newEquivocationTracker(),
 	}
 
 	c.validateFn = c.checkValidatorSignature
@@ -63,6 +67,12 @@ type core struct {
 	pendingRequestsMu *sync.Mutex
 
 	consensusTimestamp time.Time
+
+	equivocations *equivocationTracker
+
+	wal          *wal
+	sent         map[voteKey]*signedMessage // messages signed in the current sequence
+	sentSequence uint64
 }
 
 func (c *core) IsCurrentProposal(blockHash common.Hash) bool {
@@ -74,7 +84,7 @@ func (c *core) finalizeMessage(msg *Message) ([]byte, error) {
 	// Add sender address
 	msg.Address = c.Address()
 
-	msg.HashSign = []byte{}
+	msg.HashSigns = [][]byte{}
 
 	// Assign the CommittedSeal if it's a COMMIT message and proposal is not nil
 	if msg.Code == MsgCommit && c.current.Proposal() != nil {
@@ -86,10 +96,13 @@ func (c *core) finalizeMessage(msg *Message) ([]byte, error) {
 			return nil, err
 		}
 
-		param := c.current.Proposal().GetTxParam()
-		msg.HashSign, err = c.backend.Bridge().Sign(&param)
-		if err != nil {
-			return nil, err
+		params := c.current.Proposal().GetTxParams()
+		for i := range params {
+			hashSign, err := c.backend.Bridge().Sign(&params[i])
+			if err != nil {
+				return nil, err
+			}
+			msg.HashSigns = append(msg.HashSigns, hashSign)
 		}
 	}
 
@@ -113,11 +126,24 @@ func (c *core) finalizeMessage(msg *Message) ([]byte, error) {
 }
 
 func (c *core) broadcast(msg *Message) {
-	payload, err := c.finalizeMessage(msg)
+	// Never sign two different votes for a view, and send the same one again
+	// rather than signing it anew.
+	payload, err := c.sentPayload(msg)
 	if err != nil {
-		logs.Error("Failed to finalize message", "msg", msg, "err", err)
+		logs.Error("Refused to send message", "msg", msg, "err", err)
 		return
 	}
+	if payload == nil {
+		payload, err = c.finalizeMessage(msg)
+		if err != nil {
+			logs.Error("Failed to finalize message", "msg", msg, "err", err)
+			return
+		}
+		if err = c.persistSent(msg, payload); err != nil {
+			logs.Error("Failed to log message", "msg", msg, "err", err)
+			return
+		}
+	}
 
 	// Broadcast payload
 	if err = c.backend.Broadcast(c.valSet, payload); err != nil {
@@ -243,6 +269,7 @@ func (c *core) setState(state State) {
 	if state == StateAcceptRequest {
 		c.processPendingRequests()
 	}
+	c.persistState()
 	c.processBacklog()
 	logs.Debug("core State set", state.String())
 }
@@ -284,6 +311,49 @@ func (c *core) IsProposer() bool {
 	return v.IsProposer(c.backend.Address())
 }
 
+// RoundState implements consensus.Core.RoundState. The state is read by the
+// event loop, so that it is not changed while it is copied; nil is returned
+// if the loop does not answer in time.
+func (c *core) RoundState() *consensus.RoundStateInfo {
+	reply := make(chan *consensus.RoundStateInfo, 1)
+	if err := c.backend.EventMux().Post(roundStateEvent{reply: reply}); err != nil {
+		return nil
+	}
+	select {
+	case info := <-reply:
+		return info
+	case <-time.After(roundStateTimeout):
+		return nil
+	}
+}
+
+func (c *core) roundState() *consensus.RoundStateInfo {
+	current, valSet := c.current, c.valSet
+	if current == nil || valSet == nil {
+		return nil
+	}
+	info := &consensus.RoundStateInfo{
+		Sequence:              current.Sequence(),
+		Round:                 current.Round(),
+		State:                 c.state.String(),
+		IsProposer:            valSet.IsProposer(c.backend.Address()),
+		LockedHash:            current.GetLockedHash(),
+		Prepares:              current.Prepares.Size(),
+		Commits:               current.Commits.Size(),
+		WaitingForRoundChange: c.waitingForRoundChange,
+	}
+	if proposer := valSet.GetProposer(); proposer != nil {
+		info.Proposer = proposer.Address()
+	}
+	if proposal := current.Proposal(); proposal != nil {
+		info.Proposal = proposal.Hash()
+	}
+	if c.roundChangeSet != nil {
+		info.RoundChanges = c.roundChangeSet.size(new(big.Int).Add(info.Round, common.Big1))
+	}
+	return info
+}
+
 func (c *core) newRoundChangeTimer(wait bool) {
 	c.stopTimer()
 
@@ -330,9 +400,9 @@ func (c *core) commit() {
 			copy(committedSeals[i][:], v.CommittedSeal[:])
 		}
 
-		if err := c.backend.Commit(proposal, committedSeals); err != nil {
+		if err := c.backend.Commit(proposal, committedSeals, c.current.Round()); err != nil {
 			c.current.UnlockHash() //Unlock block when insertion fails
-			c.sendNextRoundChange()
+			c.sendNextRoundChange(lbft.RoundChangeCommitFailed)
 			return
 		}
 	}
@@ -345,6 +415,7 @@ func (c *core) catchUpRound(view *consensus.View) {
 	c.updateRoundState(view, c.valSet, true)
 	c.roundChangeSet.Clear(view.Round)
 	c.newRoundChangeTimer(false)
+	c.persistState()
 
 	logs.Trace("Catch up round", "new_round", view.Round, "new_seq", view.Sequence, "new_proposer", c.valSet)
 }
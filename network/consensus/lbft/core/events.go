@@ -1,6 +1,7 @@
 package core
 
 import (
+	"land-bridge/network/consensus"
 	"land-bridge/network/consensus/lbft"
 )
 
@@ -10,3 +11,8 @@ type backlogEvent struct {
 }
 
 type timeoutEvent struct{}
+
+// roundStateEvent asks the event loop for a snapshot of the round state.
+type roundStateEvent struct {
+	reply chan *consensus.RoundStateInfo
+}
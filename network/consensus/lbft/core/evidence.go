@@ -0,0 +1,301 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/consensus"
+	"land-bridge/network/consensus/lbft"
+)
+
+// maxTrackedMessages bounds the signed messages remembered per validator to
+// detect equivocation.
+const maxTrackedMessages = 64
+
+var (
+	errInvalidEvidence = errors.New("invalid equivocation evidence")
+	errKnownEvidence   = errors.New("known equivocation evidence")
+)
+
+// Evidence proves that a validator signed two conflicting PRE-PREPARE,
+// PREPARE or COMMIT messages for the same sequence and round. Both messages
+// are kept as signed payloads so anyone can check them.
+type Evidence struct {
+	Validator common.Address
+	Code      uint64
+	Sequence  *big.Int
+	Round     *big.Int
+	First     []byte
+	Second    []byte
+}
+
+// Hash identifies the offence, whichever pair of messages proves it.
+func (e *Evidence) Hash() common.Hash {
+	data, _ := rlp.EncodeToBytes([]interface{}{e.Validator, e.Code, e.Sequence, e.Round})
+	return crypto.Keccak256Hash(data)
+}
+
+// DecodeEvidence decodes and verifies RLP encoded evidence.
+func DecodeEvidence(blob []byte) (*Evidence, error) {
+	var e Evidence
+	if err := rlp.DecodeBytes(blob, &e); err != nil {
+		return nil, err
+	}
+	if err := e.Verify(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Verify checks that both messages are signed by the validator and claim
+// different digests for the view of the evidence.
+func (e *Evidence) Verify() error {
+	if e.Sequence == nil || e.Round == nil {
+		return errInvalidEvidence
+	}
+	first, err := signedDigest(e.First)
+	if err != nil {
+		return err
+	}
+	second, err := signedDigest(e.Second)
+	if err != nil {
+		return err
+	}
+	for _, m := range []*signedMessage{first, second} {
+		if m.address != e.Validator || m.code != e.Code ||
+			m.view.Sequence.Cmp(e.Sequence) != 0 || m.view.Round.Cmp(e.Round) != 0 {
+			return errInvalidEvidence
+		}
+	}
+	if first.digest == second.digest {
+		return errInvalidEvidence
+	}
+	return nil
+}
+
+// signedMessage is the part of a consensus message equivocation is judged on.
+type signedMessage struct {
+	address common.Address
+	code    uint64
+	view    *consensus.View
+	digest  common.Hash
+	payload []byte
+}
+
+// signedDigest checks the signature of a payload and extracts the view and
+// digest it votes for.
+func signedDigest(payload []byte) (*signedMessage, error) {
+	msg := new(Message)
+	if err := msg.FromPayload(payload, lbft.GetSignatureAddress); err != nil {
+		return nil, err
+	}
+	return messageDigest(msg, payload)
+}
+
+func messageDigest(msg *Message, payload []byte) (*signedMessage, error) {
+	m := &signedMessage{address: msg.Address, code: msg.Code, payload: payload}
+	switch msg.Code {
+	case MsgPreprepare:
+		var preprepare *consensus.Preprepare
+		if err := msg.Decode(&preprepare); err != nil {
+			return nil, err
+		}
+		if preprepare.Proposal == nil {
+			return nil, errInvalidEvidence
+		}
+		m.view, m.digest = preprepare.View, preprepare.Proposal.Hash()
+	case MsgPrepare, MsgCommit:
+		var subject *consensus.Subject
+		if err := msg.Decode(&subject); err != nil {
+			return nil, err
+		}
+		m.view, m.digest = subject.View, subject.Digest
+	default:
+		return nil, errInvalidEvidence
+	}
+	if m.view == nil || m.view.Sequence == nil || m.view.Round == nil {
+		return nil, errInvalidEvidence
+	}
+	return m, nil
+}
+
+type voteKey struct {
+	code     uint64
+	sequence uint64
+	round    uint64
+}
+
+// older reports whether k is for an earlier view than o.
+func (k voteKey) older(o voteKey) bool {
+	if k.sequence != o.sequence {
+		return k.sequence < o.sequence
+	}
+	if k.round != o.round {
+		return k.round < o.round
+	}
+	return k.code < o.code
+}
+
+// equivocationTracker remembers the first message each validator signed per
+// kind and view, for the sequences next to the current one.
+type equivocationTracker struct {
+	mu     sync.Mutex
+	seen   map[common.Address]map[voteKey]*signedMessage
+	pruned uint64 // sequence the stale views were last forgotten at
+}
+
+func newEquivocationTracker() *equivocationTracker {
+	return &equivocationTracker{seen: make(map[common.Address]map[voteKey]*signedMessage)}
+}
+
+// track records m and returns evidence if its sender already signed a
+// different digest for the same kind and view. Only views from sequence-1 to
+// sequence+1 are tracked, the older ones are forgotten. A validator sending
+// more than maxTrackedMessages messages evicts its own oldest ones.
+func (t *equivocationTracker) track(m *signedMessage, sequence uint64) *Evidence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !m.view.Sequence.IsUint64() || !m.view.Round.IsUint64() {
+		return nil
+	}
+	if seq := m.view.Sequence.Uint64(); seq+1 < sequence || seq > sequence+1 {
+		return nil
+	}
+	if t.pruned != sequence {
+		t.prune(sequence)
+	}
+
+	key := voteKey{m.code, m.view.Sequence.Uint64(), m.view.Round.Uint64()}
+	votes, ok := t.seen[m.address]
+	if !ok {
+		votes = make(map[voteKey]*signedMessage)
+		t.seen[m.address] = votes
+	}
+	first, ok := votes[key]
+	if !ok {
+		if len(votes) >= maxTrackedMessages {
+			evictOldest(votes)
+		}
+		votes[key] = m
+		return nil
+	}
+	if first.digest == m.digest {
+		return nil
+	}
+	return &Evidence{
+		Validator: m.address,
+		Code:      m.code,
+		Sequence:  new(big.Int).Set(m.view.Sequence),
+		Round:     new(big.Int).Set(m.view.Round),
+		First:     first.payload,
+		Second:    m.payload,
+	}
+}
+
+// prune forgets the views before sequence-1.
+func (t *equivocationTracker) prune(sequence uint64) {
+	for addr, votes := range t.seen {
+		for key := range votes {
+			if key.sequence+1 < sequence {
+				delete(votes, key)
+			}
+		}
+		if len(votes) == 0 {
+			delete(t.seen, addr)
+		}
+	}
+	t.pruned = sequence
+}
+
+func evictOldest(votes map[voteKey]*signedMessage) {
+	var (
+		oldest voteKey
+		found  bool
+	)
+	for key := range votes {
+		if !found || key.older(oldest) {
+			oldest, found = key, true
+		}
+	}
+	delete(votes, oldest)
+}
+
+// checkEquivocation looks for a conflicting message of the sender of msg. New
+// evidence is stored and gossiped to the other validators.
+func (c *core) checkEquivocation(msg *Message, payload []byte) {
+	if msg.Code != MsgPreprepare && msg.Code != MsgPrepare && msg.Code != MsgCommit {
+		return
+	}
+	m, err := messageDigest(msg, payload)
+	if err != nil {
+		return
+	}
+	evidence := c.equivocations.track(m, c.currentSequence())
+	if evidence == nil {
+		return
+	}
+	logs.Warn("validator %s equivocated: two %d messages at sequence %v round %v", evidence.Validator.Hex(), evidence.Code, evidence.Sequence, evidence.Round)
+	if err := c.recordEvidence(evidence); err != nil {
+		if err != errKnownEvidence {
+			logs.Error("record equivocation evidence: %v", err)
+		}
+		return
+	}
+	blob, err := rlp.EncodeToBytes(evidence)
+	if err != nil {
+		return
+	}
+	c.broadcast(&Message{
+		Code: MsgEvidence,
+		Msg:  blob,
+	})
+}
+
+// handleEvidence stores the evidence gossiped by another validator. Only new
+// and valid evidence is accepted, and broadcast again so that it reaches the
+// validators the reporter is not connected to. Known evidence stops there.
+func (c *core) handleEvidence(msg *Message, src lbft.Validator) error {
+	evidence, err := DecodeEvidence(msg.Msg)
+	if err != nil {
+		logs.Warn("invalid equivocation evidence from %s: %v", src.Address().Hex(), err)
+		return errInvalidEvidence
+	}
+	if err := c.recordEvidence(evidence); err != nil {
+		return err
+	}
+	logs.Warn("validator %s equivocated at sequence %v round %v, reported by %s", evidence.Validator.Hex(), evidence.Sequence, evidence.Round, src.Address().Hex())
+	c.broadcast(&Message{
+		Code: MsgEvidence,
+		Msg:  msg.Msg,
+	})
+	return nil
+}
+
+func (c *core) recordEvidence(evidence *Evidence) error {
+	blob, err := rlp.EncodeToBytes(evidence)
+	if err != nil {
+		return err
+	}
+	stored, err := c.backend.StoreEvidence(evidence.Hash(), blob)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return errKnownEvidence
+	}
+	return nil
+}
+
+func (c *core) currentSequence() uint64 {
+	if c.current == nil || !c.current.Sequence().IsUint64() {
+		return 0
+	}
+	return c.current.Sequence().Uint64()
+}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/consensus"
+)
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signedVote returns the payload of a message of the given kind signed by key
+// for digest at sequence and round.
+func signedVote(t *testing.T, key *ecdsa.PrivateKey, code uint64, sequence, round int64, digest common.Hash) []byte {
+	subject, err := Encode(&consensus.Subject{
+		View:   &consensus.View{Sequence: big.NewInt(sequence), Round: big.NewInt(round)},
+		Digest: digest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &Message{Code: code, Msg: subject, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	data, err := msg.PayloadNoSig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Signature, err = crypto.Sign(crypto.Keccak256(data), key); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := msg.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func trackVote(t *testing.T, tracker *equivocationTracker, payload []byte, current uint64) *Evidence {
+	m, err := signedDigest(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker.track(m, current)
+}
+
+func testEvidence(t *testing.T, key *ecdsa.PrivateKey) *Evidence {
+	return &Evidence{
+		Validator: crypto.PubkeyToAddress(key.PublicKey),
+		Code:      MsgCommit,
+		Sequence:  big.NewInt(5),
+		Round:     big.NewInt(1),
+		First:     signedVote(t, key, MsgCommit, 5, 1, common.HexToHash("0x01")),
+		Second:    signedVote(t, key, MsgCommit, 5, 1, common.HexToHash("0x02")),
+	}
+}
+
+func TestEvidenceVerify(t *testing.T) {
+	key, other := newTestKey(t), newTestKey(t)
+	if err := testEvidence(t, key).Verify(); err != nil {
+		t.Fatalf("valid evidence: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(e *Evidence)
+	}{
+		{"same digest", func(e *Evidence) { e.Second = e.First }},
+		{"other validator", func(e *Evidence) { e.Validator = crypto.PubkeyToAddress(other.PublicKey) }},
+		{"second message by another validator", func(e *Evidence) {
+			e.Second = signedVote(t, other, MsgCommit, 5, 1, common.HexToHash("0x02"))
+		}},
+		{"other kind", func(e *Evidence) { e.Code = MsgPrepare }},
+		{"messages of two kinds", func(e *Evidence) {
+			e.Second = signedVote(t, key, MsgPrepare, 5, 1, common.HexToHash("0x02"))
+		}},
+		{"other sequence", func(e *Evidence) { e.Sequence = big.NewInt(6) }},
+		{"other round", func(e *Evidence) { e.Round = big.NewInt(2) }},
+		{"messages of two rounds", func(e *Evidence) {
+			e.Second = signedVote(t, key, MsgCommit, 5, 2, common.HexToHash("0x02"))
+		}},
+		{"no view", func(e *Evidence) { e.Sequence = nil }},
+		{"signature over another message", func(e *Evidence) {
+			var first, second Message
+			if err := rlp.DecodeBytes(e.First, &first); err != nil {
+				t.Fatal(err)
+			}
+			if err := rlp.DecodeBytes(e.Second, &second); err != nil {
+				t.Fatal(err)
+			}
+			second.Signature = first.Signature
+			e.Second, _ = second.Payload()
+		}},
+		{"not a vote", func(e *Evidence) {
+			e.Second = signedVote(t, key, MsgRoundChange, 5, 1, common.HexToHash("0x02"))
+		}},
+	}
+	for _, tt := range tests {
+		e := testEvidence(t, key)
+		tt.modify(e)
+		if err := e.Verify(); err == nil {
+			t.Errorf("%s: evidence verified", tt.name)
+		}
+	}
+}
+
+func TestDecodeEvidence(t *testing.T) {
+	key := newTestKey(t)
+	e := testEvidence(t, key)
+	blob, err := rlp.EncodeToBytes(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeEvidence(blob)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if decoded.Hash() != e.Hash() {
+		t.Fatalf("decoded evidence %x, want %x", decoded.Hash(), e.Hash())
+	}
+	// the same offence proven by the messages in the other order
+	e.First, e.Second = e.Second, e.First
+	if e.Hash() != decoded.Hash() {
+		t.Fatal("offence hash depends on the message order")
+	}
+
+	if _, err := DecodeEvidence(blob[:len(blob)-1]); err == nil {
+		t.Error("truncated evidence decoded")
+	}
+	e.Round = big.NewInt(3)
+	if blob, err = rlp.EncodeToBytes(e); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeEvidence(blob); err != errInvalidEvidence {
+		t.Errorf("evidence of the wrong round: %v, want %v", err, errInvalidEvidence)
+	}
+}
+
+func TestEquivocationTracker(t *testing.T) {
+	key := newTestKey(t)
+	tracker := newEquivocationTracker()
+	a, b := common.HexToHash("0x0a"), common.HexToHash("0x0b")
+
+	if e := trackVote(t, tracker, signedVote(t, key, MsgPrepare, 10, 0, a), 10); e != nil {
+		t.Fatal("evidence from a first message")
+	}
+	if e := trackVote(t, tracker, signedVote(t, key, MsgPrepare, 10, 0, a), 10); e != nil {
+		t.Fatal("evidence from the same message twice")
+	}
+	if e := trackVote(t, tracker, signedVote(t, key, MsgCommit, 10, 0, b), 10); e != nil {
+		t.Fatal("evidence from messages of two kinds")
+	}
+	if e := trackVote(t, tracker, signedVote(t, key, MsgPrepare, 10, 1, b), 10); e != nil {
+		t.Fatal("evidence from messages of two rounds")
+	}
+	e := trackVote(t, tracker, signedVote(t, key, MsgPrepare, 10, 0, b), 10)
+	if e == nil {
+		t.Fatal("no evidence from conflicting messages")
+	}
+	if err := e.Verify(); err != nil {
+		t.Fatalf("tracked evidence does not verify: %v", err)
+	}
+}
+
+func TestEquivocationTrackerWindow(t *testing.T) {
+	key := newTestKey(t)
+	a, b := common.HexToHash("0x0a"), common.HexToHash("0x0b")
+
+	tests := []struct {
+		sequence int64
+		tracked  bool
+	}{
+		{8, false}, {9, true}, {10, true}, {11, true}, {12, false}, {1000, false},
+	}
+	for _, tt := range tests {
+		tracker := newEquivocationTracker()
+		trackVote(t, tracker, signedVote(t, key, MsgCommit, tt.sequence, 0, a), 10)
+		if e := trackVote(t, tracker, signedVote(t, key, MsgCommit, tt.sequence, 0, b), 10); (e != nil) != tt.tracked {
+			t.Errorf("sequence %d at 10: evidence %v, want tracked %v", tt.sequence, e != nil, tt.tracked)
+		}
+	}
+
+	// views behind the window are forgotten once the sequence moves on
+	tracker := newEquivocationTracker()
+	trackVote(t, tracker, signedVote(t, key, MsgCommit, 10, 0, a), 10)
+	trackVote(t, tracker, signedVote(t, key, MsgCommit, 12, 0, a), 12)
+	if n := len(tracker.seen[crypto.PubkeyToAddress(key.PublicKey)]); n != 1 {
+		t.Fatalf("%d messages tracked after the sequence moved on, want 1", n)
+	}
+}
+
+func TestEquivocationTrackerEviction(t *testing.T) {
+	flooder, honest := newTestKey(t), newTestKey(t)
+	a, b := common.HexToHash("0x0a"), common.HexToHash("0x0b")
+	tracker := newEquivocationTracker()
+
+	trackVote(t, tracker, signedVote(t, honest, MsgCommit, 10, 0, a), 10)
+	for round := int64(0); round <= maxTrackedMessages; round++ {
+		trackVote(t, tracker, signedVote(t, flooder, MsgPrepare, 10, round, a), 10)
+	}
+	if n := len(tracker.seen[crypto.PubkeyToAddress(flooder.PublicKey)]); n != maxTrackedMessages {
+		t.Fatalf("%d messages of the flooding validator tracked, want %d", n, maxTrackedMessages)
+	}
+
+	// the flooding validator evicts its own oldest messages, not the others'
+	if e := trackVote(t, tracker, signedVote(t, honest, MsgCommit, 10, 0, b), 10); e == nil {
+		t.Fatal("equivocation missed after another validator flooded the tracker")
+	}
+	if e := trackVote(t, tracker, signedVote(t, flooder, MsgPrepare, 10, maxTrackedMessages, b), 10); e == nil {
+		t.Fatal("equivocation missed in the latest round of the flooding validator")
+	}
+	if e := trackVote(t, tracker, signedVote(t, flooder, MsgPrepare, 10, 0, b), 10); e != nil {
+		t.Fatal("evicted message still tracked")
+	}
+}
@@ -10,8 +10,19 @@ import (
 
 // Start implements core.Engine.Start
 func (c *core) Start() error {
-	// Start a new round from last sequence + 1
+	wal, records, err := c.openWAL()
+	if err != nil {
+		return err
+	}
+	// The log is in place before the first round starts, so that nothing
+	// signed is left out of it and the messages signed before a restart are
+	// not contradicted
+	c.wal = wal
+	c.trackLoggedSent(records)
+	// Start a new round from last sequence + 1, then resume the view logged
+	// for it before a restart
 	c.startNewRound(common.Big0)
+	c.restoreWAL(records)
 
 	// Tests will handle events itself, so we have to make subscribeEvents()
 	// be able to call in test.
@@ -28,7 +39,7 @@ func (c *core) Stop() error {
 	c.unsubscribeEvents()
 
 	c.handlerWg.Wait()
-	return nil
+	return c.wal.close()
 }
 
 // Subscribe both internal and external events
@@ -39,6 +50,7 @@ func (c *core) subscribeEvents() {
 		consensus.MessageEvent{},
 		// internal events
 		backlogEvent{},
+		roundStateEvent{},
 	)
 	c.timeoutSub = c.backend.EventMux().Subscribe(
 		timeoutEvent{},
@@ -94,6 +106,8 @@ func (c *core) handleEvents() {
 					}
 					c.backend.Gossip(c.valSet, p)
 				}
+			case roundStateEvent:
+				ev.reply <- c.roundState()
 			}
 		case _, ok := <-c.timeoutSub.Chan():
 			if !ok {
@@ -133,6 +147,8 @@ func (c *core) handleMsg(payload []byte) error {
 		return lbft.ErrUnauthorizedAddress
 	}
 
+	c.checkEquivocation(msg, payload)
+
 	return c.handleCheckedMsg(msg, src)
 }
 
@@ -158,6 +174,8 @@ func (c *core) handleCheckedMsg(msg *Message, src lbft.Validator) error {
 	case MsgRoundChange:
 		err := c.handleRoundChange(msg, src)
 		return testBacklog(err)
+	case MsgEvidence:
+		return c.handleEvidence(msg, src)
 	default:
 		logs.Error("Invalid message", "msg", msg)
 	}
@@ -172,7 +190,7 @@ func (c *core) handleTimeoutMsg() {
 	if !c.waitingForRoundChange {
 		maxRound := c.roundChangeSet.MaxRound(c.valSet.F() + 1)
 		if maxRound != nil && maxRound.Cmp(c.current.Round()) > 0 {
-			c.sendRoundChange(maxRound)
+			c.sendRoundChange(maxRound, lbft.RoundChangeCatchUp)
 			return
 		}
 	}
@@ -182,6 +200,6 @@ func (c *core) handleTimeoutMsg() {
 		logs.Trace("round change timeout, catch up latest sequence", "number", lastProposal.Number().Uint64())
 		c.startNewRound(common.Big0)
 	} else {
-		c.sendNextRoundChange()
+		c.sendNextRoundChange(lbft.RoundChangeTimeout)
 	}
 }
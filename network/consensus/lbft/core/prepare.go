@@ -72,6 +72,7 @@ func (c *core) acceptPrepare(msg *Message, src lbft.Validator) error {
 		logs.Error("Failed to add PREPARE message to round state", "msg", msg, "err", err)
 		return err
 	}
+	c.persistReceived(msg)
 
 	return nil
 }
@@ -80,7 +80,7 @@ func (c *core) handlePreprepare(msg *Message, src lbft.Validator) error {
 			})
 		} else {
 			logs.Warn("Failed to verify proposal", "err", err, "duration", duration)
-			c.sendNextRoundChange()
+			c.sendNextRoundChange(lbft.RoundChangeInvalidProposal)
 		}
 		return err
 	}
@@ -96,7 +96,7 @@ func (c *core) handlePreprepare(msg *Message, src lbft.Validator) error {
 				c.sendCommit()
 			} else {
 				// Send round change
-				c.sendNextRoundChange()
+				c.sendNextRoundChange(lbft.RoundChangeLockMismatch)
 			}
 		} else {
 			// Either
@@ -12,19 +12,20 @@ import (
 )
 
 // sendNextRoundChange sends the ROUND CHANGE message with current round + 1
-func (c *core) sendNextRoundChange() {
+func (c *core) sendNextRoundChange(cause lbft.RoundChangeCause) {
 	cv := c.currentView()
-	c.sendRoundChange(new(big.Int).Add(cv.Round, common.Big1))
+	c.sendRoundChange(new(big.Int).Add(cv.Round, common.Big1), cause)
 }
 
 // sendRoundChange sends the ROUND CHANGE message with the given round
-func (c *core) sendRoundChange(round *big.Int) {
+func (c *core) sendRoundChange(round *big.Int, cause lbft.RoundChangeCause) {
 
 	cv := c.currentView()
 	if cv.Round.Cmp(round) >= 0 {
 		logs.Error("Cannot send out the round change", "current round", cv.Round, "target round", round)
 		return
 	}
+	c.roundChanged(cause)
 
 	c.catchUpRound(&consensus.View{
 		// The round number we'd like to transfer to.
@@ -78,11 +79,14 @@ func (c *core) handleRoundChange(msg *Message, src lbft.Validator) error {
 	// try to catch up the round number.
 	if c.waitingForRoundChange && num == c.valSet.F()+1 {
 		if cv.Round.Cmp(roundView.Round) < 0 {
-			c.sendRoundChange(roundView.Round)
+			c.sendRoundChange(roundView.Round, lbft.RoundChangeCatchUp)
 		}
 		return nil
 	} else if num == c.QuorumSize() && (c.waitingForRoundChange || cv.Round.Cmp(roundView.Round) < 0) {
 		// We've received 2f+1/Ceil(2N/3) ROUND CHANGE messages, start a new round immediately.
+		if !c.waitingForRoundChange {
+			c.roundChanged(lbft.RoundChangeQuorum)
+		}
 		c.startNewRound(roundView.Round)
 		return nil
 	} else if cv.Round.Cmp(roundView.Round) < 0 {
@@ -123,6 +127,17 @@ func (rcs *roundChangeSet) Add(r *big.Int, msg *Message) (int, error) {
 	return rcs.roundChanges[round].Size(), nil
 }
 
+// size returns the number of messages for a round.
+func (rcs *roundChangeSet) size(r *big.Int) int {
+	rcs.mu.Lock()
+	defer rcs.mu.Unlock()
+
+	if rms := rcs.roundChanges[r.Uint64()]; rms != nil {
+		return rms.Size()
+	}
+	return 0
+}
+
 // Clear deletes the messages with smaller round
 func (rcs *roundChangeSet) Clear(round *big.Int) {
 	rcs.mu.Lock()
@@ -152,3 +167,12 @@ func (rcs *roundChangeSet) MaxRound(num int) *big.Int {
 	}
 	return maxRound
 }
+
+// roundChanged reports the current round as abandoned to the backend.
+func (c *core) roundChanged(cause lbft.RoundChangeCause) {
+	var proposer common.Address
+	if p := c.valSet.GetProposer(); p != nil {
+		proposer = p.Address()
+	}
+	c.backend.RoundChanged(c.current.Sequence(), c.current.Round(), proposer, cause)
+}
@@ -15,6 +15,7 @@ const (
 	MsgPrepare
 	MsgCommit
 	MsgRoundChange
+	MsgEvidence
 )
 
 type Message struct {
@@ -23,7 +24,7 @@ type Message struct {
 	Address       common.Address
 	Signature     []byte
 	CommittedSeal []byte
-	HashSign      []byte // for commit to collect chainA hash sign
+	HashSigns     [][]byte // for commit to collect the hash sign of every transfer
 }
 
 // ==============================================
@@ -32,7 +33,7 @@ type Message struct {
 
 // EncodeRLP serializes m into the Ethereum RLP format.
 func (m *Message) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{m.Code, m.Msg, m.Address, m.Signature, m.CommittedSeal, m.HashSign})
+	return rlp.Encode(w, []interface{}{m.Code, m.Msg, m.Address, m.Signature, m.CommittedSeal, m.HashSigns})
 }
 
 // DecodeRLP implements rlp.Decoder, and load the consensus fields from a RLP stream.
@@ -43,14 +44,14 @@ func (m *Message) DecodeRLP(s *rlp.Stream) error {
 		Address       common.Address
 		Signature     []byte
 		CommittedSeal []byte
-		HashSign      []byte
+		HashSigns     [][]byte
 	}
 
 	if err := s.Decode(&msg); err != nil {
 		return err
 	}
 	m.Code, m.Msg, m.Address, m.Signature, m.CommittedSeal = msg.Code, msg.Msg, msg.Address, msg.Signature, msg.CommittedSeal
-	m.HashSign = msg.HashSign
+	m.HashSigns = msg.HashSigns
 	return nil
 }
 
@@ -94,7 +95,7 @@ func (m *Message) PayloadNoSig() ([]byte, error) {
 		Address:       m.Address,
 		Signature:     []byte{},
 		CommittedSeal: m.CommittedSeal,
-		HashSign:      m.HashSign,
+		HashSigns:     m.HashSigns,
 	})
 }
 
@@ -0,0 +1,394 @@
+package core
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/consensus"
+)
+
+// Kinds of write-ahead log records.
+const (
+	walState    uint64 = iota // round state after a transition
+	walSent                   // signed message of this validator, logged before it is sent
+	walReceived               // PREPARE or COMMIT accepted into the round state
+)
+
+// maxWALRecord bounds a single record, a proposal with its transfers included.
+const maxWALRecord = 16 * 1024 * 1024
+
+var (
+	errConflictingMessage = errors.New("message conflicts with one already sent in this view")
+	errCorruptWAL         = errors.New("corrupt write-ahead log record")
+)
+
+// walRecord is one entry of the consensus write-ahead log. Records are kept
+// for a single sequence: the log is truncated when a new one starts.
+type walRecord struct {
+	Kind     uint64
+	Sequence uint64
+	Payload  []byte
+}
+
+// walRoundState is the part of the round state needed to resume a view.
+type walRoundState struct {
+	Round      *big.Int
+	State      uint64
+	LockedHash common.Hash
+	Preprepare []byte // encoded PRE-PREPARE, empty if none was accepted
+}
+
+// wal appends records to a file, each framed by its length and CRC32. The
+// round state and the messages of this validator are synced before the append
+// returns, received messages are synced along with the next of them: losing
+// those only costs votes the other validators send again. A nil wal logs
+// nothing.
+type wal struct {
+	f        *os.File
+	sequence uint64 // sequence of the records in the file
+}
+
+// openWAL opens the log at path and returns the records it holds. A record
+// torn by a crash ends the log and is cut off.
+func openWAL(path string) (*wal, []*walRecord, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, size, err := readWAL(f)
+	if err != nil {
+		logs.Warn("lbft WAL %s: %v, dropping the records after offset %d", path, err, size)
+	}
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	w := &wal{f: f}
+	if len(records) > 0 {
+		w.sequence = records[len(records)-1].Sequence
+	}
+	return w, records, nil
+}
+
+// readWAL decodes the records of f and returns them with the size of the
+// valid prefix of the file.
+func readWAL(f *os.File) ([]*walRecord, int64, error) {
+	var (
+		records []*walRecord
+		size    int64
+		header  [8]byte
+		r       = bufio.NewReader(f)
+	)
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				return records, size, nil
+			}
+			return records, size, errCorruptWAL
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		if length > maxWALRecord {
+			return records, size, errCorruptWAL
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return records, size, errCorruptWAL
+		}
+		if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+			return records, size, errCorruptWAL
+		}
+		record := new(walRecord)
+		if err := rlp.DecodeBytes(data, record); err != nil {
+			return records, size, err
+		}
+		records = append(records, record)
+		size += int64(len(header) + len(data))
+	}
+}
+
+func (w *wal) append(kind uint64, sequence uint64, payload []byte) error {
+	if w == nil {
+		return nil
+	}
+	if sequence != w.sequence {
+		if err := w.reset(sequence); err != nil {
+			return err
+		}
+	}
+	data, err := rlp.EncodeToBytes(&walRecord{Kind: kind, Sequence: sequence, Payload: payload})
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(data))
+	copy(buf[8:], data)
+	if _, err := w.f.Write(buf); err != nil {
+		return err
+	}
+	if kind == walReceived {
+		return nil
+	}
+	return w.f.Sync()
+}
+
+// reset drops the records of the previous sequence.
+func (w *wal) reset(sequence uint64) error {
+	if w == nil || sequence == w.sequence {
+		return nil
+	}
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.sequence = sequence
+	return nil
+}
+
+func (w *wal) close() error {
+	if w == nil {
+		return nil
+	}
+	return w.f.Close()
+}
+
+// openWAL opens the configured write-ahead log. Without a path the node runs
+// without one and may equivocate after a crash.
+func (c *core) openWAL() (*wal, []*walRecord, error) {
+	if c.config.WALPath == "" {
+		logs.Warn("lbft WAL disabled, a restarted validator does not resume its view")
+		return nil, nil, nil
+	}
+	return openWAL(c.config.WALPath)
+}
+
+// persistState logs the current round state.
+func (c *core) persistState() {
+	if c.wal == nil || c.current == nil {
+		return
+	}
+	s := &walRoundState{
+		Round:      c.current.Round(),
+		State:      uint64(c.state),
+		LockedHash: c.current.GetLockedHash(),
+	}
+	if preprepare := c.current.Preprepare; preprepare != nil {
+		encoded, err := Encode(preprepare)
+		if err != nil {
+			logs.Error("encode lbft WAL preprepare: %v", err)
+			return
+		}
+		s.Preprepare = encoded
+	}
+	payload, err := rlp.EncodeToBytes(s)
+	if err != nil {
+		logs.Error("encode lbft WAL state: %v", err)
+		return
+	}
+	if err := c.wal.append(walState, c.current.Sequence().Uint64(), payload); err != nil {
+		logs.Error("write lbft WAL: %v", err)
+	}
+}
+
+// persistReceived logs a PREPARE or COMMIT accepted into the round state.
+func (c *core) persistReceived(msg *Message) {
+	if c.wal == nil || msg.Address == c.Address() {
+		return
+	}
+	payload, err := msg.Payload()
+	if err != nil {
+		return
+	}
+	if err := c.wal.append(walReceived, c.current.Sequence().Uint64(), payload); err != nil {
+		logs.Error("write lbft WAL: %v", err)
+	}
+}
+
+// sentPayload returns the payload already sent for the kind and view of msg,
+// or errConflictingMessage if the one sent votes for another digest.
+func (c *core) sentPayload(msg *Message) ([]byte, error) {
+	if msg.Code != MsgPreprepare && msg.Code != MsgPrepare && msg.Code != MsgCommit {
+		return nil, nil
+	}
+	msg.Address = c.Address()
+	m, err := messageDigest(msg, nil)
+	if err != nil {
+		return nil, nil
+	}
+	sent, ok := c.sent[sentKey(m)]
+	if !ok {
+		return nil, nil
+	}
+	if sent.digest != m.digest {
+		return nil, errConflictingMessage
+	}
+	return sent.payload, nil
+}
+
+// persistSent logs a signed message of this validator for the current
+// sequence before it is sent.
+func (c *core) persistSent(msg *Message, payload []byte) error {
+	if msg.Code != MsgPreprepare && msg.Code != MsgPrepare && msg.Code != MsgCommit {
+		return nil
+	}
+	m, err := messageDigest(msg, payload)
+	if err != nil {
+		return err
+	}
+	if c.current == nil || m.view.Sequence.Cmp(c.current.Sequence()) != 0 {
+		return nil
+	}
+	if err := c.wal.append(walSent, m.view.Sequence.Uint64(), payload); err != nil {
+		return err
+	}
+	c.trackSent(m)
+	return nil
+}
+
+// trackLoggedSent remembers the messages this validator signed according to
+// the log, so that none is contradicted once rounds start.
+func (c *core) trackLoggedSent(records []*walRecord) {
+	for _, record := range records {
+		if record.Kind != walSent {
+			continue
+		}
+		if m, err := signedDigest(record.Payload); err == nil && m.address == c.Address() {
+			c.trackSent(m)
+		}
+	}
+}
+
+func (c *core) trackSent(m *signedMessage) {
+	if c.sent == nil || c.sentSequence != m.view.Sequence.Uint64() {
+		c.sent = make(map[voteKey]*signedMessage)
+		c.sentSequence = m.view.Sequence.Uint64()
+	}
+	c.sent[sentKey(m)] = m
+}
+
+func sentKey(m *signedMessage) voteKey {
+	return voteKey{m.code, m.view.Sequence.Uint64(), m.view.Round.Uint64()}
+}
+
+// restoreWAL resumes the view logged for the current sequence, with its
+// locked proposal, PREPAREs and COMMITs, and sends again the messages this
+// validator signed in it.
+func (c *core) restoreWAL(records []*walRecord) {
+	sequence := c.current.Sequence().Uint64()
+	var (
+		state    *walRoundState
+		received [][]byte
+		sent     [][]byte
+	)
+	for _, record := range records {
+		if record.Sequence != sequence {
+			continue
+		}
+		switch record.Kind {
+		case walState:
+			s := new(walRoundState)
+			if err := rlp.DecodeBytes(record.Payload, s); err != nil {
+				logs.Warn("decode lbft WAL state: %v", err)
+				continue
+			}
+			state = s
+		case walSent:
+			m, err := signedDigest(record.Payload)
+			if err != nil || m.address != c.Address() {
+				continue
+			}
+			c.trackSent(m)
+			sent = append(sent, record.Payload)
+			received = append(received, record.Payload)
+		case walReceived:
+			received = append(received, record.Payload)
+		}
+	}
+	if state == nil || state.Round == nil {
+		return
+	}
+
+	var preprepare *consensus.Preprepare
+	if len(state.Preprepare) > 0 {
+		if err := rlp.DecodeBytes(state.Preprepare, &preprepare); err != nil {
+			logs.Warn("decode lbft WAL preprepare: %v", err)
+			preprepare = nil
+		}
+	}
+	if preprepare == nil && !EmptyHash(state.LockedHash) {
+		logs.Error("lbft WAL locks %s without its proposal, resuming unlocked", state.LockedHash.Hex())
+		state.LockedHash = common.Hash{}
+	}
+	if preprepare != nil {
+		// The bridge only signs transfers it verified since it started.
+		if _, err := c.backend.Verify(preprepare.Proposal); err != nil {
+			logs.Warn("restored proposal %s does not verify: %v", preprepare.Proposal.Hash().Hex(), err)
+		}
+	}
+
+	view := &consensus.View{
+		Sequence: new(big.Int).SetUint64(sequence),
+		Round:    new(big.Int).Set(state.Round),
+	}
+	_, lastProposer := c.backend.LastProposal()
+	c.valSet.CalcProposer(lastProposer, view.Round.Uint64())
+	c.roundChangeSet = newRoundChangeSet(c.valSet)
+	c.current = newRoundState(view, c.valSet, state.LockedHash, preprepare, nil)
+
+	if preprepare != nil {
+		digest := preprepare.Proposal.Hash()
+		for _, payload := range received {
+			msg := new(Message)
+			if err := msg.FromPayload(payload, c.validateFn); err != nil {
+				continue
+			}
+			m, err := messageDigest(msg, payload)
+			if err != nil || m.digest != digest || m.view.Cmp(view) != 0 {
+				continue
+			}
+			switch msg.Code {
+			case MsgPrepare:
+				c.current.Prepares.Add(msg)
+			case MsgCommit:
+				c.current.Commits.Add(msg)
+			}
+		}
+	}
+
+	// The block of a committed view was not inserted, or the sequence would
+	// be over: wait for the COMMITs again.
+	restored := State(state.State)
+	if restored.Cmp(StatePrepared) > 0 {
+		restored = StatePrepared
+	}
+	c.state = restored
+	c.waitingForRoundChange = false
+	logs.Info("lbft resumed sequence %d round %v in state %s from the WAL, locked %v, %d prepares, %d commits",
+		sequence, view.Round, restored, c.current.IsHashLocked(), c.current.Prepares.Size(), c.current.Commits.Size())
+
+	for _, payload := range sent {
+		if err := c.backend.Gossip(c.valSet, payload); err != nil {
+			logs.Warn("resend lbft message: %v", err)
+		}
+	}
+	c.newRoundChangeTimer(false)
+	c.persistState()
+	if c.state == StatePrepared && c.current.Commits.Size() >= c.QuorumSize() {
+		c.commit()
+	}
+}
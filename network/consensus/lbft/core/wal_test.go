@@ -0,0 +1,224 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"land-bridge/network/consensus"
+)
+
+func newTestWAL(t *testing.T) (string, *wal) {
+	dir, err := ioutil.TempDir("", "lbft-wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "lbft.wal")
+	w, records, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Fatalf("new log holds %d records", len(records))
+	}
+	return path, w
+}
+
+func reopenWAL(t *testing.T, path string, w *wal) (*wal, []*walRecord) {
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	w, records, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, records
+}
+
+func checkRecords(t *testing.T, records []*walRecord, want ...*walRecord) {
+	t.Helper()
+	if len(records) != len(want) {
+		t.Fatalf("%d records, want %d", len(records), len(want))
+	}
+	for i := range want {
+		if records[i].Kind != want[i].Kind || records[i].Sequence != want[i].Sequence || !bytes.Equal(records[i].Payload, want[i].Payload) {
+			t.Errorf("record %d is %+v, want %+v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestWALReplay(t *testing.T) {
+	path, w := newTestWAL(t)
+	want := []*walRecord{
+		{Kind: walState, Sequence: 5, Payload: []byte{1}},
+		{Kind: walSent, Sequence: 5, Payload: []byte{2, 2}},
+		{Kind: walReceived, Sequence: 5, Payload: []byte{3, 3, 3}},
+	}
+	for _, r := range want {
+		if err := w.append(r.Kind, r.Sequence, r.Payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, records := reopenWAL(t, path, w)
+	checkRecords(t, records, want...)
+
+	// the records go on after a restart, in the same sequence
+	more := &walRecord{Kind: walState, Sequence: 5, Payload: []byte{4}}
+	if err := w.append(more.Kind, more.Sequence, more.Payload); err != nil {
+		t.Fatal(err)
+	}
+	w, records = reopenWAL(t, path, w)
+	checkRecords(t, records, append(want, more)...)
+	w.close()
+}
+
+func TestWALNewSequenceTruncates(t *testing.T) {
+	path, w := newTestWAL(t)
+	if err := w.append(walState, 5, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.append(walSent, 5, []byte{2}); err != nil {
+		t.Fatal(err)
+	}
+	next := &walRecord{Kind: walState, Sequence: 6, Payload: []byte{3}}
+	if err := w.append(next.Kind, next.Sequence, next.Payload); err != nil {
+		t.Fatal(err)
+	}
+	w, records := reopenWAL(t, path, w)
+	checkRecords(t, records, next)
+	if w.sequence != 6 {
+		t.Errorf("sequence %d after reopening, want 6", w.sequence)
+	}
+	w.close()
+}
+
+func TestWALTornRecord(t *testing.T) {
+	path, w := newTestWAL(t)
+	first := &walRecord{Kind: walState, Sequence: 7, Payload: []byte{1, 2, 3}}
+	if err := w.append(first.Kind, first.Sequence, first.Payload); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := info.Size()
+	if err := w.append(walSent, 7, bytes.Repeat([]byte{9}, 64)); err != nil {
+		t.Fatal(err)
+	}
+	w.close()
+
+	// a crash in the middle of the second append
+	if err := os.Truncate(path, valid+20); err != nil {
+		t.Fatal(err)
+	}
+	w, records, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, records, first)
+	if info, err = os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != valid {
+		t.Errorf("log is %d bytes after the torn record is cut off, want %d", info.Size(), valid)
+	}
+
+	// appends resume right after the last valid record
+	second := &walRecord{Kind: walReceived, Sequence: 7, Payload: []byte{4}}
+	if err := w.append(second.Kind, second.Sequence, second.Payload); err != nil {
+		t.Fatal(err)
+	}
+	w, records = reopenWAL(t, path, w)
+	checkRecords(t, records, first, second)
+	w.close()
+}
+
+func TestWALCorruptRecord(t *testing.T) {
+	path, w := newTestWAL(t)
+	first := &walRecord{Kind: walState, Sequence: 8, Payload: []byte{1}}
+	if err := w.append(first.Kind, first.Sequence, first.Payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.append(walSent, 8, []byte{2, 2, 2, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.append(walReceived, 8, []byte{3}); err != nil {
+		t.Fatal(err)
+	}
+	w.close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[bytes.Index(data, []byte{2, 2, 2, 2})] ^= 0xff
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, records, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecords(t, records, first)
+	w.close()
+}
+
+func signedPrepare(t *testing.T, key *ecdsa.PrivateKey, sequence, round int64, digest common.Hash) (*Message, []byte) {
+	subject, err := Encode(&consensus.Subject{
+		View:   &consensus.View{Sequence: big.NewInt(sequence), Round: big.NewInt(round)},
+		Digest: digest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &Message{Code: MsgPrepare, Msg: subject, Address: crypto.PubkeyToAddress(key.PublicKey)}
+	data, err := msg.PayloadNoSig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Signature, err = crypto.Sign(crypto.Keccak256(data), key); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := msg.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg, payload
+}
+
+func TestTrackLoggedSent(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	c := &core{address: crypto.PubkeyToAddress(key.PublicKey)}
+
+	_, own := signedPrepare(t, key, 9, 1, common.HexToHash("0x01"))
+	_, foreign := signedPrepare(t, other, 9, 2, common.HexToHash("0x02"))
+	c.trackLoggedSent([]*walRecord{
+		{Kind: walSent, Sequence: 9, Payload: own},
+		{Kind: walReceived, Sequence: 9, Payload: foreign},
+		{Kind: walSent, Sequence: 9, Payload: foreign},
+	})
+
+	same, _ := signedPrepare(t, key, 9, 1, common.HexToHash("0x01"))
+	if payload, err := c.sentPayload(same); err != nil || !bytes.Equal(payload, own) {
+		t.Errorf("PREPARE logged before the restart not sent again: %x, %v", payload, err)
+	}
+	conflicting, _ := signedPrepare(t, key, 9, 1, common.HexToHash("0x03"))
+	if _, err := c.sentPayload(conflicting); err != errConflictingMessage {
+		t.Errorf("conflicting PREPARE: err %v, want %v", err, errConflictingMessage)
+	}
+	// only the messages of this validator are tracked
+	later, _ := signedPrepare(t, key, 9, 2, common.HexToHash("0x03"))
+	if payload, err := c.sentPayload(later); err != nil || payload != nil {
+		t.Errorf("PREPARE of another round: %x, %v", payload, err)
+	}
+}
@@ -57,6 +57,14 @@ type ValidatorSet interface {
 	F() int
 	// Policy Get proposer policy
 	Policy() ProposerPolicy
+	// Weight Get the proposer weight of a validator, 0 if it is not one
+	Weight(address common.Address) uint64
+	// SetWeight Set the proposer weight of a validator
+	SetWeight(address common.Address, weight uint64) bool
+	// Weights Get the proposer weights differing from the default one
+	Weights() map[common.Address]uint64
+	// SetParent Set the block the set is taken at, which seeds the proposer selection
+	SetParent(number uint64, hash common.Hash)
 }
 
 // ----------------------------------------------------------------------------
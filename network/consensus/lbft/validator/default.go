@@ -17,12 +17,15 @@
 package validator
 
 import (
+	"encoding/binary"
 	"math"
+	"math/big"
 	"reflect"
 	"sort"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 
 	"land-bridge/network/consensus/lbft"
 )
@@ -48,6 +51,10 @@ type defaultSet struct {
 	proposer    lbft.Validator
 	validatorMu sync.RWMutex
 	selector    lbft.ProposalSelector
+
+	weights      map[common.Address]uint64 // weights differing from lbft.DefaultWeight
+	parentNumber uint64
+	parentHash   common.Hash
 }
 
 func newDefaultSet(addrs []common.Address, policy lbft.ProposerPolicy) *defaultSet {
@@ -65,9 +72,16 @@ func newDefaultSet(addrs []common.Address, policy lbft.ProposerPolicy) *defaultS
 	if valSet.Size() > 0 {
 		valSet.proposer = valSet.GetByIndex(0)
 	}
-	valSet.selector = roundRobinProposer
-	if policy == lbft.Sticky {
+	valSet.weights = make(map[common.Address]uint64)
+	switch policy {
+	case lbft.Sticky:
 		valSet.selector = stickyProposer
+	case lbft.WeightedRoundRobin:
+		valSet.selector = valSet.weightedRoundRobinProposer
+	case lbft.WeightedRandom:
+		valSet.selector = valSet.weightedRandomProposer
+	default:
+		valSet.selector = roundRobinProposer
 	}
 
 	return valSet
@@ -158,6 +172,118 @@ func stickyProposer(valSet lbft.ValidatorSet, proposer common.Address, round uin
 	return valSet.GetByIndex(pick)
 }
 
+// weightedRoundRobinProposer walks a smooth weighted round robin schedule,
+// in which every validator appears as many times as its weight, spread out.
+// The position in the schedule advances with the height and the round.
+func (valSet *defaultSet) weightedRoundRobinProposer(_ lbft.ValidatorSet, _ common.Address, round uint64) lbft.Validator {
+	total := valSet.totalWeight()
+	if total == 0 {
+		return nil
+	}
+	current := make([]int64, len(valSet.validators))
+	var pick int
+	for step := (valSet.parentNumber + round) % total; ; step-- {
+		pick = 0
+		for i, val := range valSet.validators {
+			current[i] += int64(valSet.weightOf(val.Address()))
+			if current[i] > current[pick] {
+				pick = i
+			}
+		}
+		current[pick] -= int64(total)
+		if step == 0 {
+			break
+		}
+	}
+	return valSet.validators[pick]
+}
+
+// weightedRandomProposer picks a validator with a probability proportional to
+// its weight, from a hash of the parent block and the round that every
+// validator computes alike.
+func (valSet *defaultSet) weightedRandomProposer(_ lbft.ValidatorSet, _ common.Address, round uint64) lbft.Validator {
+	total := valSet.totalWeight()
+	if total == 0 {
+		return nil
+	}
+	var r [8]byte
+	binary.BigEndian.PutUint64(r[:], round)
+	seed := new(big.Int).SetBytes(crypto.Keccak256(valSet.parentHash[:], r[:]))
+	target := seed.Mod(seed, new(big.Int).SetUint64(total)).Uint64()
+	for _, val := range valSet.validators {
+		weight := valSet.weightOf(val.Address())
+		if target < weight {
+			return val
+		}
+		target -= weight
+	}
+	return nil
+}
+
+func (valSet *defaultSet) totalWeight() uint64 {
+	var total uint64
+	for _, val := range valSet.validators {
+		total += valSet.weightOf(val.Address())
+	}
+	return total
+}
+
+func (valSet *defaultSet) weightOf(address common.Address) uint64 {
+	if weight, ok := valSet.weights[address]; ok {
+		return weight
+	}
+	return lbft.DefaultWeight
+}
+
+// has reports whether address is a validator, with validatorMu held.
+func (valSet *defaultSet) has(address common.Address) bool {
+	for _, v := range valSet.validators {
+		if v.Address() == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (valSet *defaultSet) Weight(address common.Address) uint64 {
+	valSet.validatorMu.RLock()
+	defer valSet.validatorMu.RUnlock()
+	if !valSet.has(address) {
+		return 0
+	}
+	return valSet.weightOf(address)
+}
+
+func (valSet *defaultSet) SetWeight(address common.Address, weight uint64) bool {
+	valSet.validatorMu.Lock()
+	defer valSet.validatorMu.Unlock()
+	if weight == 0 || !valSet.has(address) {
+		return false
+	}
+	if weight == lbft.DefaultWeight {
+		delete(valSet.weights, address)
+	} else {
+		valSet.weights[address] = weight
+	}
+	return true
+}
+
+func (valSet *defaultSet) Weights() map[common.Address]uint64 {
+	valSet.validatorMu.RLock()
+	defer valSet.validatorMu.RUnlock()
+	weights := make(map[common.Address]uint64, len(valSet.weights))
+	for address, weight := range valSet.weights {
+		weights[address] = weight
+	}
+	return weights
+}
+
+func (valSet *defaultSet) SetParent(number uint64, hash common.Hash) {
+	valSet.validatorMu.Lock()
+	defer valSet.validatorMu.Unlock()
+	valSet.parentNumber, valSet.parentHash = number, hash
+}
+
 func (valSet *defaultSet) AddValidator(address common.Address) bool {
 	valSet.validatorMu.Lock()
 	defer valSet.validatorMu.Unlock()
@@ -180,6 +306,7 @@ func (valSet *defaultSet) RemoveValidator(address common.Address) bool {
 	for i, v := range valSet.validators {
 		if v.Address() == address {
 			valSet.validators = append(valSet.validators[:i], valSet.validators[i+1:]...)
+			delete(valSet.weights, address)
 			return true
 		}
 	}
@@ -194,7 +321,12 @@ func (valSet *defaultSet) Copy() lbft.ValidatorSet {
 	for _, v := range valSet.validators {
 		addresses = append(addresses, v.Address())
 	}
-	return NewSet(addresses, valSet.policy)
+	cpy := newDefaultSet(addresses, valSet.policy)
+	for address, weight := range valSet.weights {
+		cpy.weights[address] = weight
+	}
+	cpy.parentNumber, cpy.parentHash = valSet.parentNumber, valSet.parentHash
+	return cpy
 }
 
 func (valSet *defaultSet) F() int { return int(math.Ceil(float64(valSet.Size())/3)) - 1 }
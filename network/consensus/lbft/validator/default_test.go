@@ -0,0 +1,121 @@
+package validator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"land-bridge/network/consensus/lbft"
+)
+
+var (
+	addrA = common.HexToAddress("0x0a")
+	addrB = common.HexToAddress("0x0b")
+	addrC = common.HexToAddress("0x0c")
+)
+
+func weightedSet(t *testing.T, policy lbft.ProposerPolicy, weights map[common.Address]uint64) lbft.ValidatorSet {
+	valSet := NewSet([]common.Address{addrC, addrA, addrB}, policy)
+	for addr, weight := range weights {
+		if !valSet.SetWeight(addr, weight) {
+			t.Fatalf("weight %d of %s refused", weight, addr.Hex())
+		}
+	}
+	return valSet
+}
+
+func proposerAt(valSet lbft.ValidatorSet, number uint64, round uint64) common.Address {
+	valSet.SetParent(number, common.BigToHash(new(big.Int).SetUint64(number)))
+	valSet.CalcProposer(common.Address{}, round)
+	return valSet.GetProposer().Address()
+}
+
+func TestWeightedRoundRobinProposer(t *testing.T) {
+	valSet := weightedSet(t, lbft.WeightedRoundRobin, map[common.Address]uint64{addrA: 3})
+
+	// every validator proposes as often as its weight in each cycle
+	counts := make(map[common.Address]int)
+	var schedule []common.Address
+	for number := uint64(0); number < 10; number++ {
+		proposer := proposerAt(valSet, number, 0)
+		counts[proposer]++
+		schedule = append(schedule, proposer)
+	}
+	if counts[addrA] != 6 || counts[addrB] != 2 || counts[addrC] != 2 {
+		t.Fatalf("proposals per validator %v, want 6 2 2", counts)
+	}
+	// the heavier validator is spread over the cycle
+	for i := 2; i < len(schedule); i++ {
+		if schedule[i] == addrA && schedule[i-1] == addrA && schedule[i-2] == addrA {
+			t.Fatalf("%s proposes three blocks in a row: %v", addrA.Hex(), schedule)
+		}
+	}
+	// a round change moves to the next slot of the schedule
+	for number := uint64(0); number < 5; number++ {
+		if got, want := proposerAt(valSet, number, 1), schedule[number+1]; got != want {
+			t.Errorf("round 1 at %d: proposer %s, want %s", number, got.Hex(), want.Hex())
+		}
+	}
+}
+
+func TestWeightedRandomProposer(t *testing.T) {
+	valSet := weightedSet(t, lbft.WeightedRandom, map[common.Address]uint64{addrA: 8})
+
+	counts := make(map[common.Address]int)
+	const rounds = 10000
+	for round := uint64(0); round < rounds; round++ {
+		counts[proposerAt(valSet, 7, round)]++
+	}
+	// addrA holds 8 of the 10 weight units
+	if share := float64(counts[addrA]) / rounds; share < 0.77 || share > 0.83 {
+		t.Errorf("%s proposes %.3f of the rounds, want about 0.8", addrA.Hex(), share)
+	}
+	if counts[addrB] == 0 || counts[addrC] == 0 {
+		t.Errorf("a validator never proposes: %v", counts)
+	}
+}
+
+func TestWeightedProposerCopy(t *testing.T) {
+	for _, policy := range []lbft.ProposerPolicy{lbft.WeightedRoundRobin, lbft.WeightedRandom} {
+		valSet := weightedSet(t, policy, map[common.Address]uint64{addrA: 3, addrC: 2})
+		valSet.SetParent(41, common.HexToHash("0x41"))
+		cpy := valSet.Copy()
+
+		// every validator computes the same proposer from its own copy
+		for round := uint64(0); round < 20; round++ {
+			valSet.CalcProposer(addrB, round)
+			cpy.CalcProposer(common.Address{}, round)
+			if got, want := cpy.GetProposer().Address(), valSet.GetProposer().Address(); got != want {
+				t.Fatalf("policy %d round %d: copy picks %s, want %s", policy, round, got.Hex(), want.Hex())
+			}
+		}
+
+		// the copy does not share the weights
+		cpy.SetWeight(addrA, 1)
+		if valSet.Weight(addrA) != 3 || cpy.Weight(addrA) != 1 {
+			t.Fatalf("policy %d: weights %d and %d after changing the copy", policy, valSet.Weight(addrA), cpy.Weight(addrA))
+		}
+	}
+}
+
+func TestSetWeight(t *testing.T) {
+	valSet := weightedSet(t, lbft.WeightedRoundRobin, nil)
+	if valSet.SetWeight(addrA, 0) {
+		t.Error("weight 0 accepted")
+	}
+	if valSet.SetWeight(common.HexToAddress("0x0d"), 2) {
+		t.Error("weight of a non-validator accepted")
+	}
+	if !valSet.SetWeight(addrA, 2) || valSet.Weight(addrA) != 2 {
+		t.Fatal("weight 2 not set")
+	}
+	if !valSet.SetWeight(addrA, lbft.DefaultWeight) || len(valSet.Weights()) != 0 {
+		t.Errorf("default weight kept apart: %v", valSet.Weights())
+	}
+	valSet.SetWeight(addrB, 4)
+	valSet.RemoveValidator(addrB)
+	if valSet.Weight(addrB) != 0 || len(valSet.Weights()) != 0 {
+		t.Errorf("weight of a removed validator kept: %v", valSet.Weights())
+	}
+}
@@ -26,11 +26,11 @@ type Proposal interface {
 
 	String() string
 
-	// TxHash for get txhash sign
-	TxHash() common.Hash
+	// TxHashes returns the source transaction hash of every transfer
+	TxHashes() []common.Hash
 
-	// GetTxParam For sign
-	GetTxParam() bridge.TxParam
+	// GetTxParams returns the parameters to sign for every transfer, in the order of TxHashes
+	GetTxParams() []bridge.TxParam
 }
 
 type Request struct {
@@ -3,6 +3,7 @@ package network
 import (
 	"crypto/ecdsa"
 	"fmt"
+	"net"
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -25,6 +26,26 @@ var (
 // SetNodeConfig applies node-related command line flags to the config.
 func SetNodeConfig(ctx *cli.Context, cfg *node.Config, conf *conf.Config) {
 	SetP2PConfig(ctx, &cfg.P2P, conf)
+	setRPC(cfg, conf)
+}
+
+func setRPC(cfg *node.Config, conf *conf.Config) {
+	rc := conf.RPC
+	if rc == nil {
+		return
+	}
+	switch {
+	case rc.NoIPC:
+		cfg.IPCPath = ""
+	case rc.IPCPath != "":
+		cfg.IPCPath = rc.IPCPath
+	}
+	if rc.HTTPAddr != "" {
+		cfg.HTTPHost = rc.HTTPAddr
+	}
+	cfg.HTTPPort = int(rc.HTTPPort)
+	cfg.HTTPAuthToken = rc.Token
+	cfg.WSEnabled = rc.WS
 }
 
 func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config, conf *conf.Config) {
@@ -53,6 +74,10 @@ func setListenAddress(cfg *p2p.Config, conf *conf.Config) {
 		cfg.ListenAddr = fmt.Sprintf("%s:%d", conf.LinQConfig.Addr, conf.LinQConfig.Port)
 		logs.Info("P2P addr set :", cfg.ListenAddr)
 	}
+	cfg.NoDiscovery = conf.LinQConfig.NoDiscovery
+	if conf.LinQConfig.ExtIP != "" {
+		cfg.ExternalIP = net.ParseIP(conf.LinQConfig.ExtIP)
+	}
 }
 
 func setBootstrapNodes(cfg *p2p.Config, conf *conf.Config) {
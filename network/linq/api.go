@@ -0,0 +1,117 @@
+package linq
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"land-bridge/network/utils"
+)
+
+var errUnknownBlock = errors.New("unknown block")
+
+// APIs returns the RPC APIs of the linq service and of its consensus engine.
+func (lq *LinQ) APIs() []rpc.API {
+	apis := []rpc.API{{
+		Namespace: "linq",
+		Version:   "1.0",
+		Service:   &API{lq},
+	}}
+	if engine, ok := lq.engine.(interface{ APIs() []rpc.API }); ok {
+		apis = append(apis, engine.APIs()...)
+	}
+	return apis
+}
+
+// API exposes the LinQ chain, the pending transfers and the sync status.
+type API struct {
+	lq *LinQ
+}
+
+// BlockNumber returns the number of the head block.
+func (api *API) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.lq.blockStore.CurrentBlock().NumberU64())
+}
+
+// GetBlockByNumber returns the block with the given number, the head block for
+// "latest" or "pending".
+func (api *API) GetBlockByNumber(number rpc.BlockNumber) (*utils.Block, error) {
+	var block *utils.Block
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		block = api.lq.blockStore.CurrentBlock()
+	case rpc.EarliestBlockNumber:
+		block = api.lq.blockStore.GetBlockByNumber(0)
+	default:
+		if number < 0 {
+			return nil, errUnknownBlock
+		}
+		block = api.lq.blockStore.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, errUnknownBlock
+	}
+	return block, nil
+}
+
+// GetBlockByHash returns the block with the given hash.
+func (api *API) GetBlockByHash(hash common.Hash) (*utils.Block, error) {
+	block := api.lq.blockStore.GetBlockByHash(hash)
+	if block == nil {
+		return nil, errUnknownBlock
+	}
+	return block, nil
+}
+
+// PendingTransfer is a transfer of the pool waiting for a block.
+type PendingTransfer struct {
+	Hash       string         `json:"hash"`
+	SrcChainID uint64         `json:"srcChainId"`
+	DstChainID uint64         `json:"dstChainId"`
+	Fee        *hexutil.Big   `json:"fee"`
+	Time       hexutil.Uint64 `json:"time"`
+}
+
+// PendingTransfers returns the transfers of the pool by destination chain, in
+// the order they would be included.
+func (api *API) PendingTransfers() map[hexutil.Uint64][]*PendingTransfer {
+	content := api.lq.worker.pool.Content()
+	pending := make(map[hexutil.Uint64][]*PendingTransfer, len(content))
+	for chainID, txs := range content {
+		list := make([]*PendingTransfer, 0, len(txs))
+		for _, tx := range txs {
+			fee := new(big.Int)
+			if tx.W.FeeAmount != nil {
+				fee.Set(&tx.W.FeeAmount.Int)
+			}
+			list = append(list, &PendingTransfer{
+				Hash:       "0x" + strings.TrimPrefix(tx.W.Hash, "0x"),
+				SrcChainID: tx.W.SrcChainID,
+				DstChainID: tx.W.DstChainID,
+				Fee:        (*hexutil.Big)(fee),
+				Time:       hexutil.Uint64(tx.W.Time),
+			})
+		}
+		pending[hexutil.Uint64(chainID)] = list
+	}
+	return pending
+}
+
+// Syncing returns false when the node is not synchronising, and the sync
+// progress otherwise.
+func (api *API) Syncing() (interface{}, error) {
+	d := api.lq.handler.downloader
+	if !d.Synchronising() {
+		return false, nil
+	}
+	progress := d.Progress()
+	return map[string]interface{}{
+		"startingBlock": hexutil.Uint64(progress.StartingBlock),
+		"currentBlock":  hexutil.Uint64(progress.CurrentBlock),
+		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
+	}, nil
+}
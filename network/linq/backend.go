@@ -1,12 +1,19 @@
 package linq
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/event"
 
+	"land-bridge/conf"
 	"land-bridge/network/bridge"
 	"land-bridge/network/consensus"
 	"land-bridge/network/consensus/lbft"
@@ -14,6 +21,17 @@ import (
 	"land-bridge/network/node"
 	"land-bridge/network/p2p"
 	"land-bridge/network/p2p/enode"
+	"land-bridge/network/p2p/enr"
+	"land-bridge/network/storage"
+)
+
+const (
+	// lbftWALFile is the consensus write-ahead log in the instance directory.
+	lbftWALFile = "lbft.wal"
+
+	// discmixTimeout is how long the dial candidates wait for a discovered
+	// node before taking one from any source.
+	discmixTimeout = 100 * time.Millisecond
 )
 
 // lbft consensus Protocol variables are optionally set in addition to the "eth" protocol variables (eth/protocol.go).
@@ -34,21 +52,43 @@ type LinQ struct {
 	worker     *worker
 
 	handler           *handler
-	ethDialCandidates enode.Iterator
+	validator         common.Address
+	ethDialCandidates *enode.FairMix // nodes of the network found by the discovery
 	p2pServer         *p2p.Server
 
 	lock sync.RWMutex
 }
 
-func New(stack *node.Node, privStr string) (*LinQ, error) {
+func New(stack *node.Node, privStr string, lc *conf.LinQConfig, cc *conf.ConsensusConfig) (*LinQ, error) {
 	lq := &LinQ{
-		p2pServer:  stack.Server(),
-		blockStore: NewLinQStore(stack.GetDB(), stack.Pool),
-		bridge:     stack.Bridge,
-		eventMux:   stack.EventMux(),
+		p2pServer:         stack.Server(),
+		validator:         common.HexToAddress(privStr),
+		ethDialCandidates: enode.NewFairMix(discmixTimeout),
+		blockStore:        NewLinQStore(stack.GetDB(), stack.ChainStore, stack.Pool),
+		bridge:            stack.Bridge,
+		eventMux:          stack.EventMux(),
 	}
 
-	lq.engine = CreateConsensusEngine(stack)
+	config, err := consensusConfig(stack.ChainStore, cc)
+	if err != nil {
+		return nil, err
+	}
+	if cp := lc.Checkpoint; cp != nil && cp.Hash != "" && cp.Number%config.Epoch != 0 {
+		return nil, fmt.Errorf("checkpoint %d is not an epoch block, a multiple of %d", cp.Number, config.Epoch)
+	}
+	if lc.Checkpoint != nil && config.ProposerPolicy.Weighted() {
+		return nil, errors.New("checkpoint sync is not supported under the weighted proposer policies")
+	}
+	if config.WALPath = stack.ResolvePath(lbftWALFile); config.WALPath != "" {
+		if err := os.MkdirAll(filepath.Dir(config.WALPath), 0700); err != nil {
+			return nil, err
+		}
+	}
+	lq.engine = CreateConsensusEngine(stack, config)
+	reputation := newReputation(lq.p2pServer, time.Duration(lc.BanTime)*time.Second)
+	if err := lq.trackValidators(stack.ChainStore, lc, reputation); err != nil {
+		return nil, err
+	}
 
 	lbftProtocol := lq.engine.Protocol()
 	// set the linq specific consensus devp2p subprotocol, eth subprotocol remains set to protocolName as in upstream geth.
@@ -56,16 +96,18 @@ func New(stack *node.Node, privStr string) (*LinQ, error) {
 	lbftConsensusProtocolVersions = lbftProtocol.Versions
 	lbftConsensusProtocolLengths = lbftProtocol.Lengths
 
-	var err error
-	lq.handler, err = newHandler(lq.engine, lq.blockStore, lq.eventMux)
+	lq.handler, err = newHandler(lq.engine, lq.blockStore, stack.Bridge, lq.eventMux, lc.NetworkID, config.Params().Hash(), config.Forks(), newTrustedCheckpoint(lc.Checkpoint), reputation)
 	if err != nil {
 		return nil, err
 	}
 
-	lq.worker = newWorker(lq.blockStore, stack.Bridge, stack.Pool, stack.EventMux(), lq.engine, common.HexToAddress(privStr))
-	lq.blockStore.SetHeadCh(lq.worker.chainHeadCh)
+	stack.Bridge.SetSignatureRequester(lq.handler.requestSignatures)
+
+	lq.worker = newWorker(lq.blockStore, stack.Bridge, stack.Pool, stack.EventMux(), lq.engine, lq.validator)
+	lq.blockStore.SetHeadCh(lq.worker.chainHeadCh, lq.worker.exitCh)
 
 	stack.RegisterProtocols(lq.Protocols())
+	stack.RegisterAPIs(lq.APIs())
 	stack.RegisterLifecycle(lq)
 
 	return lq, nil
@@ -73,19 +115,32 @@ func New(stack *node.Node, privStr string) (*LinQ, error) {
 
 func (lq *LinQ) Start() error {
 	logs.Info("linq work start")
+	lq.setupDiscovery()
 	go lq.worker.start()
+	lq.bridge.Start()
 	maxPeers := lq.p2pServer.MaxPeers
 	go lq.handler.Start(maxPeers)
 	return nil
 }
 
 func (lq *LinQ) Stop() error {
-	// Stop all the peer-related stuff first.
-	lq.ethDialCandidates.Close()
+	return lq.Shutdown(context.Background())
+}
+
+// Shutdown stops the service in order: the worker stops taking transfers from
+// the pool and the consensus engine halts, the bridge drains the submissions
+// in flight until ctx is done, block insertion stops, and finally the protocol
+// handler drops its peers.
+func (lq *LinQ) Shutdown(ctx context.Context) error {
+	lq.worker.stop()
+	err := lq.bridge.Shutdown(ctx)
+	lq.blockStore.Stop()
 
+	lq.ethDialCandidates.Close()
 	lq.handler.Stop()
+	logs.Info("linq service stopped, %d transfers left in the pool", lq.worker.pool.Len())
 
-	return nil
+	return err
 }
 
 // Protocols returns all the currently configured
@@ -95,11 +150,78 @@ func (lq *LinQ) Protocols() []p2p.Protocol {
 
 	//consensus Protocol
 	lbftprotos := ConsensusProtocols((*linqHandler)(lq.handler))
+	for i := range lbftprotos {
+		lbftprotos[i].Attributes = []enr.Entry{lq.currentENREntry()}
+		lbftprotos[i].DialCandidates = lq.ethDialCandidates
+	}
 	protos = append(protos, lbftprotos...)
 
 	return protos
 }
 
-func CreateConsensusEngine(stack *node.Node) consensus.Engine {
-	return backend.New(lbft.DefaultConfig, stack.GetNodeKey(), stack.GetDB(), stack.Bridge, stack.Pool)
+func CreateConsensusEngine(stack *node.Node, config *lbft.Config) consensus.Engine {
+	return backend.New(config, stack.GetNodeKey(), stack.ChainStore, stack.Bridge, stack.Pool)
+}
+
+// trackValidators follows the validator set, starting with the one of the
+// genesis block until the engine reports that of the head. The validators are
+// never banned, and with permissioned peering they are, with the observers,
+// the only peers admitted.
+func (lq *LinQ) trackValidators(chainStore storage.ChainStore, lc *conf.LinQConfig, reputation *reputation) error {
+	extra, err := chainStore.ReadBlockByNumber(0).LBFTBlockExtra()
+	if err != nil {
+		return fmt.Errorf("genesis extra data: %v", err)
+	}
+	reputation.setValidators(extra.Validators)
+
+	var perms *permissions
+	if lc.Permissioned {
+		addrs := make([]common.Address, 0, len(lc.Observers))
+		for _, observer := range lc.Observers {
+			addrs = append(addrs, common.HexToAddress(observer))
+		}
+		perms = newPermissions(lq.p2pServer, addrs, extra.Validators)
+		logs.Info("permissioned peering enabled with %d validators and %d observers", len(extra.Validators), len(addrs))
+	}
+
+	engine, ok := lq.engine.(consensus.LBFT)
+	if !ok {
+		if perms != nil {
+			return errors.New("permissioned peering needs the LBFT engine")
+		}
+		return nil
+	}
+	engine.SetValidatorListener(func(validators []common.Address) {
+		reputation.setValidators(validators)
+		if perms != nil {
+			perms.setValidators(validators)
+		}
+	})
+	return nil
+}
+
+// consensusConfig builds the LBFT configuration from the node configuration.
+// The network-wide parameters committed in the genesis block take precedence,
+// and configuring different ones is an error.
+func consensusConfig(chainStore storage.ChainStore, cc *conf.ConsensusConfig) (*lbft.Config, error) {
+	config, err := lbft.NewConfig(cc)
+	if err != nil {
+		return nil, err
+	}
+	genesis := chainStore.ReadBlockByNumber(0)
+	if genesis == nil {
+		return nil, errors.New("chain store is empty, init the genesis block first")
+	}
+	extra, err := genesis.LBFTBlockExtra()
+	if err != nil {
+		return nil, fmt.Errorf("genesis extra data: %v", err)
+	}
+	if extra.Consensus == nil {
+		logs.Warn("the genesis block does not commit to consensus parameters, using the configured ones")
+		return config, nil
+	}
+	if err := config.ApplyGenesis(cc, extra.Consensus); err != nil {
+		return nil, err
+	}
+	return config, nil
 }
@@ -0,0 +1,167 @@
+package linq
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+
+	"land-bridge/conf"
+	"land-bridge/network/consensus"
+	"land-bridge/network/utils"
+)
+
+// checkpointTimeout is the time allowed to a peer to serve a checkpoint.
+const checkpointTimeout = 10 * time.Second
+
+var (
+	errCheckpointTimeout  = errors.New("checkpoint request timed out")
+	errCheckpointUnknown  = errors.New("peer does not have the checkpoint")
+	errCheckpointMismatch = errors.New("checkpoint block mismatch")
+	errPeerBehind         = errors.New("peer is behind the checkpoint")
+	errCheckpointCanceled = errors.New("checkpoint sync canceled")
+)
+
+// checkpointEngine is implemented by the consensus engines able to start a
+// chain from a checkpoint block instead of the genesis block.
+type checkpointEngine interface {
+	// ExportSnapshot returns the encoded voting snapshot at the given block.
+	ExportSnapshot(chain consensus.ChainReader, number uint64, hash common.Hash) ([]byte, error)
+
+	// CheckpointHeight returns the height of the last block at or below head
+	// a chain can start from.
+	CheckpointHeight(head uint64) uint64
+
+	// ImportCheckpoint verifies the checkpoint block against its snapshot, and
+	// against the trusted signers if not nil, then stores the snapshot.
+	ImportCheckpoint(block *utils.Block, snapshot []byte, signers []common.Address) error
+}
+
+// trustedCheckpoint is the block an empty chain is synced from. Either its
+// hash is known, or the block served by a peer is trusted once committed by
+// the signers.
+type trustedCheckpoint struct {
+	number  uint64
+	hash    common.Hash
+	signers []common.Address
+}
+
+func newTrustedCheckpoint(cc *conf.CheckpointConfig) *trustedCheckpoint {
+	if cc == nil {
+		return nil
+	}
+	cp := &trustedCheckpoint{number: cc.Number}
+	if cc.Hash != "" {
+		cp.hash = common.HexToHash(cc.Hash)
+		return cp
+	}
+	cp.signers = make([]common.Address, 0, len(cc.Signers))
+	for _, signer := range cc.Signers {
+		cp.signers = append(cp.signers, common.HexToAddress(signer))
+	}
+	return cp
+}
+
+// serviceCheckpointQuery returns the block with the given hash, the last
+// checkpoint block of the chain if zero, and its voting snapshot. The block is
+// nil if unknown or if the engine cannot export its snapshot.
+func serviceCheckpointQuery(backend Backend, hash common.Hash) (*utils.Block, []byte) {
+	engine, ok := backend.Engine().(checkpointEngine)
+	if !ok {
+		return nil, nil
+	}
+	chain := backend.Chain()
+	var block *utils.Block
+	if hash != (common.Hash{}) {
+		block = chain.GetBlockByHash(hash)
+	} else {
+		block = chain.GetBlockByNumber(engine.CheckpointHeight(chain.CurrentBlock().Height))
+	}
+	if block == nil || block.Height == 0 {
+		return nil, nil
+	}
+	snapshot, err := engine.ExportSnapshot(chain, block.Height, block.Hash())
+	if err != nil {
+		logs.Debug("Failed to export the snapshot of checkpoint %d: %v", block.Height, err)
+		return nil, nil
+	}
+	return block, snapshot
+}
+
+// syncCheckpoint starts the empty local chain from the checkpoint the peer
+// serves, after which the downloader syncs the blocks following it.
+func (h *handler) syncCheckpoint(peer *Peer) error {
+	engine, ok := h.engine.(checkpointEngine)
+	if !ok {
+		return errors.New("the consensus engine does not support checkpoint sync")
+	}
+	cp := h.checkpoint
+	if _, height := peer.Head(); height.Uint64() < cp.number {
+		return fmt.Errorf("%w: %d < %d", errPeerBehind, height, cp.number)
+	}
+
+	id := rand.Uint64()
+	atomic.StoreUint64(&h.checkpointReq, id)
+	defer atomic.StoreUint64(&h.checkpointReq, 0)
+	select {
+	case <-h.checkpointCh: // drop a late reply to an earlier request
+	default:
+	}
+	if err := peer.RequestCheckpoint(id, cp.hash); err != nil {
+		return err
+	}
+	timeout := time.NewTimer(checkpointTimeout)
+	defer timeout.Stop()
+
+	var res *CheckpointPacket
+	select {
+	case res = <-h.checkpointCh:
+	case <-timeout.C:
+		return errCheckpointTimeout
+	case <-h.quitSync:
+		return errCheckpointCanceled
+	}
+
+	block := res.Block
+	if block == nil {
+		return errCheckpointUnknown
+	}
+	var signers []common.Address
+	if cp.hash != (common.Hash{}) {
+		if block.Height != cp.number || block.Hash() != cp.hash {
+			err := fmt.Errorf("%w: got %d [%x…]", errCheckpointMismatch, block.Height, block.Hash().Bytes()[:4])
+			h.reputation.penalize(peer.ID(), penaltyInvalidBlock, err)
+			return err
+		}
+	} else {
+		if block.Height < cp.number {
+			return fmt.Errorf("%w: %d < %d", errPeerBehind, block.Height, cp.number)
+		}
+		signers = cp.signers
+	}
+	if err := engine.ImportCheckpoint(block, res.Snapshot, signers); err != nil {
+		h.reputation.penalize(peer.ID(), penaltyInvalidBlock, err)
+		return err
+	}
+	if err := h.blockStore.InsertCheckpoint(block); err != nil {
+		return err
+	}
+	logs.Info("Started the chain from checkpoint %d [%x…] served by %s", block.Height, block.Hash().Bytes()[:4], peer.ID())
+	return nil
+}
+
+// deliverCheckpoint passes the reply to the pending checkpoint request, and
+// drops unsolicited ones.
+func (h *handler) deliverCheckpoint(res *CheckpointPacket) {
+	if id := atomic.LoadUint64(&h.checkpointReq); id == 0 || res.RequestID != id {
+		return
+	}
+	select {
+	case h.checkpointCh <- res:
+	default:
+	}
+}
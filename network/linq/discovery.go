@@ -0,0 +1,50 @@
+package linq
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/p2p/enode"
+)
+
+// enrEntry is the ENR entry which advertises the `linq` protocol on the
+// discovery network.
+type enrEntry struct {
+	NetworkID uint64
+	Validator common.Address // address signing the consensus messages of the node
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e enrEntry) ENRKey() string {
+	return "linq"
+}
+
+// currentENREntry constructs the `linq` ENR entry of this node.
+func (lq *LinQ) currentENREntry() *enrEntry {
+	return &enrEntry{
+		NetworkID: lq.handler.networkID,
+		Validator: lq.validator,
+	}
+}
+
+// setupDiscovery feeds the nodes of the same network found by the UDP
+// discovery to the dial candidates of the protocol.
+func (lq *LinQ) setupDiscovery() {
+	it := lq.p2pServer.DiscoveryNodes()
+	if it == nil {
+		return
+	}
+	lq.ethDialCandidates.AddSource(enode.Filter(it, lq.filterNode))
+}
+
+// filterNode reports whether a discovered node runs LinQ on this network.
+func (lq *LinQ) filterNode(n *enode.Node) bool {
+	var entry enrEntry
+	if err := n.Load(&entry); err != nil {
+		return false
+	}
+	return entry.NetworkID == lq.handler.networkID
+}
@@ -12,8 +12,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
-	"gorm.io/gorm"
 
+	"land-bridge/network/storage"
 	"land-bridge/network/utils"
 )
 
@@ -76,7 +76,7 @@ type Downloader struct {
 	queue      *queue   // Scheduler for selecting the hashes to download
 	peers      *peerSet // Set of active peers from which download can proceed
 
-	stateDB *gorm.DB // Database to state sync into (and deduplicate via)
+	stateDB storage.ChainStore // Database to state sync into (and deduplicate via)
 
 	// Statistics
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
@@ -127,7 +127,7 @@ type BlockChain interface {
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(checkpoint uint64, stateDb *gorm.DB, mux *event.TypeMux, chain BlockChain, dropPeer peerDropFn) *Downloader {
+func New(checkpoint uint64, stateDb storage.ChainStore, mux *event.TypeMux, chain BlockChain, dropPeer peerDropFn) *Downloader {
 	// reset the value of maxForkAncenstry for Quorum based
 	dl := &Downloader{
 		stateDB:       stateDb,
@@ -182,6 +182,32 @@ func (d *Downloader) requestTTL() time.Duration {
 	return ttl
 }
 
+// SyncProgress gives progress indications when the node is synchronising.
+type SyncProgress struct {
+	StartingBlock uint64 // Block number where sync began
+	CurrentBlock  uint64 // Current block number where sync is at
+	HighestBlock  uint64 // Highest alleged block number in the chain
+}
+
+// Synchronising returns whether the downloader is currently retrieving blocks.
+func (d *Downloader) Synchronising() bool {
+	return atomic.LoadInt32(&d.synchronising) > 0
+}
+
+// Progress retrieves the synchronisation boundaries, specifically the origin
+// block where synchronisation started at (may have failed/suspended); the block
+// or header sync is currently at; and the latest known block which the sync targets.
+func (d *Downloader) Progress() SyncProgress {
+	d.syncStatsLock.RLock()
+	defer d.syncStatsLock.RUnlock()
+
+	return SyncProgress{
+		StartingBlock: d.syncStatsChainOrigin,
+		CurrentBlock:  d.blockchain.CurrentBlock().NumberU64(),
+		HighestBlock:  d.syncStatsChainHeight,
+	}
+}
+
 // UnregisterPeer remove a peer from the known list, preventing any action from
 // the specified peer. An effort is also made to return any pending fetches into
 // the queue.
@@ -240,6 +266,12 @@ func (d *Downloader) Cancel() {
 	logs.Debug("Reset ancient limit to zero")
 }
 
+// IsInvalidChain reports whether a synchronisation failed because the peer
+// served blocks which are invalid.
+func IsInvalidChain(err error) bool {
+	return errors.Is(err, errInvalidChain) || errors.Is(err, errInvalidAncestor)
+}
+
 // Synchronise tries to sync up our local block chain with a remote peer, both
 // adding various sanity checks as well as wrapping it with various log entries.
 func (d *Downloader) Synchronise(id string, head common.Hash, height *big.Int) error {
@@ -699,14 +731,12 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64, pivot uint64)
 
 	// Create a timeout timer, and the associated header fetcher
 	skeleton := true            // Skeleton assembly phase or finishing up
-	request := time.Now()       // time of the last skeleton fetch request
 	timeout := time.NewTimer(0) // timer to dump a non-responsive active peer
 	<-timeout.C                 // timeout channel should be initially empty
 	defer timeout.Stop()
 
 	var ttl time.Duration
 	getHeaders := func(from uint64) {
-		request = time.Now()
 
 		ttl = d.requestTTL()
 		timeout.Reset(ttl)
@@ -398,7 +398,7 @@ func (q *queue) Schedule(headers []*utils.CBlock, from uint64) []*utils.CBlock {
 	for _, header := range headers {
 		// Make sure chain order is honoured and preserved throughout
 		hash := header.Hash()
-		if header.Number == nil || header.NumberU64() != from {
+		if header.NumberU64() != from {
 			logs.Warn("Header broke chain ordering", "number", header.Number, "hash", hash, "expected", from)
 			break
 		}
@@ -8,8 +8,8 @@ import (
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/prque"
-	"github.com/ethereum/go-ethereum/consensus"
 
+	"land-bridge/network/consensus/lbft"
 	"land-bridge/network/utils"
 )
 
@@ -24,7 +24,8 @@ const (
 )
 
 var (
-	errTerminated = errors.New("terminated")
+	errTerminated    = errors.New("terminated")
+	errInvalidNumber = errors.New("invalid block number")
 )
 
 // blockRetrievalFn is a callback type for retrieving a block from the local chain.
@@ -48,6 +49,10 @@ type chainInsertFn func(utils.Blocks) (int, error)
 // peerDropFn is a callback type for dropping a peer detected as malicious.
 type peerDropFn func(id string)
 
+// peerPenaltyFn is a callback type for lowering the reputation of a peer
+// which delivered a block failing verification.
+type peerPenaltyFn func(id string, reason error)
+
 // announce is the hash notification of the availability of a new block in the
 // network.
 type announce struct {
@@ -105,10 +110,11 @@ type Fetcher struct {
 	chainHeight    chainHeightFn      // Retrieves the current chain's height
 	insertChain    chainInsertFn      // Injects a batch of blocks into the chain
 	dropPeer       peerDropFn         // Drops a peer for misbehaving
+	penalizePeer   peerPenaltyFn      // Lowers the reputation of a misbehaving peer
 }
 
 // New creates a block fetcher to retrieve blocks based on hash announcements.
-func New(getBlock blockRetrievalFn, verifyHeader headerVerifierFn, broadcastBlock blockBroadcasterFn, chainHeight chainHeightFn, insertChain chainInsertFn, dropPeer peerDropFn) *Fetcher {
+func New(getBlock blockRetrievalFn, verifyHeader headerVerifierFn, broadcastBlock blockBroadcasterFn, chainHeight chainHeightFn, insertChain chainInsertFn, dropPeer peerDropFn, penalizePeer peerPenaltyFn) *Fetcher {
 	return &Fetcher{
 		notify:         make(chan *announce),
 		inject:         make(chan *inject),
@@ -129,6 +135,7 @@ func New(getBlock blockRetrievalFn, verifyHeader headerVerifierFn, broadcastBloc
 		chainHeight:    chainHeight,
 		insertChain:    insertChain,
 		dropPeer:       dropPeer,
+		penalizePeer:   penalizePeer,
 	}
 }
 
@@ -355,6 +362,7 @@ func (f *Fetcher) loop() {
 					// If the delivered header does not match the promised number, drop the announcer
 					if header.NumberU64() != announce.number {
 						logs.Trace("Invalid block number fetched", "peer", announce.origin, "hash", header.Hash(), "announced", announce.number, "provided", header.Number)
+						f.penalizePeer(announce.origin, errInvalidNumber)
 						f.dropPeer(announce.origin)
 						f.forgetHash(hash)
 						continue
@@ -490,12 +498,14 @@ func (f *Fetcher) insert(peer string, block *utils.CBlock) {
 			// All ok, quickly propagate to our peers
 			go f.broadcastBlock(block.ToBlock(), true)
 
-		case consensus.ErrFutureBlock:
+		case lbft.ErrFutureBlock:
 			// Weird future block, don't fail, but neither propagate
+			f.penalizePeer(peer, err)
 
 		default:
 			// Something went very wrong, drop the peer
 			logs.Debug("Propagated block verification failed", "peer", peer, "number", block.Number(), "hash", hash, "err", err)
+			f.penalizePeer(peer, err)
 			f.dropPeer(peer)
 			return
 		}
@@ -0,0 +1,137 @@
+package linq
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"sort"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// errRemoteStale is returned by the fork filter when a remote fork ID is a
+	// subset of the local one, but it does not announce the next local fork.
+	errRemoteStale = errors.New("remote needs update")
+
+	// errLocalIncompatibleOrStale is returned by the fork filter when a remote
+	// fork ID does not match any local checksum, or it announces a fork the
+	// local chain already passed.
+	errLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// forkID is the fork identifier of EIP-2124 for the LinQ chain: the forks are
+// the heights activating consensus rule changes.
+type forkID struct {
+	Hash [4]byte // CRC32 checksum of the genesis hash and the passed fork heights
+	Next uint64  // Height of the next fork, 0 if none is scheduled
+}
+
+func (id forkID) String() string {
+	return fmt.Sprintf("%x/%d", id.Hash, id.Next)
+}
+
+// forkFilter checks the fork ID of a peer against the local chain.
+type forkFilter func(id forkID) error
+
+// gatherForks returns the sorted, deduplicated fork heights, leaving out the
+// ones active from genesis.
+func gatherForks(heights []uint64) []uint64 {
+	forks := make([]uint64, 0, len(heights))
+	for _, h := range heights {
+		if h > 0 {
+			forks = append(forks, h)
+		}
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i] < forks[j] })
+	for i := 1; i < len(forks); i++ {
+		if forks[i] == forks[i-1] {
+			forks = append(forks[:i], forks[i+1:]...)
+			i--
+		}
+	}
+	return forks
+}
+
+// newForkID returns the fork ID of the chain at the given head height.
+func newForkID(genesis common.Hash, forks []uint64, head uint64) forkID {
+	hash := crc32.ChecksumIEEE(genesis[:])
+	for _, fork := range gatherForks(forks) {
+		if fork <= head {
+			hash = checksumUpdate(hash, fork)
+			continue
+		}
+		return forkID{Hash: checksumToBytes(hash), Next: fork}
+	}
+	return forkID{Hash: checksumToBytes(hash)}
+}
+
+// newForkFilter returns the filter accepting the fork IDs compatible with the
+// local chain, whose head height headfn returns.
+func newForkFilter(genesis common.Hash, forks []uint64, headfn func() uint64) forkFilter {
+	forks = gatherForks(forks)
+	sums := make([][4]byte, len(forks)+1) // 0th is the genesis
+	hash := crc32.ChecksumIEEE(genesis[:])
+	sums[0] = checksumToBytes(hash)
+	for i, fork := range forks {
+		hash = checksumUpdate(hash, fork)
+		sums[i+1] = checksumToBytes(hash)
+	}
+	forks = append(forks, math.MaxUint64) // Last fork will never be passed
+
+	return func(id forkID) error {
+		head := headfn()
+		for i, fork := range forks {
+			if head >= fork {
+				continue
+			}
+			// Found the first unpassed fork, the remote checksum matching the
+			// local one is compatible unless it announces a fork already
+			// passed locally.
+			if sums[i] == id.Hash {
+				if id.Next > 0 && head >= id.Next {
+					return errLocalIncompatibleOrStale
+				}
+				return nil
+			}
+			// A remote checksum of an earlier local fork is a syncing peer,
+			// compatible if it knows the next fork.
+			for j := 0; j < i; j++ {
+				if sums[j] == id.Hash {
+					if forks[j] != id.Next {
+						return errRemoteStale
+					}
+					return nil
+				}
+			}
+			// A remote checksum of a later local fork means the local chain
+			// is syncing.
+			for j := i + 1; j < len(sums); j++ {
+				if sums[j] == id.Hash {
+					return nil
+				}
+			}
+			return errLocalIncompatibleOrStale
+		}
+		logs.Error("Impossible fork ID validation %v", id)
+		return nil
+	}
+}
+
+// checksumUpdate calculates the next IEEE CRC32 checksum based on the previous
+// one and a fork height.
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+// checksumToBytes converts a uint32 checksum into a [4]byte array.
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
@@ -0,0 +1,127 @@
+package linq
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The vectors are those of EIP-2124 for the Ethereum mainnet, whose forks are
+// plain activation heights like the LinQ ones.
+var (
+	mainnetGenesis = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+
+	// Frontier is active from genesis, Constantinople and Petersburg share a
+	// height.
+	mainnetForks = []uint64{0, 1150000, 1920000, 2463000, 2675000, 4370000, 7280000, 7280000, 9069000, 9200000, 12244000, 12965000, 13773000}
+
+	// The forks up to Petersburg, the local chain of the filter vectors.
+	petersburgForks = []uint64{1150000, 1920000, 2463000, 2675000, 4370000, 7280000}
+)
+
+func TestGatherForks(t *testing.T) {
+	got := gatherForks([]uint64{7280000, 0, 1150000, 7280000, 4370000, 0})
+	want := []uint64{1150000, 4370000, 7280000}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("forks %v, want %v", got, want)
+	}
+}
+
+func TestForkID(t *testing.T) {
+	tests := []struct {
+		head uint64
+		want forkID
+	}{
+		{0, forkID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},        // Unsynced
+		{1149999, forkID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},  // Last Frontier block
+		{1150000, forkID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}},  // First Homestead block
+		{1919999, forkID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}},  // Last Homestead block
+		{1920000, forkID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}},  // First DAO block
+		{2462999, forkID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}},  // Last DAO block
+		{2463000, forkID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}},  // First Tangerine block
+		{2674999, forkID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}},  // Last Tangerine block
+		{2675000, forkID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}},  // First Spurious block
+		{4369999, forkID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}},  // Last Spurious block
+		{4370000, forkID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}},  // First Byzantium block
+		{7279999, forkID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}},  // Last Byzantium block
+		{7280000, forkID{Hash: checksumToBytes(0x668db0af), Next: 9069000}},  // First Constantinople and Petersburg block
+		{9068999, forkID{Hash: checksumToBytes(0x668db0af), Next: 9069000}},  // Last Petersburg block
+		{9069000, forkID{Hash: checksumToBytes(0x879d6e30), Next: 9200000}},  // First Istanbul block
+		{9199999, forkID{Hash: checksumToBytes(0x879d6e30), Next: 9200000}},  // Last Istanbul block
+		{9200000, forkID{Hash: checksumToBytes(0xe029e991), Next: 12244000}}, // First Muir Glacier block
+		{12243999, forkID{Hash: checksumToBytes(0xe029e991), Next: 12244000}},
+		{12244000, forkID{Hash: checksumToBytes(0x0eb440f6), Next: 12965000}}, // First Berlin block
+		{12964999, forkID{Hash: checksumToBytes(0x0eb440f6), Next: 12965000}},
+		{12965000, forkID{Hash: checksumToBytes(0xb715077d), Next: 13773000}}, // First London block
+		{13772999, forkID{Hash: checksumToBytes(0xb715077d), Next: 13773000}},
+		{13773000, forkID{Hash: checksumToBytes(0x20c327fc), Next: 0}}, // First Arrow Glacier block
+		{20000000, forkID{Hash: checksumToBytes(0x20c327fc), Next: 0}}, // Future block
+	}
+	for i, tt := range tests {
+		if got := newForkID(mainnetGenesis, mainnetForks, tt.head); got != tt.want {
+			t.Errorf("test %d: fork ID at %d is %v, want %v", i, tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestForkFilter(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   forkID
+		err  error
+	}{
+		// Local is Petersburg, remote announces the same, no future fork is
+		// known by either
+		{7987396, forkID{Hash: checksumToBytes(0x668db0af), Next: 0}, nil},
+
+		// Local is Petersburg, remote announces the same and a future fork
+		// unknown locally
+		{7987396, forkID{Hash: checksumToBytes(0x668db0af), Next: math.MaxUint64}, nil},
+
+		// Local is Byzantium, remote announces the same without Petersburg
+		{7279999, forkID{Hash: checksumToBytes(0xa00bc324), Next: 0}, nil},
+
+		// Local is Byzantium, remote announces the same and Petersburg
+		{7279999, forkID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}, nil},
+
+		// Local is Byzantium, remote announces the same and a fork unknown
+		// locally
+		{7279999, forkID{Hash: checksumToBytes(0xa00bc324), Next: math.MaxUint64}, nil},
+
+		// Local is Petersburg, remote is a syncing Byzantium which knows
+		// Petersburg
+		{7987396, forkID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}, nil},
+
+		// Local is Petersburg, remote is a syncing Spurious which knows
+		// Byzantium, and may know Petersburg
+		{7987396, forkID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}, nil},
+
+		// Local is a syncing Byzantium, remote is Petersburg
+		{7279999, forkID{Hash: checksumToBytes(0x668db0af), Next: 0}, nil},
+
+		// Local is a syncing Spurious, remote is Byzantium without Petersburg
+		{4369999, forkID{Hash: checksumToBytes(0xa00bc324), Next: 0}, nil},
+
+		// Local is Petersburg, remote is Byzantium without Petersburg
+		{7987396, forkID{Hash: checksumToBytes(0xa00bc324), Next: 0}, errRemoteStale},
+
+		// Local is Petersburg, remote is on another chain
+		{7987396, forkID{Hash: checksumToBytes(0x5cddc0e1), Next: 0}, errLocalIncompatibleOrStale},
+		{7987396, forkID{Hash: checksumToBytes(0xafec6b27), Next: 0}, errLocalIncompatibleOrStale},
+
+		// Local passed the fork remote announces next, so local missed it
+		{88888888, forkID{Hash: checksumToBytes(0x668db0af), Next: 88888888}, errLocalIncompatibleOrStale},
+
+		// Local is Byzantium at the height of the fork remote announces,
+		// which is not the local Petersburg
+		{7279999, forkID{Hash: checksumToBytes(0xa00bc324), Next: 7279999}, errLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := newForkFilter(mainnetGenesis, petersburgForks, func() uint64 { return tt.head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: head %d, remote %v: error %v, want %v", i, tt.head, tt.id, err, tt.err)
+		}
+	}
+}
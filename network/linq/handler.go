@@ -13,6 +13,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
 
+	"land-bridge/network/bridge"
 	"land-bridge/network/consensus"
 	"land-bridge/network/linq/downloader"
 	"land-bridge/network/linq/fetcher"
@@ -22,17 +23,30 @@ import (
 )
 
 type handler struct {
-	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
+	checkpointReq uint64 // ID of the pending checkpoint request, 0 if none (accessed atomically)
+	acceptTxs     uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
-	networkID uint64
-	maxPeers  int
+	networkID     uint64
+	genesis       common.Hash // hash of the genesis block
+	forks         []uint64    // heights activating consensus rule changes
+	forkFilter    forkFilter  // checks the fork IDs of the peers against the local chain
+	consensusHash common.Hash // hash of the network-wide LBFT parameters
+	maxPeers      int
 
 	checkpointNumber uint64      // Block number for the sync progress validator to cross reference
 	checkpointHash   common.Hash // Block hash for the sync progress validator to cross reference
 
+	checkpoint   *trustedCheckpoint     // block an empty chain is synced from, nil to sync from genesis
+	checkpointCh chan *CheckpointPacket // reply to the pending checkpoint request
+
+	bridge        *bridge.Bridge                   // signs committed transfers for the peers re-collecting signatures
+	signatureMu   sync.Mutex                       // protects signatureReqs
+	signatureReqs map[uint64]chan *SignaturePacket // replies to the pending signature requests
+
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
 	peers      *peerSet
+	reputation *reputation
 
 	engine consensus.Engine
 
@@ -84,24 +98,45 @@ type Backend interface {
 	// be forwarded to the backend.
 	Handle(peer *Peer, packet Packet) error
 
+	// Penalize lowers the reputation of the peer if the error handling its
+	// message is the peer's fault.
+	Penalize(peer *Peer, reason error)
+
 	Engine() consensus.Engine
 }
 
-func newHandler(engine consensus.Engine, blockStore *Store, mux *event.TypeMux) (*handler, error) {
+func newHandler(engine consensus.Engine, blockStore *Store, bridge *bridge.Bridge, mux *event.TypeMux, networkID uint64, consensusHash common.Hash, forks []uint64, checkpoint *trustedCheckpoint, reputation *reputation) (*handler, error) {
+	genesis := blockStore.GetBlockByNumber(0)
+	if genesis == nil {
+		return nil, errors.New("chain store is empty, init the genesis block first")
+	}
 	h := &handler{
-		peers:      newPeerSet(),
-		quitSync:   make(chan struct{}),
-		engine:     engine,
-		blockStore: blockStore,
-		eventMux:   mux,
+		networkID:     networkID,
+		genesis:       genesis.Hash(),
+		forks:         forks,
+		consensusHash: consensusHash,
+		checkpoint:    checkpoint,
+		checkpointCh:  make(chan *CheckpointPacket, 1),
+		bridge:        bridge,
+		signatureReqs: make(map[uint64]chan *SignaturePacket),
+		peers:         newPeerSet(),
+		reputation:    reputation,
+		quitSync:      make(chan struct{}),
+		engine:        engine,
+		blockStore:    blockStore,
+		eventMux:      mux,
 	}
 
+	h.forkFilter = newForkFilter(h.genesis, forks, func() uint64 {
+		return blockStore.CurrentBlock().NumberU64()
+	})
+
 	if handler, ok := h.engine.(consensus.Handler); ok {
 		logs.Debug("handler SetBroadcaster")
 		handler.SetBroadcaster(h)
 	}
 
-	h.downloader = downloader.New(h.checkpointNumber, blockStore.db, h.eventMux, blockStore, h.removePeer)
+	h.downloader = downloader.New(h.checkpointNumber, blockStore.chainStore, h.eventMux, blockStore, h.removePeer)
 
 	// Construct the fetcher (short sync)
 	validator := func(header *utils.CBlock) error {
@@ -117,7 +152,7 @@ func newHandler(engine consensus.Engine, blockStore *Store, mux *event.TypeMux)
 		}
 		return n, err
 	}
-	h.fetcher = fetcher.New(blockStore.GetBlockByHash, validator, h.BroadcastBlock, heighter, inserter, h.unregisterPeer)
+	h.fetcher = fetcher.New(blockStore.GetBlockByHash, validator, h.BroadcastBlock, heighter, inserter, h.unregisterPeer, h.penalizeBlock)
 
 	h.chainSync = newChainSyncer(h)
 
@@ -133,8 +168,18 @@ func (h *handler) runEthPeer(peer *Peer, handler Handler) error {
 		height = head.Number()
 	)
 
-	if err := peer.Handshake(h.networkID, height, hash); err != nil {
-		logs.Debug("Ethereum handshake failed", "err", err)
+	forkID := newForkID(h.genesis, h.forks, height.Uint64())
+	if err := peer.Handshake(h.networkID, height, hash, h.genesis, forkID, h.forkFilter, h.consensusHash); err != nil {
+		switch {
+		case errors.Is(err, errConsensusMismatch):
+			logs.Error("peer %s runs different consensus parameters: %v", peer.ID(), err)
+		case errors.Is(err, errGenesisMismatch), errors.Is(err, errNetworkIDMismatch):
+			logs.Error("peer %s is on another network: %v", peer.ID(), err)
+		case errors.Is(err, errForkIDRejected):
+			logs.Error("peer %s is on an incompatible fork: %v", peer.ID(), err)
+		default:
+			logs.Debug("Ethereum handshake failed", "err", err)
+		}
 		return err
 	}
 
@@ -167,6 +212,7 @@ func Handle(backend Backend, peer *Peer) error {
 	logs.Trace("new peer begin to loop handle msg")
 	for {
 		if err := handleMessage(backend, peer); err != nil {
+			backend.Penalize(peer, err)
 			logs.Debug("Message handling failed in `eth`", "err", err)
 			return err
 		}
@@ -186,11 +232,19 @@ func handleMessage(backend Backend, peer *Peer) error {
 	}
 	defer msg.Discard()
 
+	if peer.flooding() {
+		backend.Penalize(peer, errFlood)
+	}
+
 	if handler, ok := backend.Engine().(consensus.Handler); ok {
 		pubKey := peer.Node().Pubkey()
 		addr := crypto.PubkeyToAddress(*pubKey)
 		handled, err := handler.HandleMsg(addr, msg)
 		if handled {
+			if errors.Is(err, consensus.ErrDuplicateMessage) {
+				backend.Penalize(peer, err)
+				return nil
+			}
 			if err != nil {
 				logs.Error("handleMessage HandleMsg error ", err)
 			}
@@ -295,6 +349,12 @@ func (h *handler) BroadcastBlock(block *utils.Block, propagate bool) {
 	}
 }
 
+// penalizeBlock lowers the reputation of a peer which delivered a block
+// failing verification.
+func (h *handler) penalizeBlock(id string, reason error) {
+	h.reputation.penalize(id, blockPenalty(reason), reason)
+}
+
 func (h *handler) removePeer(id string) {
 	// Short circuit if the peer was already removed
 	peer := h.peers.peer(id)
@@ -316,10 +376,20 @@ func (h *handler) removePeer(id string) {
 
 // doSync synchronizes the local blockchain with a remote peer.
 func (h *handler) doSync(op *chainSyncOp) error {
+	// Start an empty chain from the trusted checkpoint rather than the genesis
+	if h.checkpoint != nil && h.blockStore.CurrentBlock().NumberU64() == 0 {
+		if err := h.syncCheckpoint(op.peer); err != nil {
+			logs.Warn("Checkpoint sync with peer %s failed: %v", op.peer.ID(), err)
+			return err
+		}
+	}
 
 	// Run the sync cycle, and disable snap sync if we're past the pivot block
 	err := h.downloader.Synchronise(op.peer.ID(), op.head, op.height)
 	if err != nil {
+		if downloader.IsInvalidChain(err) {
+			h.reputation.penalize(op.peer.ID(), penaltyInvalidBlock, err)
+		}
 		return err
 	}
 	// If we've successfully finished a sync cycle and passed any required checkpoint,
@@ -22,7 +22,9 @@ func (l *linqHandler) RunPeer(peer *Peer, hand Handler) error {
 
 func (l *linqHandler) PeerInfo(id enode.ID) interface{} {
 	if p := l.peers.peer(id.String()); p != nil {
-		return nil
+		info := p.info()
+		info.Score = l.reputation.score(p.ID())
+		return info
 	}
 	return nil
 }
@@ -45,11 +47,27 @@ func (l *linqHandler) Handle(peer *Peer, packet Packet) error {
 		return l.handleBlockBroadcast(peer, packet.Block, packet.TD)
 	case *BlockHeadersPacket66:
 		return l.handleBlockHeaderst(peer, packet.BlockHeadersPacket)
+	case *CheckpointPacket:
+		(*handler)(l).deliverCheckpoint(packet)
+		return nil
+	case *GetSignaturePacket:
+		// signing may verify the transfer against its source chain
+		go (*handler)(l).serveSignature(peer, packet)
+		return nil
+	case *SignaturePacket:
+		(*handler)(l).deliverSignature(packet)
+		return nil
 	default:
 		return fmt.Errorf("unexpected eth packet type: %T", packet)
 	}
 }
 
+func (l *linqHandler) Penalize(peer *Peer, reason error) {
+	if penalty, ok := messagePenalty(reason); ok {
+		l.reputation.penalize(peer.ID(), penalty, reason)
+	}
+}
+
 func (l *linqHandler) Engine() consensus.Engine {
 	return l.engine
 }
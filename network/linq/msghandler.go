@@ -29,6 +29,10 @@ const (
 	GetBlockHeadersMsg = 0x03
 	BlockHeadersMsg    = 0x04
 	NewBlockMsg        = 0x07
+	GetCheckpointMsg   = 0x08
+	CheckpointMsg      = 0x09
+	GetSignatureMsg    = 0x0a
+	SignatureMsg       = 0x0b
 )
 
 var msglist = map[uint64]msgHandler{
@@ -36,6 +40,10 @@ var msglist = map[uint64]msgHandler{
 	NewBlockMsg:        handleNewBlock,
 	GetBlockHeadersMsg: handleGetBlockHeaders,
 	BlockHeadersMsg:    handleBlockHeaders,
+	GetCheckpointMsg:   handleGetCheckpoint,
+	CheckpointMsg:      handleCheckpoint,
+	GetSignatureMsg:    handleGetSignature,
+	SignatureMsg:       handleSignature,
 }
 
 func handleNewBlockhashes(backend Backend, msg Decoder, peer *Peer) error {
@@ -88,6 +96,39 @@ func handleGetBlockHeaders(backend Backend, msg Decoder, peer *Peer) error {
 	return peer.ReplyBlockHeaders(query.RequestID, blocks.ToCBlock())
 }
 
+func handleGetCheckpoint(backend Backend, msg Decoder, peer *Peer) error {
+	var query GetCheckpointPacket
+	if err := msg.Decode(&query); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	block, snapshot := serviceCheckpointQuery(backend, query.Hash)
+	return peer.ReplyCheckpoint(query.RequestID, block, snapshot)
+}
+
+func handleCheckpoint(backend Backend, msg Decoder, peer *Peer) error {
+	res := new(CheckpointPacket)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return backend.Handle(peer, res)
+}
+
+func handleGetSignature(backend Backend, msg Decoder, peer *Peer) error {
+	query := new(GetSignaturePacket)
+	if err := msg.Decode(query); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return backend.Handle(peer, query)
+}
+
+func handleSignature(backend Backend, msg Decoder, peer *Peer) error {
+	res := new(SignaturePacket)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return backend.Handle(peer, res)
+}
+
 // ServiceGetBlockHeadersQuery assembles the response to a header query. It is
 // exposed to allow external packages to test protocol behavior.
 func ServiceGetBlockHeadersQuery(chain *Store, query *GetBlockHeadersPacket, peer *Peer) []*utils.Block {
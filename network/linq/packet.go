@@ -86,3 +86,45 @@ type GetBlockHeadersPacket struct {
 
 func (*GetBlockHeadersPacket) Name() string { return "GetBlockHeaders" }
 func (*GetBlockHeadersPacket) Kind() byte   { return GetBlockHeadersMsg }
+
+// GetCheckpointPacket requests a block and its voting snapshot, the head
+// block if the hash is zero.
+type GetCheckpointPacket struct {
+	RequestID uint64
+	Hash      common.Hash
+}
+
+func (*GetCheckpointPacket) Name() string { return "GetCheckpoint" }
+func (*GetCheckpointPacket) Kind() byte   { return GetCheckpointMsg }
+
+// CheckpointPacket is the reply to GetCheckpointPacket, without block if the
+// peer does not have it.
+type CheckpointPacket struct {
+	RequestID uint64
+	Block     *utils.Block `rlp:"nil"`
+	Snapshot  []byte       // voting snapshot encoded by the consensus engine
+}
+
+func (*CheckpointPacket) Name() string { return "Checkpoint" }
+func (*CheckpointPacket) Kind() byte   { return CheckpointMsg }
+
+// GetSignaturePacket requests the signature of a transfer committed in a block.
+type GetSignaturePacket struct {
+	RequestID uint64
+	Block     common.Hash
+	TxHash    common.Hash
+}
+
+func (*GetSignaturePacket) Name() string { return "GetSignature" }
+func (*GetSignaturePacket) Kind() byte   { return GetSignatureMsg }
+
+// SignaturePacket is the reply to GetSignaturePacket, with an empty signature
+// if the peer cannot sign the transfer.
+type SignaturePacket struct {
+	RequestID uint64
+	TxHash    common.Hash
+	Signature []byte
+}
+
+func (*SignaturePacket) Name() string { return "Signature" }
+func (*SignaturePacket) Kind() byte   { return SignatureMsg }
@@ -55,6 +55,9 @@ type Peer struct {
 	queuedProps chan *propEvent   // Queue of blocks to broadcast to the peer
 	queuedAnns  chan *utils.Block // Queue of blocks to announce to the peer
 	term        chan struct{}     // Termination channel to stop the broadcasters
+
+	msgWindow time.Time // Start of the window the received messages are counted in
+	msgCount  int       // Messages received in the window, only accessed by the read loop
 }
 
 func (p *Peer) Head() (hash common.Hash, td *big.Int) {
@@ -74,6 +77,36 @@ func (p *Peer) SetHead(hash common.Hash, td *big.Int) {
 	p.height.Set(td)
 }
 
+// PeerInfo represents a short summary of the `linq` sub-protocol metadata known
+// about a connected peer.
+type PeerInfo struct {
+	Version uint        `json:"version"` // Protocol version negotiated
+	Height  *big.Int    `json:"height"`  // Height of the peer's blockchain
+	Head    common.Hash `json:"head"`    // Hash of the peer's best owned block
+	Score   float64     `json:"score"`   // Reputation of the peer, negative once it misbehaved
+}
+
+// info gathers and returns some `linq` protocol metadata known about a peer.
+func (p *Peer) info() *PeerInfo {
+	hash, height := p.Head()
+	info := &PeerInfo{Version: p.version, Head: hash}
+	if height != nil {
+		info.Height = new(big.Int).Set(height)
+	}
+	return info
+}
+
+// flooding counts a received message and reports whether the peer exceeded
+// floodLimit messages in the current window, once per window.
+func (p *Peer) flooding() bool {
+	now := time.Now()
+	if now.Sub(p.msgWindow) >= floodWindow {
+		p.msgWindow, p.msgCount = now, 0
+	}
+	p.msgCount++
+	return p.msgCount == floodLimit+1
+}
+
 func (p *Peer) RequestHeadersByHash(origin common.Hash, amount int, skip int, reverse bool) error {
 	logs.Debug("Fetching batch of headers", "count", amount, "fromhash", origin, "skip", skip, "reverse", reverse)
 	id := rand.Uint64()
@@ -151,8 +184,10 @@ func (p *Peer) ID() string {
 }
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *Peer) Handshake(network uint64, height *big.Int, block common.Hash) error {
+// network IDs, difficulties, head and genesis blocks. Peers announcing
+// different consensus parameters, another genesis block or an incompatible
+// fork ID are rejected; peers not announcing them are accepted.
+func (p *Peer) Handshake(network uint64, height *big.Int, block common.Hash, genesis common.Hash, forkID forkID, forkFilter forkFilter, consensus common.Hash) error {
 	errc := make(chan error, 2)
 
 	var status StatusPacket // safe to read after two values have been received from errc
@@ -164,11 +199,14 @@ func (p *Peer) Handshake(network uint64, height *big.Int, block common.Hash) err
 			NetworkID:       network,
 			Height:          height,
 			Head:            block,
+			Genesis:         genesis,
+			ForkID:          forkID,
+			Consensus:       consensus,
 		})
 	}()
 	go func() {
 		logs.Info("handshake read StatusMsg")
-		errc <- p.readStatus(network, &status)
+		errc <- p.readStatus(network, genesis, forkFilter, consensus, &status)
 	}()
 	timeout := time.NewTimer(handshakeTimeout)
 	defer timeout.Stop()
@@ -192,7 +230,7 @@ func (p *Peer) Handshake(network uint64, height *big.Int, block common.Hash) err
 }
 
 // readStatus reads the remote handshake message.
-func (p *Peer) readStatus(network uint64, status *StatusPacket) error {
+func (p *Peer) readStatus(network uint64, genesis common.Hash, forkFilter forkFilter, consensus common.Hash, status *StatusPacket) error {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
 		return err
@@ -213,6 +251,18 @@ func (p *Peer) readStatus(network uint64, status *StatusPacket) error {
 	if uint(status.ProtocolVersion) != p.version {
 		return fmt.Errorf("%w: %d (!= %d)", errProtocolVersionMismatch, status.ProtocolVersion, p.version)
 	}
+	if status.Genesis != genesis {
+		return fmt.Errorf("%w: %x (!= %x)", errGenesisMismatch, status.Genesis, genesis)
+	}
+	if status.ForkID == (forkID{}) {
+		return fmt.Errorf("%w: missing", errForkIDRejected)
+	}
+	if err := forkFilter(status.ForkID); err != nil {
+		return fmt.Errorf("%w: %v: %v", errForkIDRejected, status.ForkID, err)
+	}
+	if status.Consensus != (common.Hash{}) && status.Consensus != consensus {
+		return fmt.Errorf("%w: %x (!= %x)", errConsensusMismatch, status.Consensus, consensus)
+	}
 
 	return nil
 }
@@ -266,6 +316,45 @@ func (p *Peer) ReplyBlockHeaders(id uint64, headers []*utils.CBlock) error {
 	})
 }
 
+// RequestCheckpoint fetches a block and its voting snapshot, the head block
+// if hash is zero.
+func (p *Peer) RequestCheckpoint(id uint64, hash common.Hash) error {
+	logs.Debug("Fetching checkpoint", "hash", hash)
+	return p2p.Send(p.rw, GetCheckpointMsg, &GetCheckpointPacket{
+		RequestID: id,
+		Hash:      hash,
+	})
+}
+
+// ReplyCheckpoint sends a block and its voting snapshot, or no block if it is
+// unknown.
+func (p *Peer) ReplyCheckpoint(id uint64, block *utils.Block, snapshot []byte) error {
+	return p2p.Send(p.rw, CheckpointMsg, &CheckpointPacket{
+		RequestID: id,
+		Block:     block,
+		Snapshot:  snapshot,
+	})
+}
+
+// RequestSignature asks the peer to sign the transfer txHash committed in block.
+func (p *Peer) RequestSignature(id uint64, block common.Hash, txHash common.Hash) error {
+	return p2p.Send(p.rw, GetSignatureMsg, &GetSignaturePacket{
+		RequestID: id,
+		Block:     block,
+		TxHash:    txHash,
+	})
+}
+
+// ReplySignature sends the signature of a committed transfer, empty if the
+// transfer cannot be signed.
+func (p *Peer) ReplySignature(id uint64, txHash common.Hash, signature []byte) error {
+	return p2p.Send(p.rw, SignatureMsg, &SignaturePacket{
+		RequestID: id,
+		TxHash:    txHash,
+		Signature: signature,
+	})
+}
+
 func (p *Peer) broadcast() {
 	for {
 		select {
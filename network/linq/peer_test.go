@@ -0,0 +1,73 @@
+package linq
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/p2p"
+)
+
+func TestReadStatus(t *testing.T) {
+	genesis := common.HexToHash("0x01")
+	id := forkID{Hash: checksumToBytes(0x01020304)}
+	filter := func(remote forkID) error {
+		if remote != id {
+			return errors.New("incompatible fork")
+		}
+		return nil
+	}
+	valid := func() StatusPacket {
+		return StatusPacket{ProtocolVersion: 1, NetworkID: 7, Height: big.NewInt(3), Genesis: genesis, ForkID: id}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(status *StatusPacket)
+		err    error
+	}{
+		{"matching", func(status *StatusPacket) {}, nil},
+		{"other network", func(status *StatusPacket) { status.NetworkID = 8 }, errNetworkIDMismatch},
+		{"other genesis", func(status *StatusPacket) { status.Genesis = common.HexToHash("0x02") }, errGenesisMismatch},
+		{"no genesis", func(status *StatusPacket) { status.Genesis = common.Hash{} }, errGenesisMismatch},
+		{"no fork ID", func(status *StatusPacket) { status.ForkID = forkID{} }, errForkIDRejected},
+		{"other fork", func(status *StatusPacket) { status.ForkID.Next = 10 }, errForkIDRejected},
+		{"other consensus", func(status *StatusPacket) { status.Consensus = common.HexToHash("0x03") }, errConsensusMismatch},
+	}
+	for _, tt := range tests {
+		status := valid()
+		tt.modify(&status)
+		if err := receiveStatus(t, status, genesis, filter); !errors.Is(err, tt.err) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// a handshake from before the genesis hash and fork ID were exchanged
+	legacy := struct {
+		ProtocolVersion uint32
+		NetworkID       uint64
+		Height          *big.Int
+		Head            common.Hash
+	}{1, 7, big.NewInt(3), common.Hash{}}
+	if err := receiveStatus(t, legacy, genesis, filter); !errors.Is(err, errDecode) {
+		t.Errorf("legacy handshake: error %v, want %v", err, errDecode)
+	}
+}
+
+// receiveStatus reads the status sent by a remote peer.
+func receiveStatus(t *testing.T, status interface{}, genesis common.Hash, filter forkFilter) error {
+	local, remote := p2p.MsgPipe()
+	defer local.Close()
+
+	size, r, err := rlp.EncodeToReader(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go remote.WriteMsg(p2p.Msg{Code: StatusMsg, Size: uint32(size), Payload: r})
+
+	p := &Peer{rw: local, version: 1}
+	return p.readStatus(7, genesis, filter, common.Hash{}, new(StatusPacket))
+}
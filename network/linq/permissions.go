@@ -0,0 +1,79 @@
+package linq
+
+import (
+	"sync"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"land-bridge/network/p2p"
+	"land-bridge/network/p2p/enode"
+)
+
+// permissions admits as peers only the nodes whose node key belongs to a
+// current validator or to an observer of the allow-list.
+type permissions struct {
+	server    *p2p.Server
+	observers map[common.Address]bool
+
+	mu         sync.RWMutex
+	validators map[common.Address]bool
+}
+
+func newPermissions(server *p2p.Server, observers, validators []common.Address) *permissions {
+	p := &permissions{
+		server:     server,
+		observers:  make(map[common.Address]bool, len(observers)),
+		validators: make(map[common.Address]bool, len(validators)),
+	}
+	for _, addr := range observers {
+		p.observers[addr] = true
+	}
+	for _, addr := range validators {
+		p.validators[addr] = true
+	}
+	server.SetPeerFilter(p.allow)
+	return p
+}
+
+// allow reports whether the node may be a peer.
+func (p *permissions) allow(n *enode.Node) bool {
+	pubkey := n.Pubkey()
+	if pubkey == nil {
+		return false
+	}
+	addr := crypto.PubkeyToAddress(*pubkey)
+	if p.observers[addr] {
+		return true
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.validators[addr]
+}
+
+// setValidators replaces the validator set. When it changed, the peers no
+// longer permitted are disconnected.
+func (p *permissions) setValidators(validators []common.Address) {
+	set := make(map[common.Address]bool, len(validators))
+	for _, addr := range validators {
+		set[addr] = true
+	}
+
+	p.mu.Lock()
+	changed := len(set) != len(p.validators)
+	for addr := range set {
+		if !p.validators[addr] {
+			changed = true
+		}
+	}
+	if changed {
+		p.validators = set
+	}
+	p.mu.Unlock()
+
+	if changed {
+		logs.Info("permitted validators changed to %d nodes", len(set))
+		p.server.SetPeerFilter(p.allow)
+	}
+}
@@ -21,6 +21,9 @@ var (
 	errInvalidMsgCode          = errors.New("invalid message code")
 	errProtocolVersionMismatch = errors.New("protocol version mismatch")
 	errNetworkIDMismatch       = errors.New("network ID mismatch")
+	errConsensusMismatch       = errors.New("consensus parameters mismatch")
+	errGenesisMismatch         = errors.New("genesis block mismatch")
+	errForkIDRejected          = errors.New("fork ID rejected")
 )
 
 type Decoder interface {
@@ -34,6 +37,9 @@ type StatusPacket struct {
 	NetworkID       uint64
 	Height          *big.Int
 	Head            common.Hash
+	Genesis         common.Hash // hash of the genesis block
+	ForkID          forkID      // fork identifier of the announced head
+	Consensus       common.Hash `rlp:"optional"` // hash of the network-wide LBFT parameters
 }
 
 // HashOrNumber is a combined field for specifying an origin block.
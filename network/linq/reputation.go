@@ -0,0 +1,180 @@
+package linq
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	lru "github.com/hashicorp/golang-lru"
+
+	"land-bridge/network/consensus"
+	"land-bridge/network/consensus/lbft"
+	"land-bridge/network/p2p"
+	"land-bridge/network/p2p/enode"
+)
+
+// Penalties lowering the score of a peer. A peer whose score falls to
+// -banThreshold is disconnected and banned; the score recovers over time,
+// halving every scoreHalfLife.
+const (
+	penaltyInvalidMessage = 50 // message failing to decode, too large or of unknown code
+	penaltyInvalidBlock   = 50 // block failing verification, e.g. with invalid seals
+	penaltyFutureBlock    = 10 // block timestamped too far ahead of the local clock
+	penaltySpam           = 10 // more than floodLimit messages within floodWindow
+	penaltyDuplicate      = 5  // consensus message sent twice
+
+	banThreshold   = 100
+	scoreHalfLife  = 10 * time.Minute
+	defaultBanTime = time.Hour
+	maxScoredPeers = 1024 // peers whose score is remembered, the least recently penalized are forgotten
+
+	floodWindow = time.Second
+	floodLimit  = 500
+)
+
+var errFlood = errors.New("too many messages")
+
+type score struct {
+	value   float64
+	updated time.Time
+}
+
+// reputation scores the peers on their misbehaviours. The scores outlive the
+// connections so that a peer cannot reset its score by reconnecting.
+type reputation struct {
+	server  *p2p.Server
+	banTime time.Duration
+
+	mu         sync.Mutex
+	scores     *lru.Cache // peer id -> *score
+	validators map[common.Address]bool
+}
+
+func newReputation(server *p2p.Server, banTime time.Duration) *reputation {
+	if banTime == 0 {
+		banTime = defaultBanTime
+	}
+	scores, _ := lru.New(maxScoredPeers)
+	return &reputation{server: server, banTime: banTime, scores: scores}
+}
+
+// penalize lowers the score of the peer, and bans it once the score falls to
+// -banThreshold.
+func (r *reputation) penalize(id string, penalty int, reason error) {
+	r.mu.Lock()
+	now := time.Now()
+	s := r.current(id, now)
+	s.value -= float64(penalty)
+	ban := s.value <= -banThreshold
+	if ban {
+		r.scores.Remove(id)
+	} else {
+		r.scores.Add(id, s)
+	}
+	r.mu.Unlock()
+
+	logs.Debug("Peer %s penalized by %d to %.1f: %v", id, penalty, s.value, reason)
+	if !ban {
+		return
+	}
+	nodeID, err := enode.ParseID(id)
+	if err != nil {
+		logs.Error("Cannot ban peer %s: %v", id, err)
+		return
+	}
+	// Banning a static peer or a validator would cut the node off the peers
+	// it is told to keep or from the consensus, they are only disconnected
+	if r.server.IsStatic(nodeID) || r.isValidator(nodeID) {
+		logs.Warn("Disconnected peer %v without ban: %v", nodeID, reason)
+		r.disconnect(nodeID)
+		return
+	}
+	r.server.BanPeer(nodeID, r.banTime, reason.Error())
+}
+
+// setValidators replaces the validators exempted from bans.
+func (r *reputation) setValidators(validators []common.Address) {
+	set := make(map[common.Address]bool, len(validators))
+	for _, addr := range validators {
+		set[addr] = true
+	}
+	r.mu.Lock()
+	r.validators = set
+	r.mu.Unlock()
+}
+
+// isValidator reports whether the node is connected with the node key of a
+// current validator.
+func (r *reputation) isValidator(id enode.ID) bool {
+	for _, p := range r.server.Peers() {
+		if p.ID() != id {
+			continue
+		}
+		pubkey := p.Node().Pubkey()
+		if pubkey == nil {
+			return false
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.validators[crypto.PubkeyToAddress(*pubkey)]
+	}
+	return false
+}
+
+// disconnect drops the connection to the node, if any.
+func (r *reputation) disconnect(id enode.ID) {
+	for _, p := range r.server.Peers() {
+		if p.ID() == id {
+			p.Disconnect(p2p.DiscUselessPeer)
+		}
+	}
+}
+
+// score returns the current score of the peer, 0 for a well-behaved one.
+func (r *reputation) score(id string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current(id, time.Now()).value
+}
+
+// current returns the score of the peer decayed to now. It is called with mu
+// held.
+func (r *reputation) current(id string, now time.Time) *score {
+	v, ok := r.scores.Get(id)
+	if !ok {
+		return &score{updated: now}
+	}
+	s := v.(*score)
+	s.value *= math.Pow(0.5, float64(now.Sub(s.updated))/float64(scoreHalfLife))
+	s.updated = now
+	return s
+}
+
+// messagePenalty returns the penalty for an error handling a message of the
+// peer, and false if the error is not the peer's fault.
+func messagePenalty(err error) (int, bool) {
+	switch {
+	case errors.Is(err, errDecode), errors.Is(err, errMsgTooLarge), errors.Is(err, errInvalidMsgCode),
+		errors.Is(err, consensus.ErrInvalidMessage):
+		return penaltyInvalidMessage, true
+	case errors.Is(err, consensus.ErrDuplicateMessage):
+		return penaltyDuplicate, true
+	case errors.Is(err, errFlood):
+		return penaltySpam, true
+	}
+	return 0, false
+}
+
+// blockPenalty returns the penalty for a block of the peer failing
+// verification.
+func blockPenalty(err error) int {
+	if errors.Is(err, lbft.ErrFutureBlock) {
+		return penaltyFutureBlock
+	}
+	return penaltyInvalidBlock
+}
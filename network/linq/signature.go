@@ -0,0 +1,91 @@
+package linq
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// serveSignature signs the transfer txHash of a committed block for a peer
+// re-collecting its signatures, and replies without signature if the block or
+// the transfer is unknown or cannot be signed.
+func (h *handler) serveSignature(peer *Peer, query *GetSignaturePacket) {
+	var sig []byte
+	if block := h.blockStore.GetBlockByHash(query.Block); block != nil && h.bridge != nil {
+		params := block.ToCBlock().GetTxParams()
+		for i := range params {
+			if params[i].TxHash != query.TxHash {
+				continue
+			}
+			var err error
+			if sig, err = h.bridge.Sign(&params[i]); err != nil {
+				logs.Debug("Failed to sign transfer %s for peer %s: %v", query.TxHash.Hex(), peer.ID(), err)
+			}
+			break
+		}
+	}
+	if err := peer.ReplySignature(query.RequestID, query.TxHash, sig); err != nil {
+		logs.Debug("Failed to send the signature to peer %s: %v", peer.ID(), err)
+	}
+}
+
+// requestSignatures asks every peer to sign again the transfer txHash of the
+// committed block, and returns the signatures received before ctx is done or
+// all the peers replied.
+func (h *handler) requestSignatures(ctx context.Context, block common.Hash, txHash common.Hash) [][]byte {
+	peers := h.peers.Peers()
+	if len(peers) == 0 {
+		return nil
+	}
+	id := rand.Uint64()
+	ch := make(chan *SignaturePacket, len(peers))
+	h.signatureMu.Lock()
+	h.signatureReqs[id] = ch
+	h.signatureMu.Unlock()
+	defer func() {
+		h.signatureMu.Lock()
+		delete(h.signatureReqs, id)
+		h.signatureMu.Unlock()
+	}()
+
+	pending := 0
+	for _, peer := range peers {
+		if err := peer.RequestSignature(id, block, txHash); err != nil {
+			logs.Debug("Failed to request a signature from peer %s: %v", peer.ID(), err)
+			continue
+		}
+		pending++
+	}
+
+	var sigs [][]byte
+	for ; pending > 0; pending-- {
+		select {
+		case res := <-ch:
+			if res.TxHash == txHash && len(res.Signature) > 0 {
+				sigs = append(sigs, res.Signature)
+			}
+		case <-ctx.Done():
+			return sigs
+		case <-h.quitSync:
+			return sigs
+		}
+	}
+	return sigs
+}
+
+// deliverSignature passes the reply to the pending signature request, and
+// drops unsolicited ones.
+func (h *handler) deliverSignature(res *SignaturePacket) {
+	h.signatureMu.Lock()
+	ch := h.signatureReqs[res.RequestID]
+	h.signatureMu.Unlock()
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- res:
+	default:
+	}
+}
@@ -1,7 +1,6 @@
 package linq
 
 import (
-	"bytes"
 	"fmt"
 	"sort"
 	"sync"
@@ -10,19 +9,20 @@ import (
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
-	"github.com/ethereum/go-ethereum/rlp"
-	"gorm.io/gorm"
 
 	"land-bridge/constant"
+	"land-bridge/handle/dao"
 	"land-bridge/models"
 	"land-bridge/network/linq/txblock"
+	"land-bridge/network/storage"
 	"land-bridge/network/utils"
 )
 
-func NewLinQStore(db *gorm.DB, pool *txblock.BlockPool) *Store {
+func NewLinQStore(db dao.WrapperRepository, chainStore storage.ChainStore, pool *txblock.BlockPool) *Store {
 	ls := &Store{
-		db:   db,
-		pool: pool,
+		db:         db,
+		chainStore: chainStore,
+		pool:       pool,
 	}
 
 	ls.UpdateCurrentBlock()
@@ -32,7 +32,8 @@ func NewLinQStore(db *gorm.DB, pool *txblock.BlockPool) *Store {
 
 type Store struct {
 	currentBlock atomic.Value
-	db           *gorm.DB
+	db           dao.WrapperRepository
+	chainStore   storage.ChainStore
 	pool         *txblock.BlockPool
 
 	// procInterrupt must be atomically called
@@ -41,10 +42,20 @@ type Store struct {
 	chainmu       sync.RWMutex   // blockchain insertion lock
 
 	ChainHeadCh chan<- ChainHeadEvent
+	headDone    <-chan struct{} // closed once nobody reads ChainHeadCh anymore
 }
 
-func (ls *Store) SetHeadCh(ch chan<- ChainHeadEvent) {
+// SetHeadCh sets the channel notified of new chain heads. Notifications are
+// dropped once done is closed.
+func (ls *Store) SetHeadCh(ch chan<- ChainHeadEvent, done <-chan struct{}) {
 	ls.ChainHeadCh = ch
+	ls.headDone = done
+}
+
+// Stop interrupts block insertion and waits for the running one to finish.
+func (ls *Store) Stop() {
+	atomic.StoreInt32(&ls.procInterrupt, 1)
+	ls.wg.Wait()
 }
 
 func (ls *Store) HasBlock(hash common.Hash, number uint64) bool {
@@ -77,14 +88,33 @@ func (ls *Store) InsertChain(blocks utils.Blocks) (int, error) {
 	ls.wg.Done()
 
 	if n > 0 {
-		ls.ChainHeadCh <- ChainHeadEvent{
-			rblock,
+		select {
+		case ls.ChainHeadCh <- ChainHeadEvent{rblock}:
+		case <-ls.headDone:
 		}
 	}
 
 	return n, err
 }
 
+// InsertCheckpoint writes the block an empty chain starts from, without its
+// ancestors. The consensus engine must have imported the snapshot of the block.
+func (ls *Store) InsertCheckpoint(block *utils.Block) error {
+	ls.wg.Add(1)
+	ls.chainmu.Lock()
+	rblock, n, err := ls.insertChain(utils.Blocks{block}, false)
+	ls.chainmu.Unlock()
+	ls.wg.Done()
+
+	if n > 0 {
+		select {
+		case ls.ChainHeadCh <- ChainHeadEvent{rblock}:
+		case <-ls.headDone:
+		}
+	}
+	return err
+}
+
 func (ls *Store) insertChain(chain utils.Blocks, verifySeals bool) (*utils.Block, int, error) {
 
 	if atomic.LoadInt32(&ls.procInterrupt) == 1 {
@@ -97,10 +127,11 @@ func (ls *Store) insertChain(chain utils.Blocks, verifySeals bool) (*utils.Block
 
 	n := 0
 
-	tx := ls.db.Begin()
 	for _, block := range chain {
 
-		ls.pool.Delete(common.Bytes2Hex(block.SrcTx.TxHash))
+		for _, srcTx := range block.Transfers() {
+			ls.pool.Delete(common.Bytes2Hex(srcTx.TxHash))
+		}
 
 		if ls.CheckBlock(block.BlockHash, block.Height) {
 			continue
@@ -108,36 +139,28 @@ func (ls *Store) insertChain(chain utils.Blocks, verifySeals bool) (*utils.Block
 
 		n++
 
-		model := &models.Block{}
-
-		buf := new(bytes.Buffer)
-		err := rlp.Encode(buf, &block)
-		if err != nil {
-			tx.Rollback()
-			logs.Error("insertChain encode err:", err)
-			return nil, 0, err
+		var txHashes []*models.TxHashHistory
+		for _, srcTx := range block.Transfers() {
+			txHashes = append(txHashes, &models.TxHashHistory{
+				ChainID: srcTx.ChainID,
+				TxHash:  common.Bytes2Hex(srcTx.TxHash),
+			})
 		}
-		model.Bytes = common.Bytes2Hex(buf.Bytes())
-		model.Height = block.Height
-		model.BlockHash = block.BlockHash.Hex()
 
-		if err := tx.Create(model).Error; err != nil {
-			tx.Rollback()
-			logs.Error("insertChain create err:", err)
+		// The chain store may not share the database of the bridge. The
+		// transfers are recorded first: a crash before the block is written
+		// leaves them recorded and the block is synced again, whereas the
+		// other order would leave a block whose transfers can be proposed
+		// anew.
+		if err := ls.db.RecordBlockTransfers(block.Height, common.Bytes2Hex(block.BlockHash[:]), txHashes); err != nil {
+			logs.Error("insertChain record block err:", err)
 			return nil, 0, err
 		}
-
-		txhash := &models.TxHashHistory{}
-		txhash.ChainID = block.SrcTx.ChainID
-		txhash.TxHash = common.Bytes2Hex(block.SrcTx.TxHash)
-
-		if err := tx.Create(txhash).Error; err != nil {
-			tx.Rollback()
-			logs.Error("insertTxHash create err:", err)
+		if err := ls.chainStore.WriteBlock(block); err != nil {
+			logs.Error("insertChain write block err:", err)
 			return nil, 0, err
 		}
 	}
-	tx.Commit()
 	ls.UpdateCurrentBlock()
 
 	return chain[len(chain)-1], n, nil
@@ -168,89 +191,46 @@ func (ls *Store) CurrentBlock() *utils.Block {
 }
 
 func (ls *Store) UpdateCurrentBlock() {
-	block := &models.Block{}
-	tx := ls.db.Last(block)
-	if tx.RowsAffected == 1 {
-		var b *utils.Block
-		if len(block.Bytes) > 0 {
-			err := rlp.DecodeBytes(common.Hex2Bytes(block.Bytes), &b)
-			if err != nil {
-				panic("Error bytes decode " + err.Error())
-			}
-		}
-		ls.currentBlock.Store(b)
-		logs.Trace("Update Current Block, now is", b.Height, b.Hash().Hex())
-	} else {
-		panic("Table 'contract.blocks' doesn't exist or empty rows")
+	b := ls.chainStore.ReadHeadBlock()
+	if b == nil {
+		panic("chain store is empty, init the genesis block first")
 	}
+	ls.currentBlock.Store(b)
+	logs.Trace("Update Current Block, now is", b.Height, b.Hash().Hex())
 }
 
 // GetBlock retrieves a block from the database by hash and number.
 func (ls *Store) GetBlock(hash common.Hash, number uint64) *utils.Block {
-	block := new(models.Block)
-	tx := ls.db.Where("block_hash = ? and height = ?", hash.Hex(), number).First(&block)
-	if tx.Error != nil && tx.RowsAffected > 0 {
-		return nil
-	}
-	var b *utils.Block
-	err := rlp.DecodeBytes(common.Hex2Bytes(block.Bytes), &b)
-	if err != nil {
-		//logs.Error("block rlp change error ", err, "block height: ", block.Height)
-		return nil
-	}
-	return b
+	return ls.chainStore.ReadBlock(hash, number)
 }
 
 func (ls *Store) CheckBlock(hash common.Hash, number uint64) bool {
-	block := new(models.Block)
-	tx := ls.db.Where("block_hash = ? and height = ?", hash.Hex(), number).First(&block)
-
-	return tx.Error == nil
+	return ls.chainStore.HasBlock(hash, number)
 }
 
 // GetBlockByNumber retrieves a block from the database by number.
 func (ls *Store) GetBlockByNumber(number uint64) *utils.Block {
-	block := &models.Block{Height: number}
-	if ls.db.Where(block).First(&block).Error != nil {
-		return nil
-	}
-	var b *utils.Block
-	err := rlp.DecodeBytes(common.Hex2Bytes(block.Bytes), &b)
-	if err != nil {
-		return nil
-	}
-	return b
+	return ls.chainStore.ReadBlockByNumber(number)
 }
 
 // GetBlockByHash retrieves a block from the database by its hash.
 func (ls *Store) GetBlockByHash(hash common.Hash) *utils.Block {
-	block := new(models.Block)
-	if ls.db.Model(&block).Where("block_hash = ?", hash.Hex()).First(&block).Error != nil {
-		return nil
-	}
-	var b *utils.Block
-	err := rlp.DecodeBytes(common.Hex2Bytes(block.Bytes), &b)
-	if err != nil {
-		return nil
-	}
-	return b
+	return ls.chainStore.ReadBlockByHash(hash)
 }
 
 // PendingTxs retrieves the pendingTx to deal.
 func (ls *Store) PendingTxs() ([]*models.WrapperTransaction, error) {
-	var wrapperTransaction []*models.WrapperTransaction
-	err := ls.db.Where("status = ?", constant.STATE_SOURCE_DONE).Find(&wrapperTransaction).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return nil, err
-	}
+	return ls.db.GetWrappersByStatus(constant.STATE_SOURCE_DONE)
+}
 
-	return wrapperTransaction, nil
+// PooledTxs retrieves the wrappers which were pushed into the block pool
+// but not yet included in a block, to rebuild the pool on startup.
+func (ls *Store) PooledTxs() ([]*models.WrapperTransaction, error) {
+	return ls.db.GetPooledWrappers()
 }
 
 func (ls *Store) CheckTxHash(hashStr string, chainID uint64) bool {
-	txHashHistory := &models.TxHashHistory{}
-	err := ls.db.Model(txHashHistory).Where("tx_hash = ? and chain_id = ?", hashStr, chainID).First(txHashHistory).Error
-	return err == nil
+	return ls.db.HasTxHash(hashStr, chainID)
 }
 
 // StopInsert interrupts all insertion methods, causing them to return
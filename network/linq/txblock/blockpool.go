@@ -1,27 +1,171 @@
 package txblock
 
 import (
+	"container/heap"
+	"math/big"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"land-bridge/models"
 	"land-bridge/network/bridge"
 )
 
+// ageThreshold is how long a transfer may wait before it is served ahead of
+// better paying ones, so that low fees are delayed but never starved.
+const ageThreshold = 10 * time.Minute
+
 type TxInfo struct {
 	W       *models.WrapperTransaction
 	TxParam *bridge.TxParam
 }
 
+// feeToken identifies the token a fee is paid in. Fees are only comparable
+// when paid in the same token.
+type feeToken struct {
+	chainID uint64
+	hash    string
+}
+
+func (tx *TxInfo) feeToken() feeToken {
+	return feeToken{chainID: tx.W.SrcChainID, hash: strings.ToLower(strings.TrimPrefix(tx.W.FeeTokenHash, "0x"))}
+}
+
+func (tx *TxInfo) fee() *big.Int {
+	if tx.W.FeeAmount == nil {
+		return new(big.Int)
+	}
+	return &tx.W.FeeAmount.Int
+}
+
+type poolEntry struct {
+	key      string
+	info     *TxInfo
+	feeIndex int
+}
+
+// feeHeap orders entries paid in the same token by fee, highest first.
+type feeHeap []*poolEntry
+
+func (h feeHeap) Len() int { return len(h) }
+func (h feeHeap) Less(i, j int) bool {
+	if c := h[i].info.fee().Cmp(h[j].info.fee()); c != 0 {
+		return c > 0
+	}
+	return olderThan(h[i], h[j])
+}
+func (h feeHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].feeIndex = i
+	h[j].feeIndex = j
+}
+func (h *feeHeap) Push(x interface{}) {
+	e := x.(*poolEntry)
+	e.feeIndex = len(*h)
+	*h = append(*h, e)
+}
+func (h *feeHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+func olderThan(a, b *poolEntry) bool {
+	if a.info.W.Time != b.info.W.Time {
+		return a.info.W.Time < b.info.W.Time
+	}
+	return a.key < b.key
+}
+
+// chainQueue holds the pending transfers towards one destination chain, in
+// one fee heap per fee token.
+type chainQueue struct {
+	fees map[feeToken]*feeHeap
+	size int
+}
+
+func newChainQueue() *chainQueue {
+	return &chainQueue{fees: make(map[feeToken]*feeHeap)}
+}
+
+func (q *chainQueue) push(e *poolEntry) {
+	token := e.info.feeToken()
+	h, ok := q.fees[token]
+	if !ok {
+		h = new(feeHeap)
+		q.fees[token] = h
+	}
+	heap.Push(h, e)
+	q.size++
+}
+
+func (q *chainQueue) remove(e *poolEntry) {
+	token := e.info.feeToken()
+	h := q.fees[token]
+	heap.Remove(h, e.feeIndex)
+	if h.Len() == 0 {
+		delete(q.fees, token)
+	}
+	q.size--
+}
+
+// ordered returns the entries of the queue in the order they are served:
+// the aged entries oldest first, then the best paying entry of each fee
+// token, the oldest of those first.
+func (q *chainQueue) ordered(now time.Time) []*poolEntry {
+	aged := func(e *poolEntry) bool {
+		return now.Sub(time.Unix(int64(e.info.W.Time), 0)) > ageThreshold
+	}
+	entries := make([]*poolEntry, 0, q.size)
+	var queues [][]*poolEntry
+	for _, h := range q.fees {
+		var fresh []*poolEntry
+		for _, e := range *h {
+			if aged(e) {
+				entries = append(entries, e)
+			} else {
+				fresh = append(fresh, e)
+			}
+		}
+		if len(fresh) > 0 {
+			sort.Slice(fresh, feeHeap(fresh).Less)
+			queues = append(queues, fresh)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return olderThan(entries[i], entries[j]) })
+
+	for len(queues) > 0 {
+		next := 0
+		for i := 1; i < len(queues); i++ {
+			if olderThan(queues[i][0], queues[next][0]) {
+				next = i
+			}
+		}
+		entries = append(entries, queues[next][0])
+		if queues[next] = queues[next][1:]; len(queues[next]) == 0 {
+			queues = append(queues[:next], queues[next+1:]...)
+		}
+	}
+	return entries
+}
+
+// BlockPool keeps the transfers waiting for a LinQ block. Transfers are queued
+// per destination chain by fee paid, destination chains are served in turn,
+// and transfers older than ageThreshold are served first. Fees paid in
+// different tokens are not compared, such transfers are served by age.
 type BlockPool struct {
-	mux sync.RWMutex
-	m   map[string]*TxInfo
-	l   []string
+	mux    sync.RWMutex
+	m      map[string]*poolEntry
+	chains map[uint64]*chainQueue
+	last   uint64 // destination chain of the last removed transfer
 }
 
 func NewBlockPool() *BlockPool {
 	return &BlockPool{
-		m: make(map[string]*TxInfo),
-		l: []string{},
+		m:      make(map[string]*poolEntry),
+		chains: make(map[uint64]*chainQueue),
 	}
 }
 
@@ -30,7 +174,7 @@ func (p *BlockPool) Get(key string) (bool, *TxInfo) {
 	defer p.mux.RUnlock()
 
 	if v, ok := p.m[key]; ok {
-		return true, v
+		return true, v.info
 	} else {
 		return false, nil
 	}
@@ -39,43 +183,113 @@ func (p *BlockPool) Get(key string) (bool, *TxInfo) {
 func (p *BlockPool) Push(key string, value *TxInfo) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
-	if _, ok := p.m[key]; !ok {
-		p.l = append(p.l, key)
-		p.m[key] = value
+	if _, ok := p.m[key]; ok {
+		return
+	}
+	e := &poolEntry{key: key, info: value}
+	q, ok := p.chains[value.W.DstChainID]
+	if !ok {
+		q = newChainQueue()
+		p.chains[value.W.DstChainID] = q
 	}
+	q.push(e)
+	p.m[key] = e
+}
+
+// sortedChains returns the destination chains with pending transfers, in the
+// order they are served next.
+func (p *BlockPool) sortedChains() []uint64 {
+	ids := make([]uint64, 0, len(p.chains))
+	for id := range p.chains {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	start := sort.Search(len(ids), func(i int) bool { return ids[i] > p.last })
+	return append(ids[start:], ids[:start]...)
 }
 
 func (p *BlockPool) First() *TxInfo {
+	if txs := p.Pending(1); len(txs) > 0 {
+		return txs[0]
+	}
+	return nil
+}
+
+// Pending returns up to n transfers in the order they are served, taking
+// one transfer per destination chain in turn.
+func (p *BlockPool) Pending(n int) []*TxInfo {
 	p.mux.RLock()
 	defer p.mux.RUnlock()
 
-	if len(p.l) > 0 {
-		v, _ := p.m[p.l[0]]
-		return v
-	} else {
-		return nil
+	now := time.Now()
+	var queues [][]*poolEntry
+	for _, id := range p.sortedChains() {
+		queues = append(queues, p.chains[id].ordered(now))
+	}
+	txs := make([]*TxInfo, 0, n)
+	for depth := 0; len(txs) < n; depth++ {
+		added := false
+		for _, q := range queues {
+			if depth < len(q) && len(txs) < n {
+				txs = append(txs, q[depth].info)
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
 	}
+	return txs
 }
 
 func (p *BlockPool) Delete(key string) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
-	if _, ok := p.m[key]; ok {
-		delete(p.m, key)
-		for i, s := range p.l {
-			if s == key {
-				p.l = append(p.l[:i], p.l[i+1:]...)
-				return
-			}
+	e, ok := p.m[key]
+	if !ok {
+		return
+	}
+	delete(p.m, key)
+	id := e.info.W.DstChainID
+	q := p.chains[id]
+	q.remove(e)
+	if q.size == 0 {
+		delete(p.chains, id)
+	}
+	p.last = id
+}
+
+func (p *BlockPool) Len() int {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+
+	return len(p.m)
+}
+
+// Content returns the pending transfers grouped by destination chain, each
+// group in the order it would be served.
+func (p *BlockPool) Content() map[uint64][]*TxInfo {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+
+	now := time.Now()
+	content := make(map[uint64][]*TxInfo, len(p.chains))
+	for id, q := range p.chains {
+		entries := q.ordered(now)
+		txs := make([]*TxInfo, 0, len(entries))
+		for _, e := range entries {
+			txs = append(txs, e.info)
 		}
+		content[id] = txs
 	}
+	return content
 }
 
 func (p *BlockPool) Clean() {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
-	p.m = make(map[string]*TxInfo)
-	p.l = []string{}
+	p.m = make(map[string]*poolEntry)
+	p.chains = make(map[uint64]*chainQueue)
 }
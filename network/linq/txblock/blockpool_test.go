@@ -0,0 +1,147 @@
+package txblock
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"land-bridge/models"
+)
+
+func testTx(hash string, dst uint64, token string, fee int64, age time.Duration) *TxInfo {
+	return &TxInfo{W: &models.WrapperTransaction{
+		Hash:         hash,
+		SrcChainID:   1,
+		DstChainID:   dst,
+		FeeTokenHash: token,
+		FeeAmount:    &models.BigInt{Int: *big.NewInt(fee)},
+		Time:         uint64(time.Now().Add(-age).Unix()),
+	}}
+}
+
+func pending(p *BlockPool) []string {
+	var hashes []string
+	for _, tx := range p.Pending(p.Len()) {
+		hashes = append(hashes, tx.W.Hash)
+	}
+	return hashes
+}
+
+func TestBlockPoolFeeOrder(t *testing.T) {
+	p := NewBlockPool()
+	for _, tx := range []*TxInfo{
+		testTx("a", 2, "0xaa", 1, 3*time.Minute),
+		testTx("b", 2, "0xaa", 5, time.Minute),
+		testTx("c", 2, "0xAA", 3, 2*time.Minute),
+		testTx("d", 2, "aa", 3, 4*time.Minute),
+	} {
+		p.Push(tx.W.Hash, tx)
+	}
+	// the highest fee first, equal fees by age, the token hash in any case
+	want := []string{"b", "d", "c", "a"}
+	if got := pending(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+}
+
+func TestBlockPoolFeeTokens(t *testing.T) {
+	p := NewBlockPool()
+	for _, tx := range []*TxInfo{
+		testTx("a", 2, "0xaa", 1, 3*time.Minute),
+		testTx("b", 2, "0xaa", 10, time.Minute),
+		testTx("c", 2, "0xbb", 1000, 2*time.Minute),
+		testTx("d", 2, "0xbb", 1, 4*time.Minute),
+	} {
+		p.Push(tx.W.Hash, tx)
+	}
+	// fees in different tokens are not comparable, the oldest of the best
+	// paying transfer of each token goes first
+	want := []string{"c", "d", "b", "a"}
+	if got := pending(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+
+	// the same fee token on another chain is another token
+	p = NewBlockPool()
+	other := testTx("e", 2, "0xaa", 1000, time.Minute)
+	other.W.SrcChainID = 3
+	for _, tx := range []*TxInfo{testTx("f", 2, "0xaa", 1, 2*time.Minute), other} {
+		p.Push(tx.W.Hash, tx)
+	}
+	if got, want := pending(p), []string{"f", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+}
+
+func TestBlockPoolAgeFairness(t *testing.T) {
+	p := NewBlockPool()
+	for _, tx := range []*TxInfo{
+		testTx("a", 2, "0xaa", 100, time.Minute),
+		testTx("b", 2, "0xaa", 1, ageThreshold+2*time.Minute),
+		testTx("c", 2, "0xbb", 1, ageThreshold+time.Minute),
+		testTx("d", 2, "0xaa", 50, 2*time.Minute),
+	} {
+		p.Push(tx.W.Hash, tx)
+	}
+	// transfers waiting longer than ageThreshold go first, whatever they pay
+	want := []string{"b", "c", "a", "d"}
+	if got := pending(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+}
+
+func TestBlockPoolChainTurns(t *testing.T) {
+	p := NewBlockPool()
+	for _, tx := range []*TxInfo{
+		testTx("a", 2, "0xaa", 3, time.Minute),
+		testTx("b", 2, "0xaa", 2, time.Minute),
+		testTx("c", 3, "0xaa", 1, time.Minute),
+		testTx("d", 4, "0xaa", 1, time.Minute),
+	} {
+		p.Push(tx.W.Hash, tx)
+	}
+	if got, want := pending(p), []string{"a", "c", "d", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+	// the chain after the one of the last removed transfer goes first
+	p.Delete("c")
+	if got, want := pending(p), []string{"d", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v after a removal, want %v", got, want)
+	}
+}
+
+func TestBlockPoolRebuild(t *testing.T) {
+	txs := []*TxInfo{
+		testTx("a", 2, "0xaa", 3, time.Minute),
+		testTx("b", 4, "0xbb", 7, 2*time.Minute),
+		testTx("c", 3, "0xaa", 1, ageThreshold+time.Minute),
+		testTx("d", 2, "0xaa", 9, 3*time.Minute),
+		testTx("e", 3, "0xaa", 4, time.Minute),
+	}
+	p := NewBlockPool()
+	for _, tx := range txs {
+		p.Push(tx.W.Hash, tx)
+	}
+	p.Push("a", testTx("a", 3, "0xaa", 100, time.Minute))
+	p.Delete("b")
+	want := pending(p)
+
+	// the pool is rebuilt on startup from the stored wrappers, in the order of
+	// the store, and serves the transfers as before
+	p.Clean()
+	if p.Len() != 0 || p.First() != nil {
+		t.Fatal("transfers left after Clean")
+	}
+	for i := len(txs) - 1; i >= 0; i-- {
+		if tx := txs[i]; tx.W.Hash != "b" {
+			p.Push(tx.W.Hash, tx)
+		}
+	}
+	if got := pending(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("rebuilt pool served %v, want %v", got, want)
+	}
+	if ok, tx := p.Get("a"); !ok || tx != txs[0] {
+		t.Errorf("transfer a %v, want the first pushed", tx)
+	}
+}
@@ -23,7 +23,7 @@ const (
 // newWorkReq represents a request for new sealing work submitting with relative interrupt notifier.
 type newWorkReq struct {
 	timestamp int64
-	tx        *txblock.TxInfo
+	txs       []*txblock.TxInfo
 }
 
 type task struct {
@@ -48,6 +48,8 @@ type worker struct {
 	resultCh    chan *utils.Block
 	chainHeadCh chan ChainHeadEvent
 
+	wg sync.WaitGroup // worker loops
+
 	mu           sync.RWMutex // The lock used to protect the coinbase and extra fields
 	pendingMu    sync.RWMutex
 	pendingTasks map[common.Hash]*task
@@ -77,6 +79,9 @@ func newWorker(chain *Store, bridge *bridge.Bridge, pool *txblock.BlockPool, mux
 		chainHeadCh:  make(chan ChainHeadEvent),
 	}
 
+	worker.restorePool()
+
+	worker.wg.Add(5)
 	go worker.mainLoop()
 	go worker.listenLoop()
 	go worker.newWorkLoop()
@@ -99,11 +104,41 @@ func (w *worker) start() {
 	w.startCh <- struct{}{}
 }
 
+// stop halts the consensus engine so nothing new is committed, stops the
+// worker loops and stores the blocks sealed but not inserted yet. The
+// transfers left in the pool keep their pending wrapper status and are
+// restored on the next start.
+func (w *worker) stop() {
+	atomic.StoreInt32(&w.running, 0)
+	if engine, ok := w.engine.(consensus.LBFT); ok {
+		if err := engine.Stop(); err != nil {
+			logs.Warn("stop consensus engine: %v", err)
+		}
+	}
+	close(w.exitCh)
+	w.wg.Wait()
+
+	for {
+		select {
+		case block := <-w.resultCh:
+			if block == nil || w.chain.HasBlock(block.Hash(), block.NumberU64()) {
+				continue
+			}
+			if _, err := w.chain.InsertChain([]*utils.Block{block}); err != nil {
+				logs.Error("store sealed block %d on shutdown: %v", block.NumberU64(), err)
+			}
+		default:
+			return
+		}
+	}
+}
+
 func (w *worker) mainLoop() {
+	defer w.wg.Done()
 	for {
 		select {
 		case req := <-w.newWorkCh:
-			w.commitNewWork(req.timestamp, req.tx)
+			w.commitNewWork(req.timestamp, req.txs)
 		case <-w.exitCh:
 			return
 		}
@@ -111,6 +146,7 @@ func (w *worker) mainLoop() {
 }
 
 func (w *worker) listenLoop() {
+	defer w.wg.Done()
 	td := time.Second
 	t := time.NewTimer(td)
 	for {
@@ -157,7 +193,36 @@ func (w *worker) listenLoop() {
 	}
 }
 
+// restorePool rebuilds the block pool from the wrappers left pending by a
+// previous run.
+func (w *worker) restorePool() {
+	txs, err := w.chain.PooledTxs()
+	if err != nil {
+		logs.Error("restorePool error", err)
+		return
+	}
+	for _, tx := range txs {
+		if w.chain.CheckTxHash(tx.Hash, tx.SrcChainID) {
+			if err := w.bridge.PendingWrapperSkip(tx); err != nil {
+				logs.Error("add skip transaction to Pending")
+			}
+			continue
+		}
+		sign, err := w.bridge.BridgeMakeTx(tx)
+		if err != nil {
+			logs.Error("Failed to restore transaction", tx.Hash, err)
+			continue
+		}
+		w.pool.Push(tx.Hash, &txblock.TxInfo{
+			W:       tx,
+			TxParam: sign,
+		})
+	}
+	logs.Info("block pool restored, %d pending transfers", w.pool.Len())
+}
+
 func (w *worker) taskLoop() {
+	defer w.wg.Done()
 	var (
 		stopCh chan struct{}
 		prev   common.Hash
@@ -198,20 +263,28 @@ func (w *worker) taskLoop() {
 
 // newWorkLoop is a standalone goroutine to submit new mining work upon received events.
 func (w *worker) newWorkLoop() {
+	defer w.wg.Done()
 	var (
 		timestamp int64 // timestamp for each round of mining.
 	)
 	recommit := 3 * time.Second
 	timer := time.NewTimer(recommit)
-	<-timer.C
+	select {
+	case <-timer.C:
+	case <-w.exitCh:
+		return
+	}
 
 	// commit aborts in-flight transaction execution with given signal and resubmits a new one.
 	commit := func() {
-		if tx := w.pool.First(); tx != nil {
+		if txs := w.pool.Pending(utils.MaxBlockTransfers); len(txs) > 0 {
 			atomic.StoreInt32(&w.newTxs, 0)
-			logs.Trace("pool get", tx.W.Hash)
+			logs.Trace("pool get", len(txs))
 			timestamp = time.Now().Unix()
-			w.newWorkCh <- &newWorkReq{timestamp: timestamp, tx: tx}
+			select {
+			case w.newWorkCh <- &newWorkReq{timestamp: timestamp, txs: txs}:
+			case <-w.exitCh:
+			}
 		} else {
 			atomic.StoreInt32(&w.newTxs, 1)
 		}
@@ -257,7 +330,7 @@ func (w *worker) newWorkLoop() {
 }
 
 // commitNewWork generates several new sealing tasks based on the parent block.
-func (w *worker) commitNewWork(timestamp int64, tx *txblock.TxInfo) {
+func (w *worker) commitNewWork(timestamp int64, txs []*txblock.TxInfo) {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
@@ -288,16 +361,21 @@ func (w *worker) commitNewWork(timestamp int64, tx *txblock.TxInfo) {
 		return
 	}
 
-	logs.Trace("fetch transaction: ", tx.W.Hash)
+	logs.Trace("fetch transactions: ", len(txs))
 
-	w.commitTransaction(tx, block)
+	w.commitTransactions(txs, block)
 	w.commit(block)
 }
 
-func (w *worker) commitTransaction(tx *txblock.TxInfo, block *utils.CBlock) {
-	block.SrcTx.ChainID = tx.W.SrcChainID
-	block.SrcTx.TxHash = common.FromHex(tx.W.Hash)
-	block.TxParam = *tx.TxParam
+func (w *worker) commitTransactions(txs []*txblock.TxInfo, block *utils.CBlock) {
+	for _, tx := range txs {
+		block.Txs = append(block.Txs, utils.TxInfo{
+			ChainID: tx.W.SrcChainID,
+			TxHash:  common.FromHex(tx.W.Hash),
+		})
+		block.TxParams = append(block.TxParams, *tx.TxParam)
+	}
+	block.TxRoot = utils.DeriveTxRoot(block.Txs, block.TxParams)
 	block.Type = utils.NFT_CROSS
 }
 
@@ -316,6 +394,7 @@ func (w *worker) commit(block *utils.CBlock) error {
 // resultLoop is a standalone goroutine to handle sealing result submitting
 // and flush relative data to the database.
 func (w *worker) resultLoop() {
+	defer w.wg.Done()
 	for {
 		select {
 		case block := <-w.resultCh:
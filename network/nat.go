@@ -2,21 +2,18 @@ package network
 
 import (
 	"crypto/ecdsa"
-	"os"
-	"os/signal"
-	"syscall"
 
-	"github.com/beego/beego/v2/core/logs"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/urfave/cli"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 
 	"land-bridge/conf"
+	"land-bridge/handle/dao"
 	"land-bridge/network/bridge"
 	"land-bridge/network/linq"
 	"land-bridge/network/linq/txblock"
 	"land-bridge/network/node"
+	"land-bridge/network/storage"
 	"land-bridge/utils"
 )
 
@@ -24,20 +21,18 @@ const (
 	clientIdentifier = "land-bridge" // Client identifier to advertise over the network
 )
 
-func StartNetWork(ctx *cli.Context, conf *conf.Config) error {
+// StartNetWork starts the LinQ node without blocking. The caller owns the
+// node and closes it on shutdown.
+func StartNetWork(ctx *cli.Context, conf *conf.Config) *node.Node {
 	stack, _ := MakeFullNode(ctx, conf)
-
-	defer stack.Close()
-	startNode(stack)
-
-	stack.Wait()
-	return nil
+	if err := stack.Start(); err != nil {
+		utils.Fatalf("Error starting protocol stack: %v", err)
+	}
+	return stack
 }
 
 func dbinit(dbCfg *conf.DBConfig) *gorm.DB {
-	db, err := gorm.Open(mysql.Open(dbCfg.User+":"+dbCfg.Password+"@tcp("+dbCfg.URL+")/"+
-		dbCfg.Scheme+"?charset=utf8"), &gorm.Config{Logger: Nologger{}})
-
+	db, err := dao.OpenDB(dbCfg)
 	if err != nil {
 		panic(err)
 	}
@@ -45,45 +40,29 @@ func dbinit(dbCfg *conf.DBConfig) *gorm.DB {
 	return db
 }
 
-func startNode(stack *node.Node) {
-	if err := stack.Start(); err != nil {
-		utils.Fatalf("Error starting protocol stack: %v", err)
-	}
-
-	go func() {
-		sigc := make(chan os.Signal, 1)
-		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
-		defer signal.Stop(sigc)
-
-		<-sigc
-		logs.Info("Got interrupt, shutting down...")
-		go stack.Close()
-		for i := 5; i > 0; i-- {
-			<-sigc
-			if i > 1 {
-				logs.Warn("Already shutting down, interrupt more to panic.", "times", i-1)
-			}
-		}
-	}()
-}
-
 func MakeFullNode(ctx *cli.Context, conf *conf.Config) (*node.Node, *linq.LinQ) {
 	db := dbinit(conf.DBConfig)
 	stack, cfg := makeConfigNode(ctx, conf)
 	privkey := cfg.Node.NodeKey()
 	privStr := crypto.PubkeyToAddress(*privkey.Public().(*ecdsa.PublicKey)).Hex()
 
-	stack.SetDB(db)
-	stack.Bridge = bridge.NewBridge(db, conf, privkey)
+	repo := dao.NewBridgeDaoWithDB(db)
+	stack.SetDB(repo)
+	chainStore, err := storage.Open(conf.LinQConfig, db)
+	if err != nil {
+		utils.Fatalf("Failed to open the chain store: %v", err)
+	}
+	stack.ChainStore = chainStore
+	stack.Bridge = bridge.NewBridge(repo, conf, privkey)
 	stack.Pool = txblock.NewBlockPool()
 
-	Linq := RegisterPeerService(stack, privStr)
+	Linq := RegisterPeerService(stack, privStr, conf.LinQConfig, conf.Consensus)
 
 	return stack, Linq
 }
 
-func RegisterPeerService(stack *node.Node, privStr string) *linq.LinQ {
-	land, err := linq.New(stack, privStr)
+func RegisterPeerService(stack *node.Node, privStr string, lc *conf.LinQConfig, cc *conf.ConsensusConfig) *linq.LinQ {
+	land, err := linq.New(stack, privStr, lc, cc)
 	if err != nil {
 		utils.Fatalf("Failed to register the Ethereum service: %v", err)
 	}
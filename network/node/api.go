@@ -0,0 +1,116 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"land-bridge/network/p2p"
+	"land-bridge/network/p2p/enode"
+)
+
+// apis returns the collection of built-in RPC APIs.
+func (n *Node) apis() []rpc.API {
+	return []rpc.API{{
+		Namespace: "admin",
+		Version:   "1.0",
+		Service:   &adminAPI{n},
+	}}
+}
+
+// adminAPI is the collection of administrative API methods exposed over
+// both secure and unsecure RPC channels.
+type adminAPI struct {
+	node *Node // Node interfaced by this API
+}
+
+// AddPeer requests connecting to a remote node, and also maintaining the new
+// connection at all times, even reconnecting if it is lost.
+func (api *adminAPI) AddPeer(url string) (bool, error) {
+	server := api.node.Server()
+	// Try to add the url as a static peer and return
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	if !server.AddPeer(node) {
+		return false, ErrNodeStopped
+	}
+	return true, nil
+}
+
+// RemovePeer disconnects from a remote node if the connection exists
+func (api *adminAPI) RemovePeer(url string) (bool, error) {
+	server := api.node.Server()
+	// Try to remove the url as a static peer and return
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	if !server.RemovePeer(node) {
+		return false, ErrNodeStopped
+	}
+	return true, nil
+}
+
+// Peers retrieves all the information we know about each individual peer at the
+// protocol granularity.
+func (api *adminAPI) Peers() ([]*p2p.PeerInfo, error) {
+	server := api.node.Server()
+	if !server.Running() {
+		return nil, ErrNodeStopped
+	}
+	return server.PeersInfo(), nil
+}
+
+// Bans returns the nodes banned for misbehaving, with the end and the reason
+// of each ban.
+func (api *adminAPI) Bans() []*p2p.BanInfo {
+	return api.node.Server().Bans()
+}
+
+// Unban lifts the ban of a node, given by its ID or enode URL. It returns
+// false if the node was not banned.
+func (api *adminAPI) Unban(id string) (bool, error) {
+	nodeID, err := parseNodeID(id)
+	if err != nil {
+		return false, err
+	}
+	return api.node.Server().Unban(nodeID), nil
+}
+
+// ClearBans lifts all the bans and returns their number.
+func (api *adminAPI) ClearBans() int {
+	return api.node.Server().ClearBans()
+}
+
+// parseNodeID accepts a hex node ID or an enode URL.
+func parseNodeID(id string) (enode.ID, error) {
+	if nodeID, err := enode.ParseID(id); err == nil {
+		return nodeID, nil
+	}
+	node, err := enode.Parse(enode.ValidSchemes, id)
+	if err != nil {
+		return enode.ID{}, fmt.Errorf("invalid node ID or enode: %v", err)
+	}
+	return node.ID(), nil
+}
+
+// NodeInfo retrieves all the information we know about the host node at the
+// protocol granularity.
+func (api *adminAPI) NodeInfo() (*p2p.NodeInfo, error) {
+	return api.node.Server().NodeInfo(), nil
+}
+
+// Datadir retrieves the current data directory the node is using.
+func (api *adminAPI) Datadir() string {
+	return api.node.config.DataDir
+}
+
+// Reload re-reads the configuration file, as on SIGHUP.
+func (api *adminAPI) Reload() (bool, error) {
+	if err := api.node.Reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -16,13 +16,30 @@ import (
 )
 
 const (
-	datadirPrivateKey = "nodekey" // Path within the datadir to the node's private key
+	datadirPrivateKey   = "nodekey"   // Path within the datadir to the node's private key
+	datadirNodeDatabase = "nodes"     // Path within the datadir to store the discovered nodes
+	datadirBanDatabase  = "bans.json" // Path within the datadir to store the banned nodes
 )
 
 type Config struct {
 	DataDir string
 	Name    string `json:"name"`
 	P2P     p2p.Config
+
+	// IPCPath is the requested location to place the IPC endpoint. If the path is
+	// a simple file name, it is placed inside the instance directory. An empty
+	// path disables IPC.
+	IPCPath string
+
+	// HTTPHost and HTTPPort are the address of the HTTP RPC endpoint, disabled
+	// when HTTPPort is zero. Every request must carry HTTPAuthToken as a bearer
+	// token.
+	HTTPHost      string
+	HTTPPort      int
+	HTTPAuthToken string
+
+	// WSEnabled serves WebSocket RPC on the HTTP endpoint.
+	WSEnabled bool
 }
 
 // DefaultConfig contains reasonable params settings.
@@ -32,6 +49,8 @@ var DefaultConfig = Config{
 		MaxPeers:   50,
 		ListenAddr: "0.0.0.0:30303",
 	},
+	IPCPath:  "linq.ipc",
+	HTTPHost: "127.0.0.1",
 }
 
 func (c *Config) NodeKey() *ecdsa.PrivateKey {
@@ -69,6 +88,31 @@ func (c *Config) NodeKey() *ecdsa.PrivateKey {
 	return key
 }
 
+// IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
+// account the set data folders as well as the designated platform we're currently
+// running on.
+func (c *Config) IPCEndpoint() string {
+	// Short circuit if IPC has not been enabled
+	if c.IPCPath == "" {
+		return ""
+	}
+	// On windows we can only use plain top-level pipes
+	if runtime.GOOS == "windows" {
+		if strings.HasPrefix(c.IPCPath, `\\.\pipe\`) {
+			return c.IPCPath
+		}
+		return `\\.\pipe\` + c.IPCPath
+	}
+	// Resolve names into the data directory full paths otherwise
+	if filepath.Base(c.IPCPath) == c.IPCPath {
+		if c.DataDir == "" {
+			return filepath.Join(os.TempDir(), c.IPCPath)
+		}
+		return filepath.Join(c.instanceDir(), c.IPCPath)
+	}
+	return c.IPCPath
+}
+
 func (c *Config) NodeName() string {
 	name := c.Name
 	name += "/" + runtime.GOOS + "-" + runtime.GOARCH
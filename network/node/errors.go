@@ -25,6 +25,7 @@ import (
 
 var (
 	ErrDatadirUsed = errors.New("datadir already used by another process")
+	ErrNodeStopped = errors.New("node not started")
 
 	datadirInUseErrnos = map[uint]bool{11: true, 32: true, 35: true}
 )
@@ -1,5 +1,7 @@
 package node
 
+import "context"
+
 type Lifecycle interface {
 	// Start is called after all services have been constructed and the networking
 	// layer was also initialized to spawn any goroutines required by the service.
@@ -9,3 +11,12 @@ type Lifecycle interface {
 	// are all terminated.
 	Stop() error
 }
+
+// GracefulLifecycle is a Lifecycle which can drain its work before stopping.
+// The node calls Shutdown instead of Stop, and the service should give up
+// waiting once ctx is done.
+type GracefulLifecycle interface {
+	Lifecycle
+
+	Shutdown(ctx context.Context) error
+}
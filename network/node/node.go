@@ -1,20 +1,25 @@
 package node
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"path/filepath"
 	"reflect"
 	"sync"
 
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/ethereum/go-ethereum/event"
-	"gorm.io/gorm"
+	"github.com/ethereum/go-ethereum/rpc"
 
+	"land-bridge/handle/dao"
 	"land-bridge/network/bridge"
 	"land-bridge/network/linq/txblock"
 	"land-bridge/network/p2p"
+	"land-bridge/network/storage"
 )
 
 const (
@@ -33,9 +38,17 @@ type Node struct {
 	lifecycles    []Lifecycle // All registered backends, services, and auxiliary services that have a lifecycle
 	lock          sync.Mutex
 
-	db     *gorm.DB
-	Bridge *bridge.Bridge
-	Pool   *txblock.BlockPool
+	rpcAPIs     []rpc.API    // List of APIs currently provided by the node
+	ipcListener net.Listener // IPC RPC listener socket to serve API requests
+	ipcHandler  *rpc.Server  // IPC RPC request handler to process the API requests
+	httpServer  *http.Server // HTTP and WebSocket RPC endpoint
+	httpHandler *rpc.Server  // HTTP and WebSocket RPC request handler
+
+	db         dao.Repository
+	reloader   func() error
+	ChainStore storage.ChainStore
+	Bridge     *bridge.Bridge
+	Pool       *txblock.BlockPool
 }
 
 func NewNode(conf *Config) (*Node, error) {
@@ -57,6 +70,12 @@ func NewNode(conf *Config) (*Node, error) {
 	}
 	node.server.Config.PrivateKey = node.config.NodeKey()
 	node.server.Config.Name = node.config.NodeName()
+	if node.server.Config.NodeDatabase == "" {
+		node.server.Config.NodeDatabase = node.config.ResolvePath(datadirNodeDatabase)
+	}
+	if node.server.Config.BanDatabase == "" {
+		node.server.Config.BanDatabase = node.config.ResolvePath(datadirBanDatabase)
+	}
 
 	if node.server.Config.StaticNodes == nil {
 		node.server.Config.StaticNodes = conf.P2P.BootstrapNodes
@@ -104,19 +123,32 @@ func (n *Node) Start() error {
 
 	logs.Info("server start lifecycles ok")
 
+	if err == nil {
+		if err = n.startRPC(); err != nil {
+			n.stopRPC()
+		}
+	}
 	if err != nil {
-		n.stopServices(started)
+		n.stopServices(context.Background(), started)
 		n.doClose(nil)
 	}
 
 	return err
 }
 
-func (n *Node) stopServices(running []Lifecycle) error {
+func (n *Node) stopServices(ctx context.Context, running []Lifecycle) error {
+	n.stopRPC()
+
 	// Stop running lifecycles in reverse order.
 	failure := &StopError{Services: make(map[reflect.Type]error)}
 	for i := len(running) - 1; i >= 0; i-- {
-		if err := running[i].Stop(); err != nil {
+		var err error
+		if lifecycle, ok := running[i].(GracefulLifecycle); ok {
+			err = lifecycle.Shutdown(ctx)
+		} else {
+			err = running[i].Stop()
+		}
+		if err != nil {
 			failure.Services[reflect.TypeOf(running[i])] = err
 		}
 	}
@@ -130,15 +162,43 @@ func (n *Node) stopServices(running []Lifecycle) error {
 	return nil
 }
 
-func (n *Node) SetDB(db *gorm.DB) {
+// SetReloader sets the function which re-reads the configuration on Reload.
+func (n *Node) SetReloader(fn func() error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.reloader = fn
+}
+
+// Reload re-reads the configuration and applies the parts which can change
+// without restarting p2p or consensus.
+func (n *Node) Reload() error {
+	n.lock.Lock()
+	reloader := n.reloader
+	n.lock.Unlock()
+
+	if reloader == nil {
+		return errors.New("reload not supported")
+	}
+	return reloader()
+}
+
+func (n *Node) SetDB(db dao.Repository) {
 	n.db = db
 }
 
-func (n *Node) GetDB() *gorm.DB {
+func (n *Node) GetDB() dao.Repository {
 	return n.db
 }
 
+// Close stops the node, waiting for the services to drain without a deadline.
 func (n *Node) Close() error {
+	return n.Shutdown(context.Background())
+}
+
+// Shutdown stops the registered services in reverse order, giving them until
+// ctx is done to drain their work, then stops p2p networking and closes the
+// databases.
+func (n *Node) Shutdown(ctx context.Context) error {
 	n.startStopLock.Lock()
 	defer n.startStopLock.Unlock()
 
@@ -147,9 +207,15 @@ func (n *Node) Close() error {
 	n.lock.Unlock()
 
 	switch state {
-	case initializingState, runningState:
+	case initializingState:
 		// The node was never started.
 		return n.doClose(nil)
+	case runningState:
+		var errs []error
+		if err := n.stopServices(ctx, n.lifecycles); err != nil {
+			errs = append(errs, err)
+		}
+		return n.doClose(errs)
 	case closedState:
 		return errors.New("node not started")
 	default:
@@ -163,6 +229,16 @@ func (n *Node) doClose(errs []error) error {
 	// synchronize with OpenDatabase*.
 	n.lock.Lock()
 	n.state = closedState
+	if n.ChainStore != nil {
+		if err := n.ChainStore.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if n.db != nil {
+		if err := n.db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	n.lock.Unlock()
 
 	// Unblock n.Wait.
@@ -233,6 +309,12 @@ func (n *Node) GetNodeKey() *ecdsa.PrivateKey {
 	return n.config.NodeKey()
 }
 
+// ResolvePath returns the absolute path of a resource in the instance
+// directory, or "" when the node runs without a data directory.
+func (n *Node) ResolvePath(path string) string {
+	return n.config.ResolvePath(path)
+}
+
 // EventMux retrieves the event multiplexer used by all the network services in
 // the current protocol stack.
 func (n *Node) EventMux() *event.TypeMux {
@@ -0,0 +1,133 @@
+package node
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcShutdownTimeout bounds the wait for the HTTP requests in flight on stop.
+const rpcShutdownTimeout = 5 * time.Second
+
+// RegisterAPIs registers the RPC APIs of a service on the node.
+func (n *Node) RegisterAPIs(apis []rpc.API) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state != initializingState {
+		panic("can't register APIs on running/stopped node")
+	}
+	n.rpcAPIs = append(n.rpcAPIs, apis...)
+}
+
+// startRPC serves the node and service APIs on the IPC socket and the HTTP
+// and WebSocket endpoint that are configured.
+func (n *Node) startRPC() error {
+	apis := append(n.apis(), n.rpcAPIs...)
+
+	if n.config.IPCPath != "" {
+		endpoint := n.config.IPCEndpoint()
+		if err := os.MkdirAll(filepath.Dir(endpoint), 0700); err != nil {
+			return err
+		}
+		listener, handler, err := rpc.StartIPCEndpoint(endpoint, apis)
+		if err != nil {
+			return fmt.Errorf("IPC endpoint %s: %v", endpoint, err)
+		}
+		n.ipcListener, n.ipcHandler = listener, handler
+		logs.Info("IPC endpoint opened at %s", endpoint)
+	}
+
+	if n.config.HTTPPort > 0 {
+		handler := rpc.NewServer()
+		for _, api := range apis {
+			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+				handler.Stop()
+				return err
+			}
+		}
+		addr := net.JoinHostPort(n.config.HTTPHost, fmt.Sprint(n.config.HTTPPort))
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			handler.Stop()
+			return fmt.Errorf("HTTP endpoint %s: %v", addr, err)
+		}
+		var ws http.Handler
+		if n.config.WSEnabled {
+			ws = handler.WebsocketHandler([]string{"*"})
+		}
+		n.httpServer = &http.Server{Handler: newAuthHandler(n.config.HTTPAuthToken, handler, ws)}
+		n.httpHandler = handler
+		go n.httpServer.Serve(listener)
+		logs.Info("HTTP endpoint opened at http://%s, WebSocket %v", listener.Addr(), n.config.WSEnabled)
+	}
+	return nil
+}
+
+// stopRPC closes the RPC endpoints.
+func (n *Node) stopRPC() {
+	if n.httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), rpcShutdownTimeout)
+		n.httpServer.Shutdown(ctx)
+		cancel()
+		n.httpHandler.Stop()
+		n.httpServer, n.httpHandler = nil, nil
+	}
+	if n.ipcListener != nil {
+		n.ipcListener.Close()
+		n.ipcHandler.Stop()
+		n.ipcListener, n.ipcHandler = nil, nil
+	}
+}
+
+// authHandler requires the bearer token on every request, and passes the
+// WebSocket upgrades to ws if set.
+type authHandler struct {
+	token string
+	rpc   http.Handler
+	ws    http.Handler
+}
+
+func newAuthHandler(token string, rpc, ws http.Handler) http.Handler {
+	return &authHandler{token: token, rpc: rpc, ws: ws}
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="linq"`)
+		http.Error(w, errUnauthorized.Error(), http.StatusUnauthorized)
+		return
+	}
+	if h.ws != nil && isWebsocket(r) {
+		h.ws.ServeHTTP(w, r)
+		return
+	}
+	h.rpc.ServeHTTP(w, r)
+}
+
+var errUnauthorized = errors.New("missing or invalid bearer token")
+
+func (h *authHandler) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+// isWebsocket checks the header of an http request for a websocket upgrade request.
+func isWebsocket(r *http.Request) bool {
+	return strings.ToLower(r.Header.Get("Upgrade")) == "websocket" &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
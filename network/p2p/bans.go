@@ -0,0 +1,146 @@
+package p2p
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+
+	"land-bridge/network/p2p/enode"
+)
+
+// BanInfo describes a node which may not be a peer until the ban expires.
+type BanInfo struct {
+	ID     enode.ID  `json:"id"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"`
+}
+
+// banList is the set of banned nodes. It is written to path, if set, on
+// every change so that the bans outlive a restart.
+type banList struct {
+	path string
+
+	mu   sync.Mutex
+	bans map[enode.ID]*BanInfo
+}
+
+// loadBanList reads the bans saved at path, dropping the expired ones.
+func loadBanList(path string) (*banList, error) {
+	l := &banList{path: path, bans: make(map[enode.ID]*BanInfo)}
+	if path == "" {
+		return l, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bans []*BanInfo
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, ban := range bans {
+		if ban.Until.After(now) {
+			l.bans[ban.ID] = ban
+		}
+	}
+	return l, nil
+}
+
+// banned reports whether the node is banned.
+func (l *banList) banned(id enode.ID) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ban := l.bans[id]
+	return ban != nil && ban.Until.After(time.Now())
+}
+
+// add bans the node until the given time, extending a shorter ban.
+func (l *banList) add(id enode.ID, until time.Time, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if ban := l.bans[id]; ban != nil && ban.Until.After(until) {
+		return
+	}
+	l.bans[id] = &BanInfo{ID: id, Until: until, Reason: reason}
+	l.save()
+}
+
+// remove lifts the ban of the node, reporting whether it was banned.
+func (l *banList) remove(id enode.ID) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.bans[id]; !ok {
+		return false
+	}
+	delete(l.bans, id)
+	l.save()
+	return true
+}
+
+// clear lifts all the bans and returns their number.
+func (l *banList) clear() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := len(l.bans)
+	l.bans = make(map[enode.ID]*BanInfo)
+	l.save()
+	return n
+}
+
+// list returns the bans in force, the ones expiring first at the front.
+func (l *banList) list() []*BanInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bans := make([]*BanInfo, 0, len(l.bans))
+	for id, ban := range l.bans {
+		if !ban.Until.After(now) {
+			delete(l.bans, id)
+			continue
+		}
+		info := *ban
+		bans = append(bans, &info)
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Until.Before(bans[j].Until) })
+	return bans
+}
+
+// save writes the bans to the file through a temporary file, so that a crash
+// leaves either the old or the new list. It is called with mu held.
+func (l *banList) save() {
+	if l.path == "" {
+		return
+	}
+	bans := make([]*BanInfo, 0, len(l.bans))
+	for _, ban := range l.bans {
+		bans = append(bans, ban)
+	}
+	data, err := json.MarshalIndent(bans, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(l.path), 0700)
+	}
+	if err == nil {
+		tmp := l.path + ".tmp"
+		if err = ioutil.WriteFile(tmp, data, 0600); err == nil {
+			err = os.Rename(tmp, l.path)
+		}
+	}
+	if err != nil {
+		logs.Error("Failed to save the banned nodes to %s: %v", l.path, err)
+	}
+}
@@ -2,6 +2,7 @@ package p2p
 
 import (
 	"crypto/ecdsa"
+	"net"
 
 	"github.com/ethereum/go-ethereum/common/mclock"
 
@@ -19,4 +20,20 @@ type Config struct {
 	NetRestrict    *netutil.Netlist
 	clock          mclock.Clock
 	StaticNodes    []*enode.Node
+
+	// NoDiscovery disables the UDP node discovery, which otherwise listens on
+	// ListenAddr and starts from BootstrapNodes.
+	NoDiscovery bool
+
+	// NodeDatabase is the path of the database of discovered nodes. It is
+	// kept in memory when empty.
+	NodeDatabase string
+
+	// BanDatabase is the path of the file persisting the banned nodes. The
+	// bans are kept in memory when empty.
+	BanDatabase string
+
+	// ExternalIP is the IP advertised in the node record. Without it, the
+	// listening IP is advertised if set, or the one peers report.
+	ExternalIP net.IP
 }
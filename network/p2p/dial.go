@@ -79,6 +79,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNetRestrict      = errors.New("not contained in netrestrict list")
+	errNotPermitted     = errors.New("banned or not permitted by the peer filter")
 	errNoPort           = errors.New("node does not provide TCP port")
 )
 
@@ -139,6 +140,9 @@ type dialConfig struct {
 	dialer         NodeDialer
 	clock          mclock.Clock
 	rand           *mrand.Rand
+
+	// allow filters the dynamic dial candidates, disabled if nil.
+	allow func(*enode.Node) bool
 }
 
 func (cfg dialConfig) withDefaults() dialConfig {
@@ -241,6 +245,8 @@ loop:
 		case node := <-nodesCh:
 			if err := d.checkDial(node); err != nil {
 				logs.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IP(), "reason", err)
+			} else if d.allow != nil && !d.allow(node) {
+				logs.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IP(), "reason", errNotPermitted)
 			} else {
 				d.startDial(newDialTask(node, dynDialedConn))
 			}
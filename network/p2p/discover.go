@@ -0,0 +1,148 @@
+package p2p
+
+import (
+	"net"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	gethenode "github.com/ethereum/go-ethereum/p2p/enode"
+	gethenr "github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/p2p/enode"
+	"land-bridge/network/p2p/enr"
+)
+
+// setupDiscV5 starts the UDP node discovery on the listening address. The
+// discovery runs on the go-ethereum discv5 implementation, which signs its own
+// copy of the local node record carrying the same entries as ours.
+func (srv *Server) setupDiscV5() error {
+	addr, err := net.ResolveUDPAddr("udp", srv.ListenAddr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	realaddr := conn.LocalAddr().(*net.UDPAddr)
+	logs.Info("UDP discovery listener up", realaddr)
+
+	db, err := gethenode.OpenDB(srv.NodeDatabase)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	ln := gethenode.NewLocalNode(db, srv.PrivateKey)
+	ln.SetFallbackIP(net.IP{127, 0, 0, 1})
+	ln.SetFallbackUDP(realaddr.Port)
+	srv.localnode.SetFallbackUDP(realaddr.Port)
+	if ip := srv.ExternalIP; ip != nil {
+		ln.SetStaticIP(ip)
+		srv.localnode.SetStaticIP(ip)
+	} else if !realaddr.IP.IsUnspecified() && !realaddr.IP.IsLoopback() {
+		ln.SetStaticIP(realaddr.IP)
+		srv.localnode.SetStaticIP(realaddr.IP)
+	}
+	if tcp := srv.localnode.Node().TCP(); tcp != 0 {
+		ln.Set(gethenr.TCP(tcp))
+	}
+	for _, p := range srv.Protocols {
+		for _, e := range p.Attributes {
+			ln.Set(e)
+		}
+	}
+
+	cfg := discover.Config{
+		PrivateKey: srv.PrivateKey,
+	}
+	for _, n := range srv.BootstrapNodes {
+		bn, err := toDiscoveryNode(n)
+		if err != nil {
+			logs.Warn("Bootstrap node %v unusable for discovery: %v", n.ID(), err)
+			continue
+		}
+		cfg.Bootnodes = append(cfg.Bootnodes, bn)
+	}
+	srv.discV5, err = discover.ListenV5(conn, ln, cfg)
+	if err != nil {
+		conn.Close()
+		db.Close()
+		return err
+	}
+	srv.nodedb = db
+	return nil
+}
+
+// DiscoveryNodes returns an iterator over random nodes found by the UDP
+// discovery, or nil if discovery is disabled or the server is not running.
+func (srv *Server) DiscoveryNodes() enode.Iterator {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if srv.discV5 == nil {
+		return nil
+	}
+	return &discoveryIter{it: srv.discV5.RandomNodes()}
+}
+
+// discoveryIter converts the nodes found by the discovery to local nodes.
+// Nodes whose record does not convert are skipped.
+type discoveryIter struct {
+	it  gethenode.Iterator
+	cur *enode.Node
+}
+
+func (it *discoveryIter) Next() bool {
+	for it.it.Next() {
+		n, err := fromDiscoveryNode(it.it.Node())
+		if err != nil {
+			logs.Debug("Skipping discovered node %v: %v", it.it.Node().ID(), err)
+			continue
+		}
+		it.cur = n
+		return true
+	}
+	it.cur = nil
+	return false
+}
+
+func (it *discoveryIter) Node() *enode.Node {
+	return it.cur
+}
+
+func (it *discoveryIter) Close() {
+	it.it.Close()
+}
+
+// toDiscoveryNode converts a local node to a discovery node. A signed record
+// is carried over as is, a node parsed from an enode URL only has its key and
+// endpoint.
+func toDiscoveryNode(n *enode.Node) (*gethenode.Node, error) {
+	if n.Record().Signature() == nil {
+		return gethenode.NewV4(n.Pubkey(), n.IP(), n.TCP(), n.UDP()), nil
+	}
+	blob, err := rlp.EncodeToBytes(n.Record())
+	if err != nil {
+		return nil, err
+	}
+	var r gethenr.Record
+	if err := rlp.DecodeBytes(blob, &r); err != nil {
+		return nil, err
+	}
+	return gethenode.New(gethenode.ValidSchemes, &r)
+}
+
+// fromDiscoveryNode converts a discovered node to a local node, checking the
+// signature of its record again.
+func fromDiscoveryNode(n *gethenode.Node) (*enode.Node, error) {
+	blob, err := rlp.EncodeToBytes(n.Record())
+	if err != nil {
+		return nil, err
+	}
+	var r enr.Record
+	if err := rlp.DecodeBytes(blob, &r); err != nil {
+		return nil, err
+	}
+	return enode.New(enode.ValidSchemes, &r)
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"net"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/beego/beego/v2/core/logs"
@@ -15,6 +17,8 @@ import (
 	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	gethenode "github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 
 	"land-bridge/network/p2p/enode"
@@ -62,7 +66,15 @@ type Server struct {
 	loopWG       sync.WaitGroup // loop, listenLoop
 	peerFeed     event.Feed
 
+	peerFilter atomic.Value // func(*enode.Node) bool, admits every peer if unset
+	bans       *banList     // loaded on start
+
+	staticMu sync.RWMutex
+	static   map[enode.ID]bool // static nodes, admitted whatever the peer filter
+
 	localnode *enode.LocalNode
+	nodedb    *gethenode.DB
+	discV5    *discover.UDPv5
 	discmix   *enode.FairMix
 	dialsched *dialScheduler
 
@@ -115,6 +127,13 @@ func (srv *Server) Start() error {
 	if err := srv.setupLocalNode(); err != nil {
 		return err
 	}
+	if srv.bans == nil {
+		bans, err := loadBanList(srv.BanDatabase)
+		if err != nil {
+			return fmt.Errorf("ban list %s: %v", srv.BanDatabase, err)
+		}
+		srv.bans = bans
+	}
 	if srv.ListenAddr != "" {
 		if err := srv.setupListening(); err != nil {
 			return err
@@ -204,6 +223,7 @@ func (srv *Server) setupDialScheduler() {
 		maxDialPeers:   99,
 		maxActiveDials: 99,
 		netRestrict:    srv.NetRestrict,
+		allow:          srv.allowPeer,
 		clock:          srv.clock,
 	}
 
@@ -212,6 +232,7 @@ func (srv *Server) setupDialScheduler() {
 	}
 	srv.dialsched = newDialScheduler(config, srv.discmix, srv.SetupConn)
 	for _, n := range srv.StaticNodes {
+		srv.setStatic(n.ID(), true)
 		srv.dialsched.addStatic(n)
 	}
 }
@@ -248,11 +269,184 @@ func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount in
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case srv.bans.banned(c.node.ID()):
+		return DiscUselessPeer
+	case !srv.allowPeer(c.node):
+		return DiscUnexpectedIdentity
 	default:
 		return nil
 	}
 }
 
+// SetPeerFilter restricts the peers to the nodes fn admits, inbound and
+// outbound, besides the static nodes. Connected peers it rejects are
+// disconnected, so the filter is set again whenever the nodes it admits
+// change.
+func (srv *Server) SetPeerFilter(fn func(*enode.Node) bool) {
+	srv.peerFilter.Store(fn)
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		for _, p := range peers {
+			if !srv.IsStatic(p.ID()) && !fn(p.Node()) {
+				logs.Info("Disconnecting peer %v no longer permitted", p.ID())
+				p.Disconnect(DiscUnexpectedIdentity)
+			}
+		}
+	})
+}
+
+// Running reports whether the server is running.
+func (srv *Server) Running() bool {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	return srv.running
+}
+
+// doPeerOp runs op on the peer set in the run loop. It returns false if the
+// server is not running.
+func (srv *Server) doPeerOp(op peerOpFunc) bool {
+	if !srv.Running() {
+		return false
+	}
+	select {
+	case srv.peerOp <- op:
+		<-srv.peerOpDone
+		return true
+	case <-srv.quit:
+		return false
+	}
+}
+
+// Peers returns all connected peers.
+func (srv *Server) Peers() []*Peer {
+	var ps []*Peer
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		for _, p := range peers {
+			ps = append(ps, p)
+		}
+	})
+	return ps
+}
+
+// PeerCount returns the number of connected peers.
+func (srv *Server) PeerCount() int {
+	var count int
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		count = len(peers)
+	})
+	return count
+}
+
+// PeersInfo returns an array of metadata objects describing connected peers.
+func (srv *Server) PeersInfo() []*PeerInfo {
+	infos := make([]*PeerInfo, 0)
+	for _, peer := range srv.Peers() {
+		if peer != nil {
+			infos = append(infos, peer.Info())
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// AddPeer adds the given node to the static node set. The server keeps
+// connected to it, reconnecting when the connection fails.
+func (srv *Server) AddPeer(node *enode.Node) bool {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	if !srv.running {
+		return false
+	}
+	srv.setStatic(node.ID(), true)
+	srv.dialsched.addStatic(node)
+	return true
+}
+
+// RemovePeer removes a node from the static node set and disconnects it if
+// connected.
+func (srv *Server) RemovePeer(node *enode.Node) bool {
+	if !srv.Running() {
+		return false
+	}
+	srv.setStatic(node.ID(), false)
+	srv.dialsched.removeStatic(node)
+	return srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		if p := peers[node.ID()]; p != nil {
+			p.Disconnect(DiscRequested)
+		}
+	})
+}
+
+// BanPeer bans the node for the given duration and disconnects it. The ban
+// is kept across restarts. It returns false if the server was never started.
+func (srv *Server) BanPeer(id enode.ID, duration time.Duration, reason string) bool {
+	if srv.bans == nil {
+		return false
+	}
+	srv.bans.add(id, time.Now().Add(duration), reason)
+	logs.Warn("Banned peer %v for %v: %s", id, duration, reason)
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		if p := peers[id]; p != nil {
+			p.Disconnect(DiscUselessPeer)
+		}
+	})
+	return true
+}
+
+// Bans returns the bans in force.
+func (srv *Server) Bans() []*BanInfo {
+	if srv.bans == nil {
+		return []*BanInfo{}
+	}
+	return srv.bans.list()
+}
+
+// Unban lifts the ban of the node, reporting whether it was banned.
+func (srv *Server) Unban(id enode.ID) bool {
+	return srv.bans != nil && srv.bans.remove(id)
+}
+
+// ClearBans lifts all the bans and returns their number.
+func (srv *Server) ClearBans() int {
+	if srv.bans == nil {
+		return 0
+	}
+	return srv.bans.clear()
+}
+
+// allowPeer reports whether the node may be dialed or accepted: it is not
+// banned and passes the peer filter.
+func (srv *Server) allowPeer(n *enode.Node) bool {
+	if srv.bans.banned(n.ID()) {
+		return false
+	}
+	if srv.IsStatic(n.ID()) {
+		return true
+	}
+	fn, _ := srv.peerFilter.Load().(func(*enode.Node) bool)
+	return fn == nil || fn(n)
+}
+
+// IsStatic reports whether the node is a static peer, from StaticNodes or
+// added with AddPeer.
+func (srv *Server) IsStatic(id enode.ID) bool {
+	srv.staticMu.RLock()
+	defer srv.staticMu.RUnlock()
+	return srv.static[id]
+}
+
+func (srv *Server) setStatic(id enode.ID, static bool) {
+	srv.staticMu.Lock()
+	defer srv.staticMu.Unlock()
+	if srv.static == nil {
+		srv.static = make(map[enode.ID]bool)
+	}
+	if static {
+		srv.static[id] = true
+	} else {
+		delete(srv.static, id)
+	}
+}
+
 func (srv *Server) setupLocalNode() error {
 	// Create the devp2p handshake.
 	pubkey := crypto.FromECDSAPub(&srv.PrivateKey.PublicKey)
@@ -277,6 +471,12 @@ func (srv *Server) setupLocalNode() error {
 func (srv *Server) setupDiscovery() error {
 	srv.discmix = enode.NewFairMix(discmixTimeout)
 
+	if !srv.NoDiscovery && srv.ListenAddr != "" {
+		if err := srv.setupDiscV5(); err != nil {
+			return err
+		}
+	}
+
 	// Add protocol-specific discovery sources.
 	added := make(map[string]bool)
 	for _, proto := range srv.Protocols {
@@ -520,6 +720,17 @@ func (srv *Server) Stop() {
 	close(srv.quit)
 	srv.lock.Unlock()
 	srv.loopWG.Wait()
+
+	srv.lock.Lock()
+	if srv.discV5 != nil {
+		srv.discV5.Close()
+		srv.discV5 = nil
+	}
+	if srv.nodedb != nil {
+		srv.nodedb.Close()
+		srv.nodedb = nil
+	}
+	srv.lock.Unlock()
 }
 
 // Self returns the local node's endpoint information.
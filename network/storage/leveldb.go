@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/utils"
+)
+
+const (
+	leveldbCache   = 64 // megabytes
+	leveldbHandles = 256
+)
+
+var (
+	headBlockKey = []byte("LastBlock")
+
+	blockPrefix       = []byte("b") // blockPrefix + num (uint64 big endian) + hash -> block rlp
+	blockNumberPrefix = []byte("H") // blockNumberPrefix + hash -> num (uint64 big endian)
+	canonicalPrefix   = []byte("n") // canonicalPrefix + num (uint64 big endian) -> hash
+	snapshotPrefix    = []byte("s") // snapshotPrefix + hash -> snapshot json
+	evidencePrefix    = []byte("e") // evidencePrefix + hash -> evidence rlp
+)
+
+func encodeBlockNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+func blockKey(number uint64, hash common.Hash) []byte {
+	return append(append(append([]byte{}, blockPrefix...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+func blockNumberKey(hash common.Hash) []byte {
+	return append(append([]byte{}, blockNumberPrefix...), hash.Bytes()...)
+}
+
+func canonicalKey(number uint64) []byte {
+	return append(append([]byte{}, canonicalPrefix...), encodeBlockNumber(number)...)
+}
+
+func snapshotKey(hash common.Hash) []byte {
+	return append(append([]byte{}, snapshotPrefix...), hash.Bytes()...)
+}
+
+// leveldbStore keeps chain data in an embedded LevelDB database.
+type leveldbStore struct {
+	db ethdb.KeyValueStore
+}
+
+func NewLevelDBStore(path string) (ChainStore, error) {
+	db, err := leveldb.New(path, leveldbCache, leveldbHandles, "linq/chaindata/", false)
+	if err != nil {
+		return nil, err
+	}
+	return &leveldbStore{db: db}, nil
+}
+
+func (s *leveldbStore) decodeBlock(data []byte) *utils.Block {
+	var b *utils.Block
+	if err := rlp.DecodeBytes(data, &b); err != nil {
+		return nil
+	}
+	return b
+}
+
+func (s *leveldbStore) HasBlock(hash common.Hash, number uint64) bool {
+	ok, _ := s.db.Has(blockKey(number, hash))
+	return ok
+}
+
+func (s *leveldbStore) ReadBlock(hash common.Hash, number uint64) *utils.Block {
+	data, err := s.db.Get(blockKey(number, hash))
+	if err != nil {
+		return nil
+	}
+	return s.decodeBlock(data)
+}
+
+func (s *leveldbStore) ReadBlockByNumber(number uint64) *utils.Block {
+	data, err := s.db.Get(canonicalKey(number))
+	if err != nil {
+		return nil
+	}
+	return s.ReadBlock(common.BytesToHash(data), number)
+}
+
+func (s *leveldbStore) ReadBlockByHash(hash common.Hash) *utils.Block {
+	data, err := s.db.Get(blockNumberKey(hash))
+	if err != nil || len(data) != 8 {
+		return nil
+	}
+	return s.ReadBlock(hash, binary.BigEndian.Uint64(data))
+}
+
+func (s *leveldbStore) ReadHeadBlock() *utils.Block {
+	data, err := s.db.Get(headBlockKey)
+	if err != nil {
+		return nil
+	}
+	return s.ReadBlockByHash(common.BytesToHash(data))
+}
+
+func (s *leveldbStore) WriteBlock(block *utils.Block) error {
+	data, err := rlp.EncodeToBytes(&block)
+	if err != nil {
+		return err
+	}
+	hash, number := block.Hash(), block.NumberU64()
+
+	batch := s.db.NewBatch()
+	batch.Put(blockKey(number, hash), data)
+	batch.Put(blockNumberKey(hash), encodeBlockNumber(number))
+	batch.Put(canonicalKey(number), hash.Bytes())
+	if head := s.ReadHeadBlock(); head == nil || head.NumberU64() <= number {
+		batch.Put(headBlockKey, hash.Bytes())
+	}
+	return batch.Write()
+}
+
+func (s *leveldbStore) IterateBlocks(fn func(block *utils.Block) error) error {
+	it := s.db.NewIterator(blockPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		if len(it.Key()) != len(blockPrefix)+8+common.HashLength {
+			continue
+		}
+		if b := s.decodeBlock(it.Value()); b != nil {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
+
+func (s *leveldbStore) ReadSnapshot(hash common.Hash) ([]byte, error) {
+	return s.db.Get(snapshotKey(hash))
+}
+
+func (s *leveldbStore) WriteSnapshot(hash common.Hash, blob []byte) error {
+	return s.db.Put(snapshotKey(hash), blob)
+}
+
+func (s *leveldbStore) IterateSnapshots(fn func(hash common.Hash, blob []byte) error) error {
+	it := s.db.NewIterator(snapshotPrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		if len(it.Key()) != len(snapshotPrefix)+common.HashLength {
+			continue
+		}
+		if err := fn(common.BytesToHash(it.Key()[len(snapshotPrefix):]), common.CopyBytes(it.Value())); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func evidenceKey(hash common.Hash) []byte {
+	return append(append([]byte{}, evidencePrefix...), hash.Bytes()...)
+}
+
+func (s *leveldbStore) HasEvidence(hash common.Hash) bool {
+	ok, _ := s.db.Has(evidenceKey(hash))
+	return ok
+}
+
+func (s *leveldbStore) WriteEvidence(hash common.Hash, blob []byte) error {
+	return s.db.Put(evidenceKey(hash), blob)
+}
+
+func (s *leveldbStore) IterateEvidence(fn func(hash common.Hash, blob []byte) error) error {
+	it := s.db.NewIterator(evidencePrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		if len(it.Key()) != len(evidencePrefix)+common.HashLength {
+			continue
+		}
+		if err := fn(common.BytesToHash(it.Key()[len(evidencePrefix):]), common.CopyBytes(it.Value())); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (s *leveldbStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/utils"
+)
+
+// memoryStore keeps chain data in memory, for running a node without a
+// database. Blocks are stored RLP encoded so readers never share them.
+type memoryStore struct {
+	mu        sync.RWMutex
+	blocks    map[common.Hash][]byte
+	numbers   map[common.Hash]uint64
+	canonical map[uint64]common.Hash
+	head      common.Hash
+	snapshots map[common.Hash][]byte
+	evidence  map[common.Hash][]byte
+}
+
+func NewMemoryStore() ChainStore {
+	return &memoryStore{
+		blocks:    make(map[common.Hash][]byte),
+		numbers:   make(map[common.Hash]uint64),
+		canonical: make(map[uint64]common.Hash),
+		snapshots: make(map[common.Hash][]byte),
+		evidence:  make(map[common.Hash][]byte),
+	}
+}
+
+func (s *memoryStore) readBlock(hash common.Hash) *utils.Block {
+	data, ok := s.blocks[hash]
+	if !ok {
+		return nil
+	}
+	var b *utils.Block
+	if err := rlp.DecodeBytes(data, &b); err != nil {
+		return nil
+	}
+	return b
+}
+
+func (s *memoryStore) HasBlock(hash common.Hash, number uint64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n, ok := s.numbers[hash]
+	return ok && n == number
+}
+
+func (s *memoryStore) ReadBlock(hash common.Hash, number uint64) *utils.Block {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if n, ok := s.numbers[hash]; !ok || n != number {
+		return nil
+	}
+	return s.readBlock(hash)
+}
+
+func (s *memoryStore) ReadBlockByNumber(number uint64) *utils.Block {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, ok := s.canonical[number]
+	if !ok {
+		return nil
+	}
+	return s.readBlock(hash)
+}
+
+func (s *memoryStore) ReadBlockByHash(hash common.Hash) *utils.Block {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.readBlock(hash)
+}
+
+func (s *memoryStore) ReadHeadBlock() *utils.Block {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.readBlock(s.head)
+}
+
+func (s *memoryStore) WriteBlock(block *utils.Block) error {
+	data, err := rlp.EncodeToBytes(&block)
+	if err != nil {
+		return err
+	}
+	hash, number := block.Hash(), block.NumberU64()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.canonical[number]; ok {
+		return fmt.Errorf("block #%d already exists", number)
+	}
+	s.blocks[hash] = data
+	s.numbers[hash] = number
+	s.canonical[number] = hash
+	if head, ok := s.numbers[s.head]; !ok || head <= number {
+		s.head = hash
+	}
+	return nil
+}
+
+func (s *memoryStore) IterateBlocks(fn func(block *utils.Block) error) error {
+	s.mu.RLock()
+	numbers := make([]uint64, 0, len(s.canonical))
+	for number := range s.canonical {
+		numbers = append(numbers, number)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for _, number := range numbers {
+		if b := s.ReadBlockByNumber(number); b != nil {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) ReadSnapshot(hash common.Hash) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blob, ok := s.snapshots[hash]
+	if !ok {
+		return nil, fmt.Errorf("snapshot %s not found", hash.Hex())
+	}
+	return common.CopyBytes(blob), nil
+}
+
+func (s *memoryStore) WriteSnapshot(hash common.Hash, blob []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots[hash] = common.CopyBytes(blob)
+	return nil
+}
+
+func (s *memoryStore) IterateSnapshots(fn func(hash common.Hash, blob []byte) error) error {
+	s.mu.RLock()
+	snapshots := make(map[common.Hash][]byte, len(s.snapshots))
+	for hash, blob := range s.snapshots {
+		snapshots[hash] = blob
+	}
+	s.mu.RUnlock()
+
+	for hash, blob := range snapshots {
+		if err := fn(hash, common.CopyBytes(blob)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) HasEvidence(hash common.Hash) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.evidence[hash]
+	return ok
+}
+
+func (s *memoryStore) WriteEvidence(hash common.Hash, blob []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evidence[hash] = common.CopyBytes(blob)
+	return nil
+}
+
+func (s *memoryStore) IterateEvidence(fn func(hash common.Hash, blob []byte) error) error {
+	s.mu.RLock()
+	evidence := make(map[common.Hash][]byte, len(s.evidence))
+	for hash, blob := range s.evidence {
+		evidence[hash] = blob
+	}
+	s.mu.RUnlock()
+
+	for hash, blob := range evidence {
+		if err := fn(hash, common.CopyBytes(blob)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"gorm.io/gorm"
+
+	"land-bridge/models"
+	"land-bridge/network/utils"
+)
+
+// mysqlStore keeps blocks as hex encoded RLP in the blocks table,
+// snapshots as JSON in the snapshots table and evidence as hex encoded RLP
+// in the evidences table.
+type mysqlStore struct {
+	db *gorm.DB
+}
+
+func NewMySQLStore(db *gorm.DB) ChainStore {
+	return &mysqlStore{db: db}
+}
+
+func decodeBlockModel(model *models.Block) *utils.Block {
+	if len(model.Bytes) == 0 {
+		return nil
+	}
+	var b *utils.Block
+	if err := rlp.DecodeBytes(common.Hex2Bytes(model.Bytes), &b); err != nil {
+		return nil
+	}
+	return b
+}
+
+func (s *mysqlStore) HasBlock(hash common.Hash, number uint64) bool {
+	block := new(models.Block)
+	return s.db.Where("block_hash = ? and height = ?", hash.Hex(), number).First(block).Error == nil
+}
+
+func (s *mysqlStore) ReadBlock(hash common.Hash, number uint64) *utils.Block {
+	block := new(models.Block)
+	if s.db.Where("block_hash = ? and height = ?", hash.Hex(), number).First(block).Error != nil {
+		return nil
+	}
+	return decodeBlockModel(block)
+}
+
+func (s *mysqlStore) ReadBlockByNumber(number uint64) *utils.Block {
+	block := &models.Block{}
+	if s.db.Where("height = ?", number).First(block).Error != nil {
+		return nil
+	}
+	return decodeBlockModel(block)
+}
+
+func (s *mysqlStore) ReadBlockByHash(hash common.Hash) *utils.Block {
+	block := new(models.Block)
+	if s.db.Where("block_hash = ?", hash.Hex()).First(block).Error != nil {
+		return nil
+	}
+	return decodeBlockModel(block)
+}
+
+func (s *mysqlStore) ReadHeadBlock() *utils.Block {
+	block := &models.Block{}
+	if s.db.Order("height desc").First(block).Error != nil {
+		return nil
+	}
+	return decodeBlockModel(block)
+}
+
+func (s *mysqlStore) WriteBlock(block *utils.Block) error {
+	buf := new(bytes.Buffer)
+	if err := rlp.Encode(buf, &block); err != nil {
+		return err
+	}
+	model := &models.Block{
+		BlockHash: block.Hash().Hex(),
+		Height:    block.Height,
+		Bytes:     common.Bytes2Hex(buf.Bytes()),
+	}
+	return s.db.Create(model).Error
+}
+
+func (s *mysqlStore) IterateBlocks(fn func(block *utils.Block) error) error {
+	const batchSize = 1000
+	next := uint64(0)
+	for {
+		var blocks []*models.Block
+		if err := s.db.Where("height >= ?", next).Order("height").Limit(batchSize).Find(&blocks).Error; err != nil {
+			return err
+		}
+		for _, model := range blocks {
+			if b := decodeBlockModel(model); b != nil {
+				if err := fn(b); err != nil {
+					return err
+				}
+			}
+			next = model.Height + 1
+		}
+		if len(blocks) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (s *mysqlStore) ReadSnapshot(hash common.Hash) ([]byte, error) {
+	ssModel := &models.Snapshot{}
+	if err := s.db.Where("hash = ?", hash.Hex()).First(ssModel).Error; err != nil {
+		return nil, err
+	}
+	return []byte(ssModel.Bytes), nil
+}
+
+func (s *mysqlStore) WriteSnapshot(hash common.Hash, blob []byte) error {
+	ssModel := &models.Snapshot{}
+	if err := s.db.Where("hash = ?", hash.Hex()).First(ssModel).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	ssModel.Hash = hash.Hex()
+	ssModel.Bytes = string(blob)
+	return s.db.Save(ssModel).Error
+}
+
+func (s *mysqlStore) IterateSnapshots(fn func(hash common.Hash, blob []byte) error) error {
+	var snapshots []*models.Snapshot
+	return s.db.FindInBatches(&snapshots, 1000, func(tx *gorm.DB, batch int) error {
+		for _, model := range snapshots {
+			if err := fn(common.HexToHash(model.Hash), []byte(model.Bytes)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+func (s *mysqlStore) HasEvidence(hash common.Hash) bool {
+	var count int64
+	s.db.Model(&models.Evidence{}).Where("hash = ?", hash.Hex()).Count(&count)
+	return count > 0
+}
+
+func (s *mysqlStore) WriteEvidence(hash common.Hash, blob []byte) error {
+	return s.db.Create(&models.Evidence{
+		Hash:  hash.Hex(),
+		Bytes: common.Bytes2Hex(blob),
+	}).Error
+}
+
+func (s *mysqlStore) IterateEvidence(fn func(hash common.Hash, blob []byte) error) error {
+	var evidence []*models.Evidence
+	return s.db.FindInBatches(&evidence, 1000, func(tx *gorm.DB, batch int) error {
+		for _, model := range evidence {
+			if err := fn(common.HexToHash(model.Hash), common.Hex2Bytes(model.Bytes)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// Close leaves the shared database connection open.
+func (s *mysqlStore) Close() error {
+	return nil
+}
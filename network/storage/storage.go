@@ -0,0 +1,108 @@
+// Package storage persists LinQ blocks, LBFT snapshots and equivocation evidence.
+package storage
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+
+	"land-bridge/conf"
+	"land-bridge/network/utils"
+)
+
+const (
+	MySQL   = "mysql"
+	LevelDB = "leveldb"
+	Memory  = "memory"
+
+	DefaultChainData = "./chaindata"
+)
+
+// ChainStore is the chain data backend of a node. Relational bridge data
+// (wrappers, events, tx hash history) always stays in MySQL.
+type ChainStore interface {
+	HasBlock(hash common.Hash, number uint64) bool
+	ReadBlock(hash common.Hash, number uint64) *utils.Block
+	ReadBlockByNumber(number uint64) *utils.Block
+	ReadBlockByHash(hash common.Hash) *utils.Block
+	// ReadHeadBlock returns the block with the highest number, nil if empty.
+	ReadHeadBlock() *utils.Block
+	WriteBlock(block *utils.Block) error
+	// IterateBlocks calls fn for every block in ascending number order.
+	IterateBlocks(fn func(block *utils.Block) error) error
+
+	ReadSnapshot(hash common.Hash) ([]byte, error)
+	WriteSnapshot(hash common.Hash, blob []byte) error
+	// IterateSnapshots calls fn for every stored snapshot.
+	IterateSnapshots(fn func(hash common.Hash, blob []byte) error) error
+
+	HasEvidence(hash common.Hash) bool
+	WriteEvidence(hash common.Hash, blob []byte) error
+	// IterateEvidence calls fn for every stored equivocation evidence.
+	IterateEvidence(fn func(hash common.Hash, blob []byte) error) error
+
+	Close() error
+}
+
+// Open opens the chain store selected by the LinQ configuration. The MySQL
+// backend shares db, the LevelDB backend lives in LinQConfig.ChainData.
+func Open(cfg *conf.LinQConfig, db *gorm.DB) (ChainStore, error) {
+	backend, path := MySQL, DefaultChainData
+	if cfg != nil {
+		if cfg.ChainStore != "" {
+			backend = cfg.ChainStore
+		}
+		if cfg.ChainData != "" {
+			path = cfg.ChainData
+		}
+	}
+	return OpenBackend(backend, path, db)
+}
+
+// OpenBackend opens the named chain store backend.
+func OpenBackend(backend string, path string, db *gorm.DB) (ChainStore, error) {
+	switch backend {
+	case MySQL:
+		if db == nil {
+			return nil, fmt.Errorf("mysql chain store needs a database connection")
+		}
+		return NewMySQLStore(db), nil
+	case LevelDB:
+		return NewLevelDBStore(path)
+	case Memory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown chain store %q", backend)
+	}
+}
+
+// Migrate copies every block, snapshot and equivocation evidence of from into
+// to, and counts those written.
+func Migrate(from ChainStore, to ChainStore) (blocks int, snapshots int, evidence int, err error) {
+	err = from.IterateBlocks(func(block *utils.Block) error {
+		if to.HasBlock(block.Hash(), block.NumberU64()) {
+			return nil
+		}
+		blocks++
+		return to.WriteBlock(block)
+	})
+	if err != nil {
+		return
+	}
+	err = from.IterateSnapshots(func(hash common.Hash, blob []byte) error {
+		snapshots++
+		return to.WriteSnapshot(hash, blob)
+	})
+	if err != nil {
+		return
+	}
+	err = from.IterateEvidence(func(hash common.Hash, blob []byte) error {
+		if to.HasEvidence(hash) {
+			return nil
+		}
+		evidence++
+		return to.WriteEvidence(hash, blob)
+	})
+	return
+}
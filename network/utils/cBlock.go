@@ -34,6 +34,11 @@ type CBlock struct {
 	Type       TxType         `json:"type"`
 	SrcTx      TxInfo         `json:"srcTx"`
 
+	// Transfers of a multi-transfer block, committed to by TxRoot.
+	// SrcTx is only used by single-transfer blocks.
+	TxRoot common.Hash `json:"txRoot"`
+	Txs    []TxInfo    `json:"txs"`
+
 	// For consensus(lbft: vote)
 	CBytes LBFTBytes `json:"cBytes"`
 
@@ -41,7 +46,8 @@ type CBlock struct {
 	DstTx     TxInfo `json:"dstTx"`
 	ExtraData []byte `json:"extraData"`
 
-	TxParam bridge.TxParam `json:"txParam"`
+	TxParam  bridge.TxParam   `json:"txParam"`
+	TxParams []bridge.TxParam `json:"txParams"`
 }
 
 func (b *CBlock) EncodeRLP(w io.Writer) error {
@@ -57,6 +63,9 @@ func (b *CBlock) EncodeRLP(w io.Writer) error {
 		DstTx:      b.DstTx,
 		ExtraData:  b.ExtraData,
 		TxParam:    b.TxParam,
+		TxRoot:     b.TxRoot,
+		Txs:        b.Txs,
+		TxParams:   b.TxParams,
 	})
 }
 
@@ -77,6 +86,9 @@ func (b *CBlock) DecodeRLP(s *rlp.Stream) error {
 	b.DstTx = eb.DstTx
 	b.ExtraData = eb.ExtraData
 	b.TxParam = eb.TxParam
+	b.TxRoot = eb.TxRoot
+	b.Txs = eb.Txs
+	b.TxParams = eb.TxParams
 	return nil
 }
 
@@ -112,6 +124,9 @@ type extcblock struct {
 	DstTx      TxInfo
 	ExtraData  []byte
 	TxParam    bridge.TxParam
+	TxRoot     common.Hash      `rlp:"optional"`
+	Txs        []TxInfo         `rlp:"optional"`
+	TxParams   []bridge.TxParam `rlp:"optional"`
 }
 
 func (b *CBlock) Number() *big.Int {
@@ -127,12 +142,24 @@ func (b *CBlock) Hash() common.Hash {
 	}
 }
 
-func (b *CBlock) TxHash() common.Hash {
-	return common.BytesToHash(b.SrcTx.TxHash)
+// Transfers returns the source transactions carried by the block.
+func (b *CBlock) Transfers() []TxInfo {
+	return transfers(b.SrcTx, b.Txs)
+}
+
+func (b *CBlock) TxHashes() []common.Hash {
+	return txHashes(b.Transfers())
 }
 
-func (b *CBlock) GetTxParam() bridge.TxParam {
-	return b.TxParam
+// GetTxParams returns the parameters signed for each transfer, in the order of Transfers.
+func (b *CBlock) GetTxParams() []bridge.TxParam {
+	if len(b.Txs) > 0 {
+		return b.TxParams
+	}
+	if len(b.SrcTx.TxHash) == 0 {
+		return nil
+	}
+	return []bridge.TxParam{b.TxParam}
 }
 
 func (b *CBlock) ToBlock() *Block {
@@ -144,6 +171,8 @@ func (b *CBlock) ToBlock() *Block {
 		Time:       b.Time,
 		Type:       b.Type,
 		SrcTx:      b.SrcTx,
+		TxRoot:     b.TxRoot,
+		Txs:        b.Txs,
 		CBytes:     b.CBytes,
 		DstTx:      b.DstTx,
 		ExtraData:  b.ExtraData,
@@ -163,6 +192,9 @@ func (b *CBlock) UnmarshalJSON(input []byte) error {
 		DstTx      *TxInfo              `json:"dstTx"`
 		ExtraData  *hexutil.Bytes       `json:"extraData"`
 		TxParam    *bridge.TxParam      `json:"txParam"`
+		TxRoot     *common.Hash         `json:"txRoot"`
+		Txs        []TxInfo             `json:"txs"`
+		TxParams   []bridge.TxParam     `json:"txParams"`
 	}
 
 	var dec block
@@ -213,6 +245,13 @@ func (b *CBlock) UnmarshalJSON(input []byte) error {
 		b.TxParam = *dec.TxParam
 	}
 
+	if dec.TxRoot != nil {
+		b.TxRoot = *dec.TxRoot
+	}
+
+	b.Txs = dec.Txs
+	b.TxParams = dec.TxParams
+
 	return nil
 }
 
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"land-bridge/network/bridge"
+)
+
+// MaxBlockTransfers is the maximum number of transfers carried by a block.
+const MaxBlockTransfers = 32
+
+func transfers(srcTx TxInfo, txs []TxInfo) []TxInfo {
+	if len(txs) > 0 {
+		return txs
+	}
+	if len(srcTx.TxHash) == 0 {
+		return nil
+	}
+	return []TxInfo{srcTx}
+}
+
+func txHashes(txs []TxInfo) []common.Hash {
+	hashes := make([]common.Hash, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, common.BytesToHash(tx.TxHash))
+	}
+	return hashes
+}
+
+// DeriveTxRoot computes the binary merkle root over the transfers of a block.
+// Each leaf commits to the source transaction and the parameters validators
+// sign for it, so the sealed header fixes what is relayed.
+func DeriveTxRoot(txs []TxInfo, params []bridge.TxParam) common.Hash {
+	if len(txs) == 0 || len(txs) != len(params) {
+		return common.Hash{}
+	}
+	level := make([][]byte, 0, len(txs))
+	for i := range txs {
+		enc, err := rlp.EncodeToBytes(&txs[i])
+		if err != nil {
+			return common.Hash{}
+		}
+		level = append(level, crypto.Keccak256(enc, params[i].Hash()))
+	}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, crypto.Keccak256(level[i], level[i+1]))
+		}
+		level = next
+	}
+	return common.BytesToHash(level[0])
+}
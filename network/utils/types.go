@@ -39,6 +39,11 @@ type Block struct {
 	Type       TxType         `json:"type"`
 	SrcTx      TxInfo         `json:"srcTx"`
 
+	// Transfers of a multi-transfer block, committed to by TxRoot.
+	// SrcTx is only used by single-transfer blocks.
+	TxRoot common.Hash `json:"txRoot"`
+	Txs    []TxInfo    `json:"txs"`
+
 	// For consensus(lbft: vote)
 	CBytes LBFTBytes `json:"cBytes"`
 
@@ -59,6 +64,8 @@ type extblock struct {
 	CBytes     LBFTBytes
 	DstTx      TxInfo
 	ExtraData  []byte
+	TxRoot     common.Hash `rlp:"optional"`
+	Txs        []TxInfo    `rlp:"optional"`
 }
 
 func (b *Block) UnmarshalJSON(input []byte) error {
@@ -74,6 +81,8 @@ func (b *Block) UnmarshalJSON(input []byte) error {
 		DstTx      *TxInfo              `json:"dstTx"`
 		ExtraData  *hexutil.Bytes       `json:"extraData"`
 		TxParam    *bridge.TxParam      `json:"txParam"`
+		TxRoot     *common.Hash         `json:"txRoot"`
+		Txs        []TxInfo             `json:"txs"`
 	}
 
 	var dec block
@@ -120,6 +129,12 @@ func (b *Block) UnmarshalJSON(input []byte) error {
 		b.ExtraData = *dec.ExtraData
 	}
 
+	if dec.TxRoot != nil {
+		b.TxRoot = *dec.TxRoot
+	}
+
+	b.Txs = dec.Txs
+
 	return nil
 }
 
@@ -136,8 +151,13 @@ func (b *Block) Hash() common.Hash {
 	}
 }
 
-func (b *Block) TxHash() common.Hash {
-	return common.BytesToHash(b.SrcTx.TxHash)
+// Transfers returns the source transactions carried by the block.
+func (b *Block) Transfers() []TxInfo {
+	return transfers(b.SrcTx, b.Txs)
+}
+
+func (b *Block) TxHashes() []common.Hash {
+	return txHashes(b.Transfers())
 }
 
 func (b *Block) EncodeRLP(w io.Writer) error {
@@ -152,6 +172,8 @@ func (b *Block) EncodeRLP(w io.Writer) error {
 		CBytes:     b.CBytes,
 		DstTx:      b.DstTx,
 		ExtraData:  b.ExtraData,
+		TxRoot:     b.TxRoot,
+		Txs:        b.Txs,
 	})
 }
 
@@ -164,6 +186,8 @@ func (b *Block) ToCBlock() *CBlock {
 		Time:       b.Time,
 		Type:       b.Type,
 		SrcTx:      b.SrcTx,
+		TxRoot:     b.TxRoot,
+		Txs:        b.Txs,
 		CBytes:     b.CBytes,
 		DstTx:      b.DstTx,
 		ExtraData:  b.ExtraData,
@@ -186,6 +210,8 @@ func (b *Block) DecodeRLP(s *rlp.Stream) error {
 	b.CBytes = eb.CBytes
 	b.DstTx = eb.DstTx
 	b.ExtraData = eb.ExtraData
+	b.TxRoot = eb.TxRoot
+	b.Txs = eb.Txs
 	return nil
 }
 
@@ -304,15 +330,34 @@ type LBFTExtra struct {
 	Validators    []common.Address
 	Seal          []byte
 	CommittedSeal [][]byte
+	Consensus     *ConsensusParams // only set in the genesis block
+}
+
+// ConsensusParams are the LBFT parameters every node of a network must share.
+// The genesis block commits to them.
+type ConsensusParams struct {
+	BlockPeriod    uint64
+	ProposerPolicy uint64
+	Epoch          uint64
+	Ceil2Nby3Block uint64
+}
+
+// Hash is exchanged at handshake to detect peers running different parameters.
+func (cp *ConsensusParams) Hash() common.Hash {
+	return rlpHash(cp)
 }
 
 // EncodeRLP serializes ist into the Ethereum RLP format.
 func (le *LBFTExtra) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{
+	fields := []interface{}{
 		le.Validators,
 		le.Seal,
 		le.CommittedSeal,
-	})
+	}
+	if le.Consensus != nil {
+		fields = append(fields, le.Consensus)
+	}
+	return rlp.Encode(w, fields)
 }
 
 // DecodeRLP implements rlp.Decoder, and load the lbft fields from a RLP stream.
@@ -321,11 +366,13 @@ func (le *LBFTExtra) DecodeRLP(s *rlp.Stream) error {
 		Validators    []common.Address
 		Seal          []byte
 		CommittedSeal [][]byte
+		Consensus     *ConsensusParams `rlp:"optional"`
 	}
 	if err := s.Decode(&lbftExtra); err != nil {
 		return err
 	}
 	le.Validators, le.Seal, le.CommittedSeal = lbftExtra.Validators, lbftExtra.Seal, lbftExtra.CommittedSeal
+	le.Consensus = lbftExtra.Consensus
 	return nil
 }
 